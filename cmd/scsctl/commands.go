@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	config "scs-user/config"
+	"scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	services "scs-user/internal/services"
+	"scs-user/migrations"
+	"scs-user/pkg/migrate"
+	"scs-user/pkg/validation"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// app holds the services the commands run on
+type app struct {
+	db                 *gorm.DB
+	adminService       *services.AdminService
+	userPremiseService *services.UserPremiseService
+	signingKeyService  *services.SigningKeyService
+}
+
+func newApp(cfg *config.Config, db *gorm.DB) *app {
+	userRepo := repositories.NewUserRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	premiseRepo := repositories.NewPremiseRepository(db)
+	userPremiseRepo := repositories.NewUserPremiseRepository(db)
+	signingKeyRepo := repositories.NewSigningKeyRepository(db)
+	uow := repositories.NewUnitOfWork(db)
+	return &app{
+		db:                 db,
+		adminService:       services.NewAdminService(*userRepo, *sessionRepo, *uow),
+		userPremiseService: services.NewUserPremiseService(*userRepo, *premiseRepo, *userPremiseRepo, *uow),
+		signingKeyService:  services.NewSigningKeyService(cfg, *signingKeyRepo, *uow),
+	}
+}
+
+// userResult is the output of the commands that change a user
+type userResult struct {
+	Action string       `json:"action"`
+	User   *models.User `json:"user"`
+}
+
+func (r userResult) String() string {
+	return fmt.Sprintf("%s user %s (%s, %s, %s)", r.Action, r.User.ID, r.User.Email, r.User.Role, r.User.Status)
+}
+
+// newFlagSet returns the flag set of a command, errors are reported by main
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// parseFlags parses the arguments and checks that the required flags are set
+func parseFlags(flags *flag.FlagSet, args []string, required ...string) error {
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, flags.Arg(0))
+	}
+	for _, name := range required {
+		if flags.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("%w: -%s is required", errUsage, name)
+		}
+	}
+	return nil
+}
+
+func bootstrapAdmin(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	createAdminDto, err := parseCreateAdmin("bootstrap-admin", args)
+	if err != nil {
+		return nil, err
+	}
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.adminService.BootstrapAdmin(ctx, createAdminDto)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return skippedResult{Action: "skipped", Reason: "an admin already exists"}, nil
+	}
+	return userResult{Action: "created", User: user}, nil
+}
+
+// skippedResult is the output of bootstrap-admin when there is an admin already
+type skippedResult struct {
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+func (r skippedResult) String() string {
+	return fmt.Sprintf("Skipped, %s", r.Reason)
+}
+
+func createAdmin(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	createAdminDto, err := parseCreateAdmin("create-admin", args)
+	if err != nil {
+		return nil, err
+	}
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.adminService.CreateAdmin(ctx, createAdminDto)
+	if err != nil {
+		return nil, err
+	}
+	return userResult{Action: "created", User: user}, nil
+}
+
+func parseCreateAdmin(name string, args []string) (*dto.CreateAdminDto, error) {
+	flags := newFlagSet(name)
+	email := flags.String("email", "", "")
+	userName := flags.String("name", "", "")
+	password := flags.String("password", "", "")
+	passwordStdin := flags.Bool("password-stdin", false, "")
+	if err := parseFlags(flags, args, "email", "name"); err != nil {
+		return nil, err
+	}
+	passwordValue, err := readPassword(*password, *passwordStdin)
+	if err != nil {
+		return nil, err
+	}
+	createAdminDto := &dto.CreateAdminDto{Email: *email, Name: *userName, Password: passwordValue}
+	if err := validation.ValidateStruct(createAdminDto); err != nil {
+		return nil, err
+	}
+	return createAdminDto, nil
+}
+
+func resetPassword(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	flags := newFlagSet("reset-password")
+	userFlag := flags.String("user", "", "")
+	password := flags.String("password", "", "")
+	passwordStdin := flags.Bool("password-stdin", false, "")
+	if err := parseFlags(flags, args, "user"); err != nil {
+		return nil, err
+	}
+	passwordValue, err := readPassword(*password, *passwordStdin)
+	if err != nil {
+		return nil, err
+	}
+	setPasswordDto := &dto.SetPasswordDto{Password: passwordValue}
+	if err := validation.ValidateStruct(setPasswordDto); err != nil {
+		return nil, err
+	}
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.adminService.FindUser(ctx, *userFlag)
+	if err != nil {
+		return nil, err
+	}
+	if err := app.adminService.SetPassword(ctx, user.ID.String(), setPasswordDto); err != nil {
+		return nil, err
+	}
+	return userResult{Action: "reset password of", User: user}, nil
+}
+
+func activateUser(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	return setStatus(ctx, "activate", models.UserStatusActive, args, connect)
+}
+
+func suspendUser(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	return setStatus(ctx, "suspend", models.UserStatusSuspended, args, connect)
+}
+
+func setStatus(ctx context.Context, name string, status string, args []string, connect connectFunc) (any, error) {
+	flags := newFlagSet(name)
+	userFlag := flags.String("user", "", "")
+	if err := parseFlags(flags, args, "user"); err != nil {
+		return nil, err
+	}
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.adminService.FindUser(ctx, *userFlag)
+	if err != nil {
+		return nil, err
+	}
+	user, err = app.adminService.SetStatus(ctx, user.ID.String(), status)
+	if err != nil {
+		return nil, err
+	}
+	return userResult{Action: "updated", User: user}, nil
+}
+
+// assignmentResult is the output of assign-premise
+type assignmentResult struct {
+	Action     string              `json:"action"`
+	Assignment *models.UserPremise `json:"assignment"`
+}
+
+func (r assignmentResult) String() string {
+	return fmt.Sprintf("Assigned user %s to premise %s as %s", r.Assignment.UserID, r.Assignment.PremiseID, r.Assignment.Role)
+}
+
+func assignPremise(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	flags := newFlagSet("assign-premise")
+	userFlag := flags.String("user", "", "")
+	premiseID := flags.String("premise", "", "")
+	role := flags.String("role", "", "")
+	startsAt := flags.String("starts-at", "", "")
+	endsAt := flags.String("ends-at", "", "")
+	if err := parseFlags(flags, args, "user", "premise"); err != nil {
+		return nil, err
+	}
+	assignPremiseDto := &dto.AssignPremiseDto{PremiseID: *premiseID, Role: *role}
+	var err error
+	if assignPremiseDto.StartsAt, err = parseTime("starts-at", *startsAt); err != nil {
+		return nil, err
+	}
+	if assignPremiseDto.EndsAt, err = parseTime("ends-at", *endsAt); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateStruct(assignPremiseDto); err != nil {
+		return nil, err
+	}
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.adminService.FindUser(ctx, *userFlag)
+	if err != nil {
+		return nil, err
+	}
+	userPremise, err := app.userPremiseService.AssignPremise(ctx, user.ID.String(), assignPremiseDto)
+	if err != nil {
+		return nil, err
+	}
+	return assignmentResult{Action: "assigned", Assignment: userPremise}, nil
+}
+
+// parseTime parses an optional RFC 3339 time flag
+func parseTime(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: -%s must be an RFC 3339 time like 2006-01-02T15:04:05Z", errUsage, name)
+	}
+	return &t, nil
+}
+
+// revokedResult is the output of revoke-sessions
+type revokedResult struct {
+	Action  string `json:"action"`
+	UserID  string `json:"user_id"`
+	Revoked int64  `json:"revoked"`
+}
+
+func (r revokedResult) String() string {
+	return fmt.Sprintf("Revoked %d sessions of user %s", r.Revoked, r.UserID)
+}
+
+func revokeSessions(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	flags := newFlagSet("revoke-sessions")
+	userFlag := flags.String("user", "", "")
+	if err := parseFlags(flags, args, "user"); err != nil {
+		return nil, err
+	}
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	user, err := app.adminService.FindUser(ctx, *userFlag)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := app.adminService.RevokeSessions(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return revokedResult{Action: "revoked", UserID: user.ID.String(), Revoked: revoked}, nil
+}
+
+// signingKeyResult is the output of rotate-signing-key, the secret is never printed
+type signingKeyResult struct {
+	Action   string    `json:"action"`
+	KeyID    string    `json:"key_id"`
+	ActiveAt time.Time `json:"active_at"`
+}
+
+func (r signingKeyResult) String() string {
+	return fmt.Sprintf("Created signing key %s, it signs tokens from %s", r.KeyID, r.ActiveAt.Format(time.RFC3339))
+}
+
+func rotateSigningKey(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	if err := parseFlags(newFlagSet("rotate-signing-key"), args); err != nil {
+		return nil, err
+	}
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	key, err := app.signingKeyService.Rotate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return signingKeyResult{Action: "rotated", KeyID: key.ID.String(), ActiveAt: key.CreatedAt.Add(app.signingKeyService.ActivationDelay())}, nil
+}
+
+// migrationsResult is the output of migrate up and down
+type migrationsResult struct {
+	Action     string            `json:"action"`
+	Migrations []migrationResult `json:"migrations"`
+}
+
+type migrationResult struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Missing   bool       `json:"missing,omitempty"`
+}
+
+func (r migrationsResult) String() string {
+	if len(r.Migrations) == 0 {
+		return "No migrations " + r.Action
+	}
+	lines := make([]string, len(r.Migrations))
+	for i, migration := range r.Migrations {
+		lines[i] = fmt.Sprintf("%s %06d_%s", r.Action, migration.Version, migration.Name)
+		if r.Action != "status" {
+			continue
+		}
+		state := "pending"
+		if migration.AppliedAt != nil {
+			state = "applied " + migration.AppliedAt.Format(time.RFC3339)
+		}
+		if migration.Missing {
+			state += " (file missing)"
+		}
+		lines[i] = fmt.Sprintf("%06d_%s\t%s", migration.Version, migration.Name, state)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func runMigrate(ctx context.Context, args []string, connect connectFunc) (any, error) {
+	steps := 1
+	switch {
+	case len(args) == 1 && (args[0] == "up" || args[0] == "down" || args[0] == "status"):
+	case len(args) == 2 && args[0] == "down":
+		var err error
+		steps, err = strconv.Atoi(args[1])
+		if err != nil || steps < 1 {
+			return nil, fmt.Errorf("%w: invalid number of steps %q", errUsage, args[1])
+		}
+	default:
+		return nil, errUsage
+	}
+
+	app, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := app.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrate.New(sqlDB, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		return migrationsOf("applied", applied), err
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		return migrationsOf("reverted", reverted), err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result := migrationsResult{Action: "status", Migrations: make([]migrationResult, len(statuses))}
+		for i, status := range statuses {
+			result.Migrations[i] = migrationResult{Version: status.Version, Name: status.Name, AppliedAt: status.AppliedAt, Missing: status.Missing}
+		}
+		return result, nil
+	}
+}
+
+func migrationsOf(action string, done []migrate.Migration) migrationsResult {
+	result := migrationsResult{Action: action, Migrations: make([]migrationResult, len(done))}
+	for i, migration := range done {
+		result.Migrations[i] = migrationResult{Version: migration.Version, Name: migration.Name}
+	}
+	return result
+}
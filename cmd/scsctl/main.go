@@ -0,0 +1,193 @@
+// Command scsctl runs operator tasks against the database of the service: bootstrapping the first
+// admin, managing users, rotating the token signing keys and running migrations. It reads the
+// same configuration as the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	config "scs-user/config"
+	"scs-user/pkg/db"
+	"scs-user/pkg/errors"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Exit codes
+const (
+	exitFailed = 1 // The command failed
+	exitUsage  = 2 // The command line is invalid
+)
+
+// errUsage is returned by commands called with invalid arguments
+var errUsage = stderrors.New("invalid usage")
+
+// command is a subcommand of scsctl
+type command struct {
+	usage       string
+	description string
+	run         func(ctx context.Context, args []string, connect connectFunc) (any, error)
+}
+
+// connectFunc connects to the database, commands call it once their arguments are valid
+type connectFunc func() (*app, error)
+
+var commands = map[string]command{
+	"bootstrap-admin":    {"-email EMAIL -name NAME [-password PASSWORD | -password-stdin]", "Create an admin unless one exists", bootstrapAdmin},
+	"create-admin":       {"-email EMAIL -name NAME [-password PASSWORD | -password-stdin]", "Create an admin", createAdmin},
+	"reset-password":     {"-user USER [-password PASSWORD | -password-stdin]", "Set the password of a user and revoke the sessions", resetPassword},
+	"activate":           {"-user USER", "Activate a user", activateUser},
+	"suspend":            {"-user USER", "Suspend a user and revoke the sessions", suspendUser},
+	"assign-premise":     {"-user USER -premise PREMISE_ID [-role guard|supervisor] [-starts-at TIME] [-ends-at TIME]", "Assign a user to a premise", assignPremise},
+	"revoke-sessions":    {"-user USER", "Revoke all sessions of a user", revokeSessions},
+	"rotate-signing-key": {"", "Create a new token signing key and retire the previous ones", rotateSigningKey},
+	"migrate":            {"up | down [steps] | status", "Apply, revert or list the database migrations", runMigrate},
+}
+
+func main() {
+	flags := flag.NewFlagSet("scsctl", flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, "Print the result as JSON")
+	flags.Usage = func() { printUsage(flags.Output()) }
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(exitUsage)
+	}
+	if flags.NArg() == 0 {
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := execute(ctx, cmd, flags.Args()[1:])
+	if stderrors.Is(err, errUsage) {
+		fmt.Fprintf(os.Stderr, "%v\nUsage: scsctl [-json] %s %s\n", err, name, cmd.usage)
+		os.Exit(exitUsage)
+	}
+	if err != nil {
+		printError(*jsonOutput, err)
+		os.Exit(exitFailed)
+	}
+	printResult(*jsonOutput, result)
+}
+
+// execute runs the command and closes the database connection it opened
+func execute(ctx context.Context, cmd command, args []string) (any, error) {
+	var psqlDb *gorm.DB
+	defer func() {
+		if psqlDb == nil {
+			return
+		}
+		if sqlDB, err := psqlDb.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+	return cmd.run(ctx, args, func() (*app, error) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		psqlDb, err = db.NewGormDB(cfg)
+		if err != nil {
+			return nil, err
+		}
+		// Expected errors like unknown users are reported by the command, the output stays parseable
+		psqlDb = psqlDb.Session(&gorm.Session{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+		return newApp(cfg, psqlDb), nil
+	})
+}
+
+// loadConfig reads the configuration the same way as the server
+func loadConfig() (*config.Config, error) {
+	if os.Getenv("ENV") != "production" {
+		// A missing .env file is fine, the environment is used as is
+		_ = godotenv.Load()
+	}
+	var cfg config.Config
+	if err := env.Parse(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: scsctl [-json] COMMAND [ARGS]")
+	fmt.Fprintln(w, "\nUSER is the ID or the email address of a user.\n\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-20s %s\n", name, commands[name].description)
+		if commands[name].usage != "" {
+			fmt.Fprintf(w, "  %-20s   %s\n", "", commands[name].usage)
+		}
+	}
+}
+
+// printResult prints the result as indented JSON, or as text if it implements fmt.Stringer
+func printResult(jsonOutput bool, result any) {
+	if stringer, ok := result.(fmt.Stringer); ok && !jsonOutput {
+		fmt.Println(stringer.String())
+		return
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Fatalf("Failed to print result: %v", err)
+	}
+}
+
+// printError prints the error to stderr. Application errors keep their type in JSON output.
+func printError(jsonOutput bool, err error) {
+	if !jsonOutput {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	output := map[string]any{"type": errors.ErrorTypeInternal, "message": err.Error()}
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		output = map[string]any{"type": appErr.Type, "message": appErr.Message, "details": appErr.Details}
+		if appErr.Err != nil {
+			output["cause"] = appErr.Err.Error()
+		}
+	}
+	_ = json.NewEncoder(os.Stderr).Encode(map[string]any{"error": output})
+}
+
+// readPassword returns the password flag, or the first line of stdin with -password-stdin
+func readPassword(password string, fromStdin bool) (string, error) {
+	if fromStdin == (password != "") {
+		return "", fmt.Errorf("%w: pass either -password or -password-stdin", errUsage)
+	}
+	if password != "" {
+		return password, nil
+	}
+	content, err := io.ReadAll(io.LimitReader(os.Stdin, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	line, _, _ := strings.Cut(string(content), "\n")
+	return strings.TrimRight(line, "\r"), nil
+}
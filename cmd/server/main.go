@@ -2,13 +2,11 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	config "scs-user/config"
-	"scs-user/internal/models"
 	"scs-user/internal/server"
 	"scs-user/pkg/db"
 	kafka_client "scs-user/pkg/kafka"
@@ -47,30 +45,31 @@ func main() {
 		appLogger.Info("Postgres connected")
 	}
 
-	// Auto-migrate models
-	err = psqlDb.AutoMigrate(
-		&models.User{},
-	)
-	if err != nil {
-		appLogger.Fatalf("Database migration failed: %s", err)
+	// The migrate subcommand only runs the migrations
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), psqlDb, appLogger, os.Args[2:]); err != nil {
+			appLogger.Fatalf("Migrate: %v", err)
+		}
+		return
 	}
-
-	// Initialize Kafka producer
-	producer := startKafkaProducer("user.created", &cfg, appLogger)
-
-	// Test sending a Kafka message after producer initialization
-	ctx := context.Background()
-	err = producer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte("test-key"),
-		Value: []byte("Hello, Kafka!"),
-	})
-	if err != nil {
-		appLogger.Errorf("Failed to send Kafka message: %v", err)
-	} else {
-		appLogger.Info("Kafka message sent successfully")
+	if cfg.Database.MigrateOnStart {
+		migrator, err := newMigrator(psqlDb)
+		if err != nil {
+			appLogger.Fatalf("Database migration failed: %s", err)
+		}
+		if err := migrateUp(context.Background(), migrator, appLogger); err != nil {
+			appLogger.Fatalf("Database migration failed: %s", err)
+		}
 	}
+
+	// Initialize the event publisher
+	producer := startPublisher(&cfg, appLogger)
+
+	// Initialize Kafka consumer and the dead-letter producer, the consumer only runs when topics are configured
+	consumer, dlq := startKafkaConsumer(&cfg)
+
 	// Initialize the server
-	s := server.NewServer(&cfg, psqlDb, appLogger, producer)
+	s := server.NewServer(&cfg, psqlDb, appLogger, producer, consumer, dlq)
 
 	// Create a channel to listen for OS signals
 	quit := make(chan os.Signal, 1)
@@ -96,21 +95,72 @@ func main() {
 	if err := s.Shutdown(serverShutdownCtx); err != nil {
 		appLogger.Errorf("Server shutdown failed: %v", err)
 	}
+	if consumer != nil {
+		if err := consumer.Close(); err != nil {
+			appLogger.Errorf("Failed to close Kafka consumer: %v", err)
+		}
+		if err := dlq.Close(); err != nil {
+			appLogger.Errorf("Failed to close Kafka dead-letter producer: %v", err)
+		}
+	}
+	if err := producer.Close(); err != nil {
+		appLogger.Errorf("Failed to close Kafka producer: %v", err)
+	}
 
 	appLogger.Info("Server and consumer stopped.")
 }
-func startKafkaProducer(topic string, cfg *config.Config, logger *logger.ApiLogger) *kafka_client.Producer {
+func startPublisher(cfg *config.Config, logger *logger.ApiLogger) kafka_client.Publisher {
+	switch cfg.Kafka.Publisher {
+	case "kafka":
+		return startKafkaProducer(cfg, logger)
+	case "log":
+		logger.Warn("Events are logged instead of published to Kafka")
+		return kafka_client.NewLogPublisher(logger)
+	default:
+		logger.Fatalf("Unknown publisher %q, expected kafka or log", cfg.Kafka.Publisher)
+		return nil
+	}
+}
+
+func startKafkaProducer(cfg *config.Config, logger *logger.ApiLogger) *kafka_client.Producer {
 	// Initialize Kafka producer
-	fmt.Println("topic:", topic)
 	kafkaCfg := kafka_client.Config{
 		Brokers: strings.Split(cfg.Kafka.Brokers, ","),
-		Topic:   topic,
+		Topic:   cfg.Kafka.DefaultTopic,
 	}
 	producerCfg := kafka_client.ProducerConfig{
-		BatchSize:    1,
-		BatchTimeout: 100,   // In milliseconds
-		Async:        false, // Set to false for immediate delivery
+		BatchSize:    cfg.Kafka.ProducerBatchSize,
+		BatchTimeout: cfg.Kafka.ProducerBatchTimeout,
+		Async:        cfg.Kafka.ProducerAsync,
+		RequiredAcks: &cfg.Kafka.ProducerRequiredAcks,
+		Topics:       cfg.Kafka.TopicRoutes,
+		OnDelivery: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logger.Errorf("Failed to deliver %d Kafka messages: %v", len(messages), err)
+			}
+		},
 	}
 	producer := kafka_client.NewProducer(&kafkaCfg, &producerCfg)
 	return producer
 }
+
+func startKafkaConsumer(cfg *config.Config) (*kafka_client.Consumer, kafka_client.Publisher) {
+	if len(cfg.Kafka.ConsumerTopics) == 0 {
+		return nil, nil
+	}
+	kafkaCfg := kafka_client.Config{
+		Brokers: strings.Split(cfg.Kafka.Brokers, ","),
+	}
+	consumerCfg := kafka_client.ConsumerConfig{
+		Topics:         cfg.Kafka.ConsumerTopics,
+		GroupID:        cfg.Kafka.ConsumerGroupID,
+		MinBytes:       1,
+		MaxBytes:       10e6,
+		CommitInterval: 0, // Commit synchronously once a message is processed
+		StartOffset:    kafka.FirstOffset,
+	}
+	consumer := kafka_client.NewConsumer(&kafkaCfg, &consumerCfg)
+	// Without a topic the messages name their dead-letter topic
+	dlq := kafka_client.NewProducer(&kafkaCfg, &kafka_client.ProducerConfig{BatchSize: 1})
+	return consumer, dlq
+}
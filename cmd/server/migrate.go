@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/migrations"
+	"scs-user/pkg/logger"
+	"scs-user/pkg/migrate"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newMigrator returns the migrator of the embedded migrations for the database
+func newMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(sqlDB, migrations.FS)
+}
+
+// migrateUp applies the pending migrations, waiting for other replicas that are migrating
+func migrateUp(ctx context.Context, migrator *migrate.Migrator, logger logger.Logger) error {
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		logger.Infof("Applied migration %d_%s", migration.Version, migration.Name)
+	}
+	return err
+}
+
+// runMigrate runs the migrate subcommand: up, down [steps] or status
+func runMigrate(ctx context.Context, db *gorm.DB, logger logger.Logger, args []string) error {
+	usage := errors.New("usage: server migrate up | down [steps] | status")
+	if len(args) == 0 {
+		return usage
+	}
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return usage
+		}
+		if err := migrateUp(ctx, migrator, logger); err != nil {
+			return err
+		}
+		logger.Info("Database is up to date")
+		return nil
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		} else if len(args) > 2 {
+			return usage
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			logger.Infof("Reverted migration %d_%s", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Missing {
+				state += " (file missing)"
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return usage
+	}
+}
@@ -1,26 +1,62 @@
 package config
 
 import (
+	"net/netip"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Logger   Logger
-	Kafka    KafkaConfig
+	Server        ServerConfig
+	Database      DatabaseConfig
+	Logger        Logger
+	Kafka         KafkaConfig
+	Upload        UploadConfig
+	Storage       StorageConfig
+	Shift         ShiftConfig
+	Certification CertificationConfig
+	Outbox        OutboxConfig
+	Webhook       WebhookConfig
+	Auth          AuthConfig
+	Mail          MailConfig
 }
 type KafkaConfig struct {
 	Brokers string `env:"KAFKA_BROKERS"`
+	// Publisher is kafka to publish events, or log to only log them for local development
+	Publisher string `env:"KAFKA_PUBLISHER" envDefault:"kafka"`
+	// DefaultTopic receives the events of the types without a route in TopicRoutes
+	DefaultTopic string `env:"KAFKA_DEFAULT_TOPIC" envDefault:"scs-user.events"`
+	// TopicRoutes maps event types to topics, as a list of type=topic pairs. user.snapshot must be
+	// routed to a log-compacted topic.
+	TopicRoutes          map[string]string `env:"KAFKA_TOPIC_ROUTES" envSeparator:"," envKeyValSeparator:"=" envDefault:"user.snapshot=users.state"`
+	ProducerBatchSize    int               `env:"KAFKA_PRODUCER_BATCH_SIZE" envDefault:"100"`
+	ProducerBatchTimeout int               `env:"KAFKA_PRODUCER_BATCH_TIMEOUT" envDefault:"10"` // In milliseconds
+	// ProducerAsync returns from writes before the messages are delivered. Failed deliveries are
+	// then only logged and not retried by the outbox relay.
+	ProducerAsync bool `env:"KAFKA_PRODUCER_ASYNC" envDefault:"false"`
+	// ProducerRequiredAcks is -1 to wait for all in-sync replicas, 1 for the leader only and 0 for none
+	ProducerRequiredAcks int `env:"KAFKA_PRODUCER_REQUIRED_ACKS" envDefault:"-1"`
+	// ConsumerTopics lists the topics consumed by the service, none disables the consumer
+	ConsumerTopics          []string      `env:"KAFKA_CONSUMER_TOPICS" envSeparator:","`
+	ConsumerGroupID         string        `env:"KAFKA_CONSUMER_GROUP_ID" envDefault:"scs-user"`
+	ConsumerMaxRetries      int           `env:"KAFKA_CONSUMER_MAX_RETRIES" envDefault:"5"`
+	ConsumerRetryBackoff    time.Duration `env:"KAFKA_CONSUMER_RETRY_BACKOFF" envDefault:"500ms"`
+	ConsumerMaxRetryBackoff time.Duration `env:"KAFKA_CONSUMER_MAX_RETRY_BACKOFF" envDefault:"30s"`
+	// DLQSuffix is appended to a topic to name its dead-letter topic
+	DLQSuffix string `env:"KAFKA_DLQ_SUFFIX" envDefault:".dlq"`
+	// ValueFormat is json to publish plain JSON events, or json-schema to register the schemas of
+	// catalogued events in the schema registry and publish them in its wire format
+	ValueFormat           string        `env:"KAFKA_VALUE_FORMAT" envDefault:"json"`
+	SchemaRegistryURL     string        `env:"KAFKA_SCHEMA_REGISTRY_URL"`
+	SchemaRegistryTimeout time.Duration `env:"KAFKA_SCHEMA_REGISTRY_TIMEOUT" envDefault:"10s"`
 }
 
 // Logger config
 type Logger struct {
-	Development       bool   `env:LOG_DEVELOPMENT`
-	DisableCaller     bool   `env:LOG_DISABLE_CALLER default:"false"`
-	DisableStacktrace bool   `env:LOG_DISABLE_STACKTRACE default:"false"`
-	Encoding          string `env:LOG_ENCODING`
-	Level             string `env:LOG_LEVEL`
+	Development       bool   `env:"LOG_DEVELOPMENT"`
+	DisableCaller     bool   `env:"LOG_DISABLE_CALLER" envDefault:"false"`
+	DisableStacktrace bool   `env:"LOG_DISABLE_STACKTRACE" envDefault:"false"`
+	Encoding          string `env:"LOG_ENCODING"`
+	Level             string `env:"LOG_LEVEL"`
 }
 type ServerConfig struct {
 	Port         string        `env:"PORT"`
@@ -29,10 +65,105 @@ type ServerConfig struct {
 	WriteTimeout time.Duration `env:"WRITE_TIMEOUT"`
 }
 
+type UploadConfig struct {
+	Dir             string `env:"UPLOAD_DIR" envDefault:"./uploads"`
+	MaxAvatarSize   int64  `env:"UPLOAD_MAX_AVATAR_SIZE" envDefault:"5242880"`    // In bytes
+	MaxAvatarPixels int    `env:"UPLOAD_MAX_AVATAR_PIXELS" envDefault:"25000000"` // Width times height
+	MaxEvidenceSize int64  `env:"UPLOAD_MAX_EVIDENCE_SIZE" envDefault:"52428800"` // In bytes
+	MaxDocumentSize int64  `env:"UPLOAD_MAX_DOCUMENT_SIZE" envDefault:"10485760"` // In bytes
+}
+
+type StorageConfig struct {
+	Driver         string        `env:"STORAGE_DRIVER" envDefault:"local"` // local or s3
+	PublicBaseURL  string        `env:"STORAGE_PUBLIC_BASE_URL" envDefault:"/api/v1/files"`
+	SigningSecret  string        `env:"STORAGE_SIGNING_SECRET"` // Signs the download URLs of local storage, at least 32 characters
+	SignedURLTTL   time.Duration `env:"STORAGE_SIGNED_URL_TTL" envDefault:"15m"`
+	ThumbnailSizes []int         `env:"STORAGE_THUMBNAIL_SIZES" envDefault:"64,128,256" envSeparator:","`
+	S3Endpoint     string        `env:"S3_ENDPOINT"`
+	S3Region       string        `env:"S3_REGION"`
+	S3Bucket       string        `env:"S3_BUCKET"`
+	S3AccessKey    string        `env:"S3_ACCESS_KEY"`
+	S3SecretKey    string        `env:"S3_SECRET_KEY"`
+	S3UsePathStyle bool          `env:"S3_USE_PATH_STYLE" envDefault:"true"`
+}
+
+type ShiftConfig struct {
+	ClockInWindow  time.Duration `env:"SHIFT_CLOCK_IN_WINDOW" envDefault:"15m"`   // How early before its start a shift can be clocked into
+	OverlapHorizon time.Duration `env:"SHIFT_OVERLAP_HORIZON" envDefault:"2160h"` // How far ahead open-ended schedules are checked for overlaps
+	ClockOutGrace  time.Duration `env:"SHIFT_CLOCK_OUT_GRACE" envDefault:"2h"`    // How long after the end of a shift a user who has not clocked out still counts as on duty
+}
+
+type CertificationConfig struct {
+	ExpiryNoticeDays    int           `env:"CERTIFICATION_EXPIRY_NOTICE_DAYS" envDefault:"30"`    // How many days ahead certification.expiring is published
+	ExpiryCheckInterval time.Duration `env:"CERTIFICATION_EXPIRY_CHECK_INTERVAL" envDefault:"1h"` // How often expiring certifications are looked for
+	// RequiredForClockIn lists the certification types a user needs to clock in to a shift, none are required if empty
+	RequiredForClockIn []string `env:"CERTIFICATION_REQUIRED_FOR_CLOCK_IN" envSeparator:","`
+}
+
+type OutboxConfig struct {
+	PollInterval    time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`    // How often the relay looks for unpublished events
+	BatchSize       int           `env:"OUTBOX_BATCH_SIZE" envDefault:"500"`      // How many events the relay reads per run
+	RetryBaseDelay  time.Duration `env:"OUTBOX_RETRY_BASE_DELAY" envDefault:"1s"` // Delay before the first retry, doubled on every further attempt
+	RetryMaxDelay   time.Duration `env:"OUTBOX_RETRY_MAX_DELAY" envDefault:"5m"`  // Upper bound of the retry delay
+	MaxAttempts     int           `env:"OUTBOX_MAX_ATTEMPTS" envDefault:"25"`     // How often an event is attempted before it is set aside as failed
+	Retention       time.Duration `env:"OUTBOX_RETENTION" envDefault:"168h"`      // How long published events are kept
+	CleanupInterval time.Duration `env:"OUTBOX_CLEANUP_INTERVAL" envDefault:"1h"` // How often published events past the retention are deleted
+}
+
+type WebhookConfig struct {
+	PollInterval   time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"1s"`     // How often the worker looks for due deliveries
+	BatchSize      int           `env:"WEBHOOK_BATCH_SIZE" envDefault:"100"`       // How many deliveries the worker attempts per run
+	Concurrency    int           `env:"WEBHOOK_CONCURRENCY" envDefault:"10"`       // How many deliveries are sent at the same time
+	Timeout        time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`          // How long an endpoint has to respond
+	MaxAttempts    int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"8"`       // After how many attempts a delivery fails
+	RetryBaseDelay time.Duration `env:"WEBHOOK_RETRY_BASE_DELAY" envDefault:"10s"` // Delay before the first retry, doubled on every further attempt
+	RetryMaxDelay  time.Duration `env:"WEBHOOK_RETRY_MAX_DELAY" envDefault:"1h"`   // Upper bound of the retry delay
+	// DisableAfterFailures is the number of consecutive failed attempts after which a subscription is disabled
+	DisableAfterFailures int           `env:"WEBHOOK_DISABLE_AFTER_FAILURES" envDefault:"50"`
+	Retention            time.Duration `env:"WEBHOOK_RETENTION" envDefault:"720h"`      // How long finished deliveries are kept in the log
+	CleanupInterval      time.Duration `env:"WEBHOOK_CLEANUP_INTERVAL" envDefault:"1h"` // How often finished deliveries past the retention are deleted
+	// AllowedNetworks lists the CIDRs of loopback, private or link-local networks that endpoints
+	// may be in, e.g. for an on-premises receiver. Other internal addresses are refused.
+	AllowedNetworks []netip.Prefix `env:"WEBHOOK_ALLOWED_NETWORKS" envSeparator:","`
+}
+
+type AuthConfig struct {
+	MaxFailedLogins  int           `env:"AUTH_MAX_FAILED_LOGINS" envDefault:"5"`   // After how many failed logins in a row an account is locked
+	LockoutDuration  time.Duration `env:"AUTH_LOCKOUT_DURATION" envDefault:"15m"`  // How long a locked account can not log in
+	PasswordResetTTL time.Duration `env:"AUTH_PASSWORD_RESET_TTL" envDefault:"1h"` // How long a password reset link is valid
+	// SigningKeyRefreshInterval is how often the signing keys are reloaded. A new key only signs
+	// tokens once it is this old, so that every replica accepts them by then.
+	SigningKeyRefreshInterval time.Duration `env:"AUTH_SIGNING_KEY_REFRESH_INTERVAL" envDefault:"1m"`
+}
+
+type MailConfig struct {
+	// Provider is smtp to send emails, or log to only log them for local development
+	Provider string `env:"MAIL_PROVIDER" envDefault:"log"`
+	From     string `env:"MAIL_FROM" envDefault:"Smart City <no-reply@localhost>"`
+	// AppBaseURL is the address of the web app the links in emails point to
+	AppBaseURL    string `env:"MAIL_APP_BASE_URL" envDefault:"http://localhost:3000"`
+	DefaultLocale string `env:"MAIL_DEFAULT_LOCALE" envDefault:"en"` // Used for users without a locale or a locale without templates
+	SMTPHost      string `env:"SMTP_HOST"`
+	SMTPPort      int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername  string `env:"SMTP_USERNAME"`
+	SMTPPassword  string `env:"SMTP_PASSWORD"`
+	// SMTPTLS is starttls to upgrade the connection, tls for implicit TLS or none
+	SMTPTLS         string        `env:"SMTP_TLS" envDefault:"starttls"`
+	PollInterval    time.Duration `env:"MAIL_POLL_INTERVAL" envDefault:"1s"`     // How often queued emails are looked for
+	BatchSize       int           `env:"MAIL_BATCH_SIZE" envDefault:"50"`        // How many emails are sent per run
+	MaxAttempts     int           `env:"MAIL_MAX_ATTEMPTS" envDefault:"10"`      // After how many attempts an email is given up
+	RetryBaseDelay  time.Duration `env:"MAIL_RETRY_BASE_DELAY" envDefault:"30s"` // Delay before the first retry, doubled on every further attempt
+	RetryMaxDelay   time.Duration `env:"MAIL_RETRY_MAX_DELAY" envDefault:"1h"`   // Upper bound of the retry delay
+	Retention       time.Duration `env:"MAIL_RETENTION" envDefault:"168h"`       // How long sent emails are kept
+	CleanupInterval time.Duration `env:"MAIL_CLEANUP_INTERVAL" envDefault:"1h"`  // How often sent emails past the retention are deleted
+}
+
 type DatabaseConfig struct {
 	DbHost     string `env:"DB_HOST"`
 	DbPort     string `env:"DB_PORT"`
 	DbUser     string `env:"DB_USER"`
 	DbPassword string `env:"DB_PASSWORD"`
 	DbName     string `env:"DB_NAME"`
+	// MigrateOnStart applies pending migrations when the server starts, disable it to run them with the migrate command instead
+	MigrateOnStart bool `env:"DB_MIGRATE_ON_START" envDefault:"true"`
 }
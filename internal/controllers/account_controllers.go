@@ -0,0 +1,77 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type AccountHandler struct {
+	svc services.AccountService
+}
+
+// NewHandler constructor
+func NewAccountHandler(svc services.AccountService) *AccountHandler {
+	return &AccountHandler{svc: svc}
+}
+
+func (h *AccountHandler) ChangePassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		sessionId := c.Get("session_id").(string)
+		changePasswordDto := &dto.ChangePasswordDto{}
+		if err := c.Bind(changePasswordDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(changePasswordDto); err != nil {
+			return err
+		}
+
+		if err := h.svc.ChangePassword(c.Request().Context(), userId, sessionId, changePasswordDto); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *AccountHandler) ChangeEmail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		changeEmailDto := &dto.ChangeEmailDto{}
+		if err := c.Bind(changeEmailDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(changeEmailDto); err != nil {
+			return err
+		}
+
+		if err := h.svc.RequestEmailChange(c.Request().Context(), userId, changeEmailDto); err != nil {
+			return err
+		}
+		return c.JSON(202, "verification sent")
+	}
+}
+
+func (h *AccountHandler) ConfirmEmailChange() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		confirmEmailChangeDto := &dto.ConfirmEmailChangeDto{}
+		if err := c.Bind(confirmEmailChangeDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(confirmEmailChangeDto); err != nil {
+			return err
+		}
+
+		if err := h.svc.ConfirmEmailChange(c.Request().Context(), confirmEmailChangeDto.Token); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
@@ -0,0 +1,13 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *AccountHandler) RegisterRoutes(g *echo.Group, mw *middleware.MiddlewareManager) {
+	g.POST("/me/password", mw.JWTAuth(h.ChangePassword()))
+	g.POST("/me/email", mw.JWTAuth(h.ChangeEmail()))
+	g.POST("/me/email/confirm", h.ConfirmEmailChange())
+}
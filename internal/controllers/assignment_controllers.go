@@ -0,0 +1,114 @@
+package http
+
+import (
+	"mime/multipart"
+	"net/http"
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type AssignmentHandler struct {
+	svc services.AssignmentService
+}
+
+// NewHandler constructor
+func NewAssignmentHandler(svc services.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{svc: svc}
+}
+
+func (h *AssignmentHandler) CreateAssignment() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		createAssignmentDto := &dto.CreateAssignmentDto{}
+		if err := c.Bind(createAssignmentDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(createAssignmentDto); err != nil {
+			return err
+		}
+
+		assignment, err := h.svc.CreateAssignment(c.Request().Context(), userId, createAssignmentDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, assignment)
+	}
+}
+
+func (h *AssignmentHandler) GetAssignment() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		role := c.Get("role").(string)
+		assignment, err := h.svc.GetAssignment(c.Request().Context(), c.Param("assignmentId"), userId, role)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, assignment)
+	}
+}
+
+func (h *AssignmentHandler) GetMyAssignments() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		filter := dto.AssignmentFilter{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(filter); err != nil {
+			return err
+		}
+
+		assignments, err := h.svc.GetUserAssignments(c.Request().Context(), userId, filter)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, assignments)
+	}
+}
+
+func (h *AssignmentHandler) CancelAssignment() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		assignment, err := h.svc.CancelAssignment(c.Request().Context(), c.Param("assignmentId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, assignment)
+	}
+}
+
+// CompleteStep accepts a JSON body or a multipart form with an optional "evidence" file
+func (h *AssignmentHandler) CompleteStep() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		completeStepDto := &dto.CompleteStepDto{}
+		if err := c.Bind(completeStepDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(completeStepDto); err != nil {
+			return err
+		}
+
+		var evidence *multipart.FileHeader
+		if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+			file, err := c.FormFile("evidence")
+			if err != nil && err != http.ErrMissingFile {
+				return errors.NewBadRequestError("Invalid evidence file")
+			}
+			evidence = file
+		}
+
+		assignment, err := h.svc.CompleteStep(c.Request().Context(), userId, c.Param("assignmentId"), c.Param("stepId"), completeStepDto, evidence)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, assignment)
+	}
+}
@@ -0,0 +1,15 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *AssignmentHandler) RegisterRoutes(users *echo.Group, assignments *echo.Group, mw *middleware.MiddlewareManager) {
+	users.GET("/me/assignments", mw.JWTAuth(h.GetMyAssignments()))
+	assignments.POST("", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.CreateAssignment())))
+	assignments.GET("/:assignmentId", mw.JWTAuth(h.GetAssignment()))
+	assignments.POST("/:assignmentId/cancel", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.CancelAssignment())))
+	assignments.POST("/:assignmentId/steps/:stepId/complete", mw.JWTAuth(h.CompleteStep()))
+}
@@ -30,7 +30,7 @@ func (h *AuthHandler) Login() echo.HandlerFunc {
 		if err := validation.ValidateStruct(loginReq); err != nil {
 			return err
 		}
-		token, err := h.svc.Login(c.Request().Context(), &loginReq)
+		token, err := h.svc.Login(c.Request().Context(), &loginReq, c.Request().UserAgent(), c.RealIP())
 		if err != nil {
 			return err
 		}
@@ -56,3 +56,39 @@ func (h *AuthHandler) ValidateToken() echo.HandlerFunc {
 		return c.JSON(200, result)
 	}
 }
+
+func (h *AuthHandler) ForgotPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		forgotPasswordDto := &dto.ForgotPasswordDto{}
+		if err := c.Bind(forgotPasswordDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(forgotPasswordDto); err != nil {
+			return err
+		}
+
+		if err := h.svc.ForgotPassword(c.Request().Context(), forgotPasswordDto); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *AuthHandler) ResetPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		resetPasswordDto := &dto.ResetPasswordDto{}
+		if err := c.Bind(resetPasswordDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(resetPasswordDto); err != nil {
+			return err
+		}
+
+		if err := h.svc.ResetPassword(c.Request().Context(), resetPasswordDto); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
@@ -7,4 +7,6 @@ import (
 func (h *AuthHandler) RegisterRoutes(g *echo.Group) {
 	g.POST("/login", h.Login())
 	g.POST("/validate-token", h.ValidateToken())
+	g.POST("/forgot-password", h.ForgotPassword())
+	g.POST("/reset-password", h.ResetPassword())
 }
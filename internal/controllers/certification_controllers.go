@@ -0,0 +1,159 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type CertificationHandler struct {
+	svc services.CertificationService
+}
+
+// NewHandler constructor
+func NewCertificationHandler(svc services.CertificationService) *CertificationHandler {
+	return &CertificationHandler{svc: svc}
+}
+
+func (h *CertificationHandler) GetMyCertifications() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		certifications, err := h.svc.GetUserCertifications(c.Request().Context(), userId)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, certifications)
+	}
+}
+
+func (h *CertificationHandler) CreateMyCertification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return h.createCertification(c, c.Get("user_id").(string))
+	}
+}
+
+func (h *CertificationHandler) GetUserCertifications() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		certifications, err := h.svc.GetUserCertifications(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, certifications)
+	}
+}
+
+func (h *CertificationHandler) CreateUserCertification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return h.createCertification(c, c.Param("id"))
+	}
+}
+
+func (h *CertificationHandler) GetExpiringCertifications() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := dto.ExpiringCertificationsQuery{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(query); err != nil {
+			return err
+		}
+
+		certifications, err := h.svc.GetExpiringCertifications(c.Request().Context(), query.Days)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, certifications)
+	}
+}
+
+func (h *CertificationHandler) GetCertification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		role := c.Get("role").(string)
+		certification, err := h.svc.GetCertification(c.Request().Context(), c.Param("certificationId"), userId, role)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, certification)
+	}
+}
+
+func (h *CertificationHandler) UploadDocument() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		role := c.Get("role").(string)
+		file, err := c.FormFile("document")
+		if err != nil {
+			return errors.NewBadRequestError("document file is required")
+		}
+
+		certification, err := h.svc.UploadDocument(c.Request().Context(), c.Param("certificationId"), userId, role, file)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, certification)
+	}
+}
+
+func (h *CertificationHandler) VerifyCertification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		certification, err := h.svc.VerifyCertification(c.Request().Context(), c.Param("certificationId"), userId)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, certification)
+	}
+}
+
+func (h *CertificationHandler) RejectCertification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		rejectCertificationDto := &dto.RejectCertificationDto{}
+		if err := c.Bind(rejectCertificationDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(rejectCertificationDto); err != nil {
+			return err
+		}
+
+		certification, err := h.svc.RejectCertification(c.Request().Context(), c.Param("certificationId"), userId, rejectCertificationDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, certification)
+	}
+}
+
+func (h *CertificationHandler) DeleteCertification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		role := c.Get("role").(string)
+		if err := h.svc.DeleteCertification(c.Request().Context(), c.Param("certificationId"), userId, role); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *CertificationHandler) createCertification(c echo.Context, userID string) error {
+	createCertificationDto := &dto.CreateCertificationDto{}
+	if err := c.Bind(createCertificationDto); err != nil {
+		return errors.NewBadRequestError("Invalid request body")
+	}
+	// Validate the DTO
+	if err := validation.ValidateStruct(createCertificationDto); err != nil {
+		return err
+	}
+
+	certification, err := h.svc.CreateCertification(c.Request().Context(), userID, createCertificationDto)
+	if err != nil {
+		return err
+	}
+	return c.JSON(201, certification)
+}
@@ -0,0 +1,20 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *CertificationHandler) RegisterRoutes(users *echo.Group, certifications *echo.Group, mw *middleware.MiddlewareManager) {
+	users.GET("/me/certifications", mw.JWTAuth(h.GetMyCertifications()))
+	users.POST("/me/certifications", mw.JWTAuth(h.CreateMyCertification()))
+	users.GET("/:id/certifications", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetUserCertifications())))
+	users.POST("/:id/certifications", mw.JWTAuth(mw.RequireRoles("admin")(h.CreateUserCertification())))
+	certifications.GET("/expiring", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetExpiringCertifications())))
+	certifications.GET("/:certificationId", mw.JWTAuth(h.GetCertification()))
+	certifications.POST("/:certificationId/document", mw.JWTAuth(h.UploadDocument()))
+	certifications.POST("/:certificationId/verify", mw.JWTAuth(mw.RequireRoles("admin")(h.VerifyCertification())))
+	certifications.POST("/:certificationId/reject", mw.JWTAuth(mw.RequireRoles("admin")(h.RejectCertification())))
+	certifications.DELETE("/:certificationId", mw.JWTAuth(h.DeleteCertification()))
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler serves files of the local blob store through signed URLs
+type FileHandler struct {
+	store *storage.LocalStore
+}
+
+// NewHandler constructor
+func NewFileHandler(store *storage.LocalStore) *FileHandler {
+	return &FileHandler{store: store}
+}
+
+func (h *FileHandler) DownloadFile() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Param("*")
+		if !h.store.VerifySignature(key, c.QueryParam("expires"), c.QueryParam("signature")) {
+			return errors.NewForbiddenError("Invalid or expired signature")
+		}
+
+		content, info, err := h.store.Get(c.Request().Context(), key)
+		if err == storage.ErrNotFound {
+			return errors.NewNotFoundError("file")
+		}
+		if err != nil {
+			return errors.NewInternalError("Failed to read file", err)
+		}
+		defer content.Close()
+
+		c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+		c.Response().Header().Set("Cache-Control", "private, max-age=300")
+		return c.Stream(http.StatusOK, info.ContentType, io.Reader(content))
+	}
+}
@@ -0,0 +1,9 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+func (h *FileHandler) RegisterRoutes(g *echo.Group) {
+	g.GET("/*", h.DownloadFile())
+}
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type GroupHandler struct {
+	svc services.GroupService
+}
+
+// NewHandler constructor
+func NewGroupHandler(svc services.GroupService) *GroupHandler {
+	return &GroupHandler{svc: svc}
+}
+
+func (h *GroupHandler) CreateGroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		createGroupDto := &dto.CreateGroupDto{}
+		if err := c.Bind(createGroupDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(createGroupDto); err != nil {
+			return err
+		}
+
+		group, err := h.svc.CreateGroup(c.Request().Context(), createGroupDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, group)
+	}
+}
+
+func (h *GroupHandler) GetGroups() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			return err
+		}
+		filter := dto.GroupFilter{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(filter); err != nil {
+			return err
+		}
+
+		groups, err := h.svc.GetGroups(c.Request().Context(), filter, page, limit)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, groups)
+	}
+}
+
+func (h *GroupHandler) GetGroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		group, err := h.svc.GetGroup(c.Request().Context(), c.Param("groupId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, group)
+	}
+}
+
+func (h *GroupHandler) UpdateGroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		updateGroupDto := &dto.UpdateGroupDto{}
+		if err := c.Bind(updateGroupDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(updateGroupDto); err != nil {
+			return err
+		}
+
+		group, err := h.svc.UpdateGroup(c.Request().Context(), c.Param("groupId"), updateGroupDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, group)
+	}
+}
+
+func (h *GroupHandler) DeleteGroup() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.DeleteGroup(c.Request().Context(), c.Param("groupId")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *GroupHandler) GetMembers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := dto.GroupMembersQuery{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+
+		users, err := h.svc.GetMembers(c.Request().Context(), c.Param("groupId"), query)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, users)
+	}
+}
+
+func (h *GroupHandler) AddMembers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		addGroupMembersDto := &dto.AddGroupMembersDto{}
+		if err := c.Bind(addGroupMembersDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(addGroupMembersDto); err != nil {
+			return err
+		}
+
+		if err := h.svc.AddMembers(c.Request().Context(), c.Param("groupId"), addGroupMembersDto); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *GroupHandler) RemoveMember() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.RemoveMember(c.Request().Context(), c.Param("groupId"), c.Param("userId")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *GroupHandler) GetGroupPremises() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		groupPremises, err := h.svc.GetGroupPremises(c.Request().Context(), c.Param("groupId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, groupPremises)
+	}
+}
+
+func (h *GroupHandler) AssignPremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		assignGroupPremiseDto := &dto.AssignGroupPremiseDto{}
+		if err := c.Bind(assignGroupPremiseDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(assignGroupPremiseDto); err != nil {
+			return err
+		}
+
+		groupPremise, err := h.svc.AssignPremise(c.Request().Context(), c.Param("groupId"), assignGroupPremiseDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, groupPremise)
+	}
+}
+
+func (h *GroupHandler) UnassignPremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.UnassignPremise(c.Request().Context(), c.Param("groupId"), c.Param("premiseId")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *GroupHandler) GetMyGroups() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		groups, err := h.svc.GetUserGroups(c.Request().Context(), c.Get("user_id").(string))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, groups)
+	}
+}
+
+func (h *GroupHandler) GetUserGroups() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		groups, err := h.svc.GetUserGroups(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, groups)
+	}
+}
+
+func (h *GroupHandler) GetMyEffectivePremises() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		premises, err := h.svc.GetEffectivePremises(c.Request().Context(), c.Get("user_id").(string))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premises)
+	}
+}
+
+func (h *GroupHandler) GetUserEffectivePremises() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		premises, err := h.svc.GetEffectivePremises(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premises)
+	}
+}
@@ -0,0 +1,25 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *GroupHandler) RegisterRoutes(users *echo.Group, groups *echo.Group, mw *middleware.MiddlewareManager) {
+	users.GET("/me/groups", mw.JWTAuth(h.GetMyGroups()))
+	users.GET("/me/effective-premises", mw.JWTAuth(h.GetMyEffectivePremises()))
+	users.GET("/:id/groups", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetUserGroups())))
+	users.GET("/:id/effective-premises", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetUserEffectivePremises())))
+	groups.POST("", mw.JWTAuth(mw.RequireRoles("admin")(h.CreateGroup())))
+	groups.GET("", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetGroups())))
+	groups.GET("/:groupId", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetGroup())))
+	groups.PATCH("/:groupId", mw.JWTAuth(mw.RequireRoles("admin")(h.UpdateGroup())))
+	groups.DELETE("/:groupId", mw.JWTAuth(mw.RequireRoles("admin")(h.DeleteGroup())))
+	groups.GET("/:groupId/members", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetMembers())))
+	groups.POST("/:groupId/members", mw.JWTAuth(mw.RequireRoles("admin")(h.AddMembers())))
+	groups.DELETE("/:groupId/members/:userId", mw.JWTAuth(mw.RequireRoles("admin")(h.RemoveMember())))
+	groups.GET("/:groupId/premises", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetGroupPremises())))
+	groups.POST("/:groupId/premises", mw.JWTAuth(mw.RequireRoles("admin")(h.AssignPremise())))
+	groups.DELETE("/:groupId/premises/:premiseId", mw.JWTAuth(mw.RequireRoles("admin")(h.UnassignPremise())))
+}
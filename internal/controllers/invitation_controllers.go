@@ -0,0 +1,77 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type InvitationHandler struct {
+	svc services.InvitationService
+}
+
+// NewHandler constructor
+func NewInvitationHandler(svc services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{svc: svc}
+}
+
+func (h *InvitationHandler) InviteUser() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		inviteUserDto := &dto.InviteUserDto{}
+		if err := c.Bind(inviteUserDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(inviteUserDto); err != nil {
+			return err
+		}
+
+		invitation, err := h.svc.InviteUser(c.Request().Context(), userId, inviteUserDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, invitation)
+	}
+}
+
+func (h *InvitationHandler) ResendInvitation() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		invitation, err := h.svc.ResendInvitation(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, invitation)
+	}
+}
+
+func (h *InvitationHandler) RevokeInvitation() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.RevokeInvitation(c.Request().Context(), c.Param("id")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *InvitationHandler) AcceptInvitation() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		acceptInvitationDto := &dto.AcceptInvitationDto{}
+		if err := c.Bind(acceptInvitationDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(acceptInvitationDto); err != nil {
+			return err
+		}
+
+		if err := h.svc.AcceptInvitation(c.Request().Context(), acceptInvitationDto); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
@@ -0,0 +1,14 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *InvitationHandler) RegisterRoutes(g *echo.Group, mw *middleware.MiddlewareManager) {
+	g.POST("", mw.JWTAuth(mw.RequireRoles("admin")(h.InviteUser())))
+	g.POST("/:id/resend", mw.JWTAuth(mw.RequireRoles("admin")(h.ResendInvitation())))
+	g.DELETE("/:id", mw.JWTAuth(mw.RequireRoles("admin")(h.RevokeInvitation())))
+	g.POST("/accept", h.AcceptInvitation())
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"scs-user/pkg/errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// parsePagination reads the page and limit query parameters, defaulting to the first page of 10
+func parsePagination(c echo.Context) (int, int, error) {
+	page := c.QueryParam("page")
+	limit := c.QueryParam("limit")
+	if page == "" {
+		page = "1"
+	}
+	if limit == "" {
+		limit = "10"
+	}
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt < 1 {
+		return 0, 0, errors.NewBadRequestError("Invalid page number")
+	}
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt < 1 {
+		return 0, 0, errors.NewBadRequestError("Invalid limit")
+	}
+	return pageInt, limitInt, nil
+}
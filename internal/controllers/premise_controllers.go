@@ -0,0 +1,202 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type PremiseHandler struct {
+	svc services.PremiseService
+}
+
+// NewHandler constructor
+func NewPremiseHandler(svc services.PremiseService) *PremiseHandler {
+	return &PremiseHandler{svc: svc}
+}
+
+func (h *PremiseHandler) CreatePremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		createPremiseDto := &dto.CreatePremiseDto{}
+		if err := c.Bind(createPremiseDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(createPremiseDto); err != nil {
+			return err
+		}
+
+		premise, err := h.svc.CreatePremise(c.Request().Context(), createPremiseDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, premise)
+	}
+}
+
+func (h *PremiseHandler) GetPremises() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			return err
+		}
+		filter := dto.PremiseFilter{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(filter); err != nil {
+			return err
+		}
+
+		premises, err := h.svc.GetPremises(c.Request().Context(), filter, page, limit)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premises)
+	}
+}
+
+func (h *PremiseHandler) GetPremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		premise, err := h.svc.GetPremiseByID(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premise)
+	}
+}
+
+func (h *PremiseHandler) UpdatePremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		updatePremiseDto := &dto.UpdatePremiseDto{}
+		if err := c.Bind(updatePremiseDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(updatePremiseDto); err != nil {
+			return err
+		}
+
+		premise, err := h.svc.UpdatePremise(c.Request().Context(), c.Param("id"), updatePremiseDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premise)
+	}
+}
+
+func (h *PremiseHandler) DeletePremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.DeletePremise(c.Request().Context(), c.Param("id")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *PremiseHandler) GetChildren() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		children, err := h.svc.GetChildren(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, children)
+	}
+}
+
+func (h *PremiseHandler) GetAncestors() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ancestors, err := h.svc.GetAncestors(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, ancestors)
+	}
+}
+
+func (h *PremiseHandler) GetTree() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tree, err := h.svc.GetTree(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, tree)
+	}
+}
+
+func (h *PremiseHandler) MovePremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		movePremiseDto := &dto.MovePremiseDto{}
+		if err := c.Bind(movePremiseDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(movePremiseDto); err != nil {
+			return err
+		}
+
+		premise, err := h.svc.MovePremise(c.Request().Context(), c.Param("id"), movePremiseDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premise)
+	}
+}
+
+func (h *PremiseHandler) GetPremisesContaining() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := dto.GeoPointQuery{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(query); err != nil {
+			return err
+		}
+
+		premises, err := h.svc.GetPremisesContaining(c.Request().Context(), query.Point())
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premises)
+	}
+}
+
+func (h *PremiseHandler) GetNearbyPremises() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := dto.NearbyQuery{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(query); err != nil {
+			return err
+		}
+
+		premises, err := h.svc.GetNearbyPremises(c.Request().Context(), query.Point(), query.RadiusMeters)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, premises)
+	}
+}
+
+func (h *PremiseHandler) GetNearbyGuards() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := dto.NearbyQuery{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(query); err != nil {
+			return err
+		}
+
+		guards, err := h.svc.GetNearbyGuards(c.Request().Context(), query.Point(), query.RadiusMeters)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, guards)
+	}
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *PremiseHandler) RegisterRoutes(g *echo.Group, mw *middleware.MiddlewareManager) {
+	g.POST("", mw.JWTAuth(mw.RequireRoles("admin")(h.CreatePremise())))
+	g.GET("", mw.JWTAuth(h.GetPremises()))
+	g.GET("/containing", mw.JWTAuth(h.GetPremisesContaining()))
+	g.GET("/nearby", mw.JWTAuth(h.GetNearbyPremises()))
+	g.GET("/nearby/guards", mw.JWTAuth(h.GetNearbyGuards()))
+	g.GET("/:id", mw.JWTAuth(h.GetPremise()))
+	g.PATCH("/:id", mw.JWTAuth(mw.RequireRoles("admin")(h.UpdatePremise())))
+	g.DELETE("/:id", mw.JWTAuth(mw.RequireRoles("admin")(h.DeletePremise())))
+	g.GET("/:id/children", mw.JWTAuth(h.GetChildren()))
+	g.GET("/:id/ancestors", mw.JWTAuth(h.GetAncestors()))
+	g.GET("/:id/tree", mw.JWTAuth(h.GetTree()))
+	g.POST("/:id/move", mw.JWTAuth(mw.RequireRoles("admin")(h.MovePremise())))
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type ProfileHandler struct {
+	svc services.ProfileService
+}
+
+// NewHandler constructor
+func NewProfileHandler(svc services.ProfileService) *ProfileHandler {
+	return &ProfileHandler{svc: svc}
+}
+
+func (h *ProfileHandler) GetMyProfile() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		profile, err := h.svc.GetProfile(c.Request().Context(), userId)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, profile)
+	}
+}
+
+func (h *ProfileHandler) UpdateMyProfile() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		updateProfileDto := &dto.UpdateProfileDto{}
+		if err := c.Bind(updateProfileDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(updateProfileDto); err != nil {
+			return err
+		}
+
+		profile, err := h.svc.UpdateProfile(c.Request().Context(), userId, updateProfileDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, profile)
+	}
+}
+
+func (h *ProfileHandler) UploadMyAvatar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		file, err := c.FormFile("avatar")
+		if err != nil {
+			return errors.NewBadRequestError("avatar file is required")
+		}
+
+		profile, err := h.svc.UploadAvatar(c.Request().Context(), userId, file)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, profile)
+	}
+}
+
+// GetAvatar redirects to a signed URL of the user's avatar or one of its thumbnails (?size=128)
+func (h *ProfileHandler) GetAvatar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		avatarURL, err := h.svc.GetAvatarURL(c.Request().Context(), c.Param("id"), c.QueryParam("size"))
+		if err != nil {
+			return err
+		}
+		return c.Redirect(http.StatusFound, avatarURL)
+	}
+}
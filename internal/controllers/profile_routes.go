@@ -0,0 +1,14 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *ProfileHandler) RegisterRoutes(g *echo.Group, mw *middleware.MiddlewareManager) {
+	g.GET("/me/profile", mw.JWTAuth(h.GetMyProfile()))
+	g.PATCH("/me/profile", mw.JWTAuth(h.UpdateMyProfile()))
+	g.POST("/me/profile/avatar", mw.JWTAuth(h.UploadMyAvatar()))
+	g.GET("/:id/avatar", mw.JWTAuth(h.GetAvatar()))
+}
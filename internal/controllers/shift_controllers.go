@@ -0,0 +1,128 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type ShiftHandler struct {
+	svc services.ShiftService
+}
+
+// NewHandler constructor
+func NewShiftHandler(svc services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{svc: svc}
+}
+
+func (h *ShiftHandler) CreateShift() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		createShiftDto := &dto.CreateShiftDto{}
+		if err := c.Bind(createShiftDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(createShiftDto); err != nil {
+			return err
+		}
+
+		shift, err := h.svc.CreateShift(c.Request().Context(), userId, createShiftDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, shift)
+	}
+}
+
+func (h *ShiftHandler) GetShifts() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter := dto.ShiftFilter{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(filter); err != nil {
+			return err
+		}
+
+		occurrences, err := h.svc.GetShiftOccurrences(c.Request().Context(), filter)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, occurrences)
+	}
+}
+
+func (h *ShiftHandler) GetMyShifts() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter := dto.ShiftFilter{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(filter); err != nil {
+			return err
+		}
+		filter.UserID = c.Get("user_id").(string)
+
+		occurrences, err := h.svc.GetShiftOccurrences(c.Request().Context(), filter)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, occurrences)
+	}
+}
+
+func (h *ShiftHandler) GetShift() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		shift, err := h.svc.GetShift(c.Request().Context(), c.Param("shiftId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, shift)
+	}
+}
+
+func (h *ShiftHandler) DeleteShift() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.DeleteShift(c.Request().Context(), c.Param("shiftId")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *ShiftHandler) ClockIn() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		attendance, err := h.svc.ClockIn(c.Request().Context(), userId, c.Param("shiftId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, attendance)
+	}
+}
+
+func (h *ShiftHandler) ClockOut() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		attendance, err := h.svc.ClockOut(c.Request().Context(), userId, c.Param("shiftId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, attendance)
+	}
+}
+
+func (h *ShiftHandler) GetOnDuty() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		attendances, err := h.svc.GetOnDuty(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, attendances)
+	}
+}
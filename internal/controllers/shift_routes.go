@@ -0,0 +1,18 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *ShiftHandler) RegisterRoutes(users *echo.Group, premises *echo.Group, shifts *echo.Group, mw *middleware.MiddlewareManager) {
+	users.GET("/me/shifts", mw.JWTAuth(h.GetMyShifts()))
+	premises.GET("/:id/on-duty", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetOnDuty())))
+	shifts.POST("", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.CreateShift())))
+	shifts.GET("", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetShifts())))
+	shifts.GET("/:shiftId", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetShift())))
+	shifts.DELETE("/:shiftId", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.DeleteShift())))
+	shifts.POST("/:shiftId/clock-in", mw.JWTAuth(h.ClockIn()))
+	shifts.POST("/:shiftId/clock-out", mw.JWTAuth(h.ClockOut()))
+}
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"fmt"
+	"net/http"
 	"scs-user/internal/dto"
 	services "scs-user/internal/services"
 	"scs-user/pkg/errors"
+	"scs-user/pkg/export"
 	"scs-user/pkg/validation"
-	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -41,26 +44,32 @@ func (h *UserHandler) CreateUser() echo.HandlerFunc {
 	}
 }
 
-func (h *UserHandler) GetUsers() echo.HandlerFunc {
+// RepublishSnapshots publishes a snapshot of every user to the user snapshot topic
+func (h *UserHandler) RepublishSnapshots() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		page := c.QueryParam("page")
-		limit := c.QueryParam("limit")
-		if page == "" {
-			page = "1"
-		}
-		if limit == "" {
-			limit = "10"
-		}
-		pageInt, err := strconv.Atoi(page)
+		published, err := h.svc.RepublishSnapshots(c.Request().Context())
 		if err != nil {
-			return errors.NewBadRequestError("Invalid page number")
+			return err
 		}
-		limitInt, err := strconv.Atoi(limit)
+		return c.JSON(200, map[string]int{"published": published})
+	}
+}
+
+func (h *UserHandler) GetUsers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		pageInt, limitInt, err := parsePagination(c)
 		if err != nil {
-			return errors.NewBadRequestError("Invalid limit")
+			return err
+		}
+		filter := dto.UserFilter{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(filter); err != nil {
+			return err
 		}
 
-		users, err := h.svc.GetUsers(c.Request().Context(), pageInt, limitInt)
+		users, err := h.svc.GetUsers(c.Request().Context(), filter, pageInt, limitInt)
 		if err != nil {
 			return err
 		}
@@ -68,6 +77,32 @@ func (h *UserHandler) GetUsers() echo.HandlerFunc {
 		return c.JSON(200, users)
 	}
 }
+func (h *UserHandler) ExportUsers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		exportUsersDto := &dto.ExportUsersRequest{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, exportUsersDto); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(exportUsersDto); err != nil {
+			return err
+		}
+		format, err := export.ParseFormat(exportUsersDto.Format)
+		if err != nil {
+			return errors.NewBadRequestError(err.Error())
+		}
+
+		filename := fmt.Sprintf("users_%s%s", time.Now().UTC().Format("20060102_150405"), format.Extension())
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, format.ContentType())
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		res.WriteHeader(http.StatusOK)
+
+		// The status has already been sent, so errors can only be logged from here on
+		return h.svc.ExportUsers(c.Request().Context(), exportUsersDto.UserFilter, format, res)
+	}
+}
+
 func (h *UserHandler) GetMe() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		userId := c.Get("user_id").(string)
@@ -97,25 +132,3 @@ func (h *UserHandler) VerifyAccount() echo.HandlerFunc {
 		return c.JSON(200, "success")
 	}
 }
-
-// func (h *UserHandler) GetAssignments() echo.HandlerFunc {
-// 	return func(c echo.Context) error {
-// 		userID := "72b194cd-3cb1-4653-b7d5-ed2fc032ed62"
-// 		assignments, err := h.svc.GetAssignments(c.Request().Context(), userID)
-// 		if err != nil {
-// 			return err
-// 		}
-// 		return c.JSON(200, assignments)
-// 	}
-// }
-// func (h *UserHandler) CompleteStep() echo.HandlerFunc {
-// 	return func(c echo.Context) error {
-// 		assignmentId := c.Param("assignmentId")
-// 		stepId := c.Param("stepId")
-// 		err := h.svc.CompleteStep(c.Request().Context(), assignmentId, stepId)
-// 		if err != nil {
-// 			return err
-// 		}
-// 		return c.JSON(200, "success")
-// 	}
-// }
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type UserPremiseHandler struct {
+	svc services.UserPremiseService
+}
+
+// NewHandler constructor
+func NewUserPremiseHandler(svc services.UserPremiseService) *UserPremiseHandler {
+	return &UserPremiseHandler{svc: svc}
+}
+
+func (h *UserPremiseHandler) GetMyPremises() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := c.Get("user_id").(string)
+		userPremises, err := h.svc.GetUserPremises(c.Request().Context(), userId, c.QueryParam("active") == "true")
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, userPremises)
+	}
+}
+
+func (h *UserPremiseHandler) GetUserPremises() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userPremises, err := h.svc.GetUserPremises(c.Request().Context(), c.Param("id"), c.QueryParam("active") == "true")
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, userPremises)
+	}
+}
+
+func (h *UserPremiseHandler) GetPremiseUsers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userPremises, err := h.svc.GetPremiseUsers(c.Request().Context(), c.Param("id"), c.QueryParam("active") == "true")
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, userPremises)
+	}
+}
+
+func (h *UserPremiseHandler) AssignPremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		assignPremiseDto := &dto.AssignPremiseDto{}
+		if err := c.Bind(assignPremiseDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(assignPremiseDto); err != nil {
+			return err
+		}
+
+		userPremise, err := h.svc.AssignPremise(c.Request().Context(), c.Param("id"), assignPremiseDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, userPremise)
+	}
+}
+
+func (h *UserPremiseHandler) UpdateAssignment() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		updateDto := &dto.UpdatePremiseAssignmentDto{}
+		if err := c.Bind(updateDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(updateDto); err != nil {
+			return err
+		}
+
+		userPremise, err := h.svc.UpdateAssignment(c.Request().Context(), c.Param("id"), c.Param("premiseId"), updateDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, userPremise)
+	}
+}
+
+func (h *UserPremiseHandler) UnassignPremise() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.UnassignPremise(c.Request().Context(), c.Param("id"), c.Param("premiseId")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
@@ -0,0 +1,16 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *UserPremiseHandler) RegisterRoutes(users *echo.Group, premises *echo.Group, mw *middleware.MiddlewareManager) {
+	users.GET("/me/premises", mw.JWTAuth(h.GetMyPremises()))
+	users.GET("/:id/premises", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetUserPremises())))
+	users.POST("/:id/premises", mw.JWTAuth(mw.RequireRoles("admin")(h.AssignPremise())))
+	users.PATCH("/:id/premises/:premiseId", mw.JWTAuth(mw.RequireRoles("admin")(h.UpdateAssignment())))
+	users.DELETE("/:id/premises/:premiseId", mw.JWTAuth(mw.RequireRoles("admin")(h.UnassignPremise())))
+	premises.GET("/:id/users", mw.JWTAuth(mw.RequireRoles("admin", "operator")(h.GetPremiseUsers())))
+}
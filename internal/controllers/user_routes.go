@@ -10,6 +10,8 @@ func (h *UserHandler) RegisterRoutes(g *echo.Group, mw *middleware.MiddlewareMan
 
 	g.POST("", mw.JWTAuth(h.CreateUser()))
 	g.GET("", mw.JWTAuth(h.GetUsers()))
+	g.GET("/export", mw.JWTAuth(mw.RequireRoles("admin")(h.ExportUsers())))
+	g.POST("/snapshots/republish", mw.JWTAuth(mw.RequireRoles("admin")(h.RepublishSnapshots())))
 	g.GET("/me", mw.JWTAuth(h.GetMe()))
 	g.POST("/verify", h.VerifyAccount())
 
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"scs-user/internal/dto"
+	services "scs-user/internal/services"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Handler
+type WebhookHandler struct {
+	svc services.WebhookService
+}
+
+// NewHandler constructor
+func NewWebhookHandler(svc services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+func (h *WebhookHandler) CreateWebhook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		createWebhookDto := &dto.CreateWebhookDto{}
+		if err := c.Bind(createWebhookDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(createWebhookDto); err != nil {
+			return err
+		}
+
+		webhook, err := h.svc.CreateWebhook(c.Request().Context(), c.Get("user_id").(string), createWebhookDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(201, webhook)
+	}
+}
+
+func (h *WebhookHandler) GetWebhooks() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			return err
+		}
+
+		webhooks, err := h.svc.GetWebhooks(c.Request().Context(), page, limit)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, webhooks)
+	}
+}
+
+func (h *WebhookHandler) GetWebhook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		webhook, err := h.svc.GetWebhook(c.Request().Context(), c.Param("webhookId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, webhook)
+	}
+}
+
+func (h *WebhookHandler) UpdateWebhook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		updateWebhookDto := &dto.UpdateWebhookDto{}
+		if err := c.Bind(updateWebhookDto); err != nil {
+			return errors.NewBadRequestError("Invalid request body")
+		}
+		// Validate the DTO
+		if err := validation.ValidateStruct(updateWebhookDto); err != nil {
+			return err
+		}
+
+		webhook, err := h.svc.UpdateWebhook(c.Request().Context(), c.Param("webhookId"), updateWebhookDto)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, webhook)
+	}
+}
+
+func (h *WebhookHandler) DeleteWebhook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := h.svc.DeleteWebhook(c.Request().Context(), c.Param("webhookId")); err != nil {
+			return err
+		}
+		return c.JSON(200, "success")
+	}
+}
+
+func (h *WebhookHandler) TestWebhook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		delivery, err := h.svc.TestWebhook(c.Request().Context(), c.Param("webhookId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, delivery)
+	}
+}
+
+func (h *WebhookHandler) GetDeliveries() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		page, limit, err := parsePagination(c)
+		if err != nil {
+			return err
+		}
+		query := dto.WebhookDeliveriesQuery{}
+		if err := (&echo.DefaultBinder{}).BindQueryParams(c, &query); err != nil {
+			return errors.NewBadRequestError("Invalid query parameters")
+		}
+		if err := validation.ValidateStruct(query); err != nil {
+			return err
+		}
+
+		deliveries, err := h.svc.GetDeliveries(c.Request().Context(), c.Param("webhookId"), query, page, limit)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, deliveries)
+	}
+}
+
+func (h *WebhookHandler) GetDelivery() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		delivery, err := h.svc.GetDelivery(c.Request().Context(), c.Param("webhookId"), c.Param("deliveryId"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, delivery)
+	}
+}
@@ -0,0 +1,18 @@
+package http
+
+import (
+	middleware "scs-user/internal/middlewares"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *WebhookHandler) RegisterRoutes(webhooks *echo.Group, mw *middleware.MiddlewareManager) {
+	webhooks.POST("", mw.JWTAuth(mw.RequireRoles("admin")(h.CreateWebhook())))
+	webhooks.GET("", mw.JWTAuth(mw.RequireRoles("admin")(h.GetWebhooks())))
+	webhooks.GET("/:webhookId", mw.JWTAuth(mw.RequireRoles("admin")(h.GetWebhook())))
+	webhooks.PATCH("/:webhookId", mw.JWTAuth(mw.RequireRoles("admin")(h.UpdateWebhook())))
+	webhooks.DELETE("/:webhookId", mw.JWTAuth(mw.RequireRoles("admin")(h.DeleteWebhook())))
+	webhooks.POST("/:webhookId/test", mw.JWTAuth(mw.RequireRoles("admin")(h.TestWebhook())))
+	webhooks.GET("/:webhookId/deliveries", mw.JWTAuth(mw.RequireRoles("admin")(h.GetDeliveries())))
+	webhooks.GET("/:webhookId/deliveries/:deliveryId", mw.JWTAuth(mw.RequireRoles("admin")(h.GetDelivery())))
+}
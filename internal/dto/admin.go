@@ -0,0 +1,13 @@
+package dto
+
+// CreateAdminDto holds the admin created from the command line
+type CreateAdminDto struct {
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=6,max=100"`
+}
+
+// SetPasswordDto holds the password an operator sets for a user
+type SetPasswordDto struct {
+	Password string `json:"password" validate:"required,min=6,max=100"`
+}
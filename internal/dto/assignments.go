@@ -0,0 +1,34 @@
+package dto
+
+import "time"
+
+// CreateAssignmentDto is the request body for assigning a checklist to a guard
+type CreateAssignmentDto struct {
+	UserID      string `json:"user_id" validate:"required,uuid"`
+	PremiseID   string `json:"premise_id" validate:"omitempty,uuid"`
+	Type        string `json:"type" validate:"required,oneof=patrol alarm_response"`
+	Title       string `json:"title" validate:"required,min=2,max=200"`
+	Description string `json:"description" validate:"max=2000"`
+	// Sequential defaults to true
+	Sequential *bool                     `json:"sequential"`
+	DueAt      *time.Time                `json:"due_at"`
+	Steps      []CreateAssignmentStepDto `json:"steps" validate:"required,min=1,max=100,dive"`
+}
+
+// CreateAssignmentStepDto is a step of a new assignment. Steps are ordered as given.
+type CreateAssignmentStepDto struct {
+	Title            string `json:"title" validate:"required,min=2,max=200"`
+	Description      string `json:"description" validate:"max=2000"`
+	RequiresEvidence bool   `json:"requires_evidence"`
+}
+
+// AssignmentFilter holds the optional filters for listing assignments
+type AssignmentFilter struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending in_progress completed cancelled"`
+}
+
+// CompleteStepDto is the request body for completing a step, sent as JSON or as a
+// multipart form together with an evidence file
+type CompleteStepDto struct {
+	Note string `json:"note" form:"note" validate:"max=1000"`
+}
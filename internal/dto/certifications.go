@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// CreateCertificationDto is the request body for recording a license or certificate
+type CreateCertificationDto struct {
+	Type      string     `json:"type" validate:"required,min=2,max=50"`
+	Number    string     `json:"number" validate:"required,max=100"`
+	Issuer    string     `json:"issuer" validate:"required,max=200"`
+	IssuedAt  time.Time  `json:"issued_at" validate:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RejectCertificationDto is the request body for rejecting a certification
+type RejectCertificationDto struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ExpiringCertificationsQuery selects the verified certifications expiring within the next days
+type ExpiringCertificationsQuery struct {
+	Days int `query:"days" validate:"omitempty,min=1,max=365"`
+}
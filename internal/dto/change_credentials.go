@@ -0,0 +1,18 @@
+package dto
+
+// ChangePasswordDto is the request body for changing the current user's password
+type ChangePasswordDto struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=100"`
+}
+
+// ChangeEmailDto is the request body for requesting a change of the current user's email
+type ChangeEmailDto struct {
+	NewEmail        string `json:"new_email" validate:"required,email,max=255"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+// ConfirmEmailChangeDto is the request body for confirming a new email address
+type ConfirmEmailChangeDto struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -1,9 +1,20 @@
 package dto
 
 type CreateUserDto struct {
-	Name      string `json:"name" validate:"required,min=2,max=100"`
-	Email     string `json:"email" validate:"required,email,max=255"`
-	Password  string `json:"password" validate:"required,min=6,max=100"`
-	Role      string `json:"role" validate:"required,role"`
-	PremiseID string `json:"premise_id" validate:"required,uuid"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=6,max=100"`
+	Role     string `json:"role" validate:"required,role"`
+	// PremiseIDs are the premises the user is assigned to. Admins may have none, which makes them city-wide.
+	PremiseIDs []string `json:"premise_ids" validate:"omitempty,max=100,dive,uuid"`
+	// Deprecated: use PremiseIDs
+	PremiseID string `json:"premise_id" validate:"omitempty,uuid"`
+}
+
+// AllPremiseIDs returns PremiseIDs together with the deprecated PremiseID
+func (d *CreateUserDto) AllPremiseIDs() []string {
+	if d.PremiseID == "" {
+		return d.PremiseIDs
+	}
+	return append([]string{d.PremiseID}, d.PremiseIDs...)
 }
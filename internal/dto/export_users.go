@@ -0,0 +1,7 @@
+package dto
+
+// ExportUsersRequest is the query for exporting users
+type ExportUsersRequest struct {
+	UserFilter
+	Format string `query:"format" validate:"omitempty,oneof=csv jsonl ndjson xlsx"`
+}
@@ -0,0 +1,52 @@
+package dto
+
+import "scs-user/internal/models"
+
+// CreateGroupDto is the request body for creating a group
+type CreateGroupDto struct {
+	Name          string `json:"name" validate:"required,min=2,max=100"`
+	Description   string `json:"description" validate:"max=500"`
+	ParentGroupID string `json:"parent_group_id" validate:"omitempty,uuid"`
+}
+
+// UpdateGroupDto is the request body for partially updating a group.
+// An empty parent group ID makes the group a top-level group.
+type UpdateGroupDto struct {
+	Name          *string `json:"name" validate:"omitempty,min=2,max=100"`
+	Description   *string `json:"description" validate:"omitempty,max=500"`
+	ParentGroupID *string `json:"parent_group_id" validate:"omitempty,uuid"`
+}
+
+// GroupFilter holds the optional filters for listing groups
+type GroupFilter struct {
+	Search        string `query:"q" validate:"omitempty,max=100"`
+	ParentGroupID string `query:"parent_group_id" validate:"omitempty,uuid"`
+}
+
+// AddGroupMembersDto is the request body for adding users to a group
+type AddGroupMembersDto struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,max=500,dive,uuid"`
+}
+
+// GroupMembersQuery controls how the members of a group are resolved
+type GroupMembersQuery struct {
+	// DirectOnly leaves out the members of subgroups
+	DirectOnly bool `query:"direct_only"`
+	ActiveOnly bool `query:"active_only"`
+}
+
+// AssignGroupPremiseDto is the request body for assigning a premise to a group
+type AssignGroupPremiseDto struct {
+	PremiseID string `json:"premise_id" validate:"required,uuid"`
+	Role      string `json:"role" validate:"omitempty,oneof=guard supervisor"`
+}
+
+// EffectivePremise is a premise a user is assigned to directly or through a group
+type EffectivePremise struct {
+	models.Premise
+	Role string `json:"role"`
+	// Source is "direct" or "group"
+	Source    string `json:"source"`
+	GroupID   string `json:"group_id,omitempty"`
+	GroupName string `json:"group_name,omitempty"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+// InviteUserDto is the request body for inviting a new user
+type InviteUserDto struct {
+	Name       string   `json:"name" validate:"required,min=2,max=100"`
+	Email      string   `json:"email" validate:"required,email,max=255"`
+	Role       string   `json:"role" validate:"required,role"`
+	PremiseIDs []string `json:"premise_ids" validate:"omitempty,dive,uuid"`
+}
+
+// AcceptInvitationDto is the request body for accepting an invitation
+type AcceptInvitationDto struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6,max=100"`
+}
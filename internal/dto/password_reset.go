@@ -0,0 +1,12 @@
+package dto
+
+// ForgotPasswordDto is the request body for requesting a password reset link
+type ForgotPasswordDto struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// ResetPasswordDto is the request body for setting a new password with a reset link
+type ResetPasswordDto struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6,max=100"`
+}
@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"scs-user/internal/models"
+	"scs-user/pkg/geo"
+)
+
+// CreatePremiseDto is the request body for creating a premise
+type CreatePremiseDto struct {
+	Name            string       `json:"name" validate:"required,min=2,max=200"`
+	Address         string       `json:"address" validate:"max=500"`
+	ParentPremiseID string       `json:"parent_premise_id" validate:"omitempty,uuid"`
+	Latitude        *float64     `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude       *float64     `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	Geofence        *geo.Polygon `json:"geofence"`
+}
+
+// UpdatePremiseDto is the request body for partially updating a premise.
+// ClearLocation and ClearGeofence remove the coordinates and the geofence.
+type UpdatePremiseDto struct {
+	Name          *string      `json:"name" validate:"omitempty,min=2,max=200"`
+	Address       *string      `json:"address" validate:"omitempty,max=500"`
+	Latitude      *float64     `json:"latitude" validate:"required_with=Longitude,omitempty,latitude"`
+	Longitude     *float64     `json:"longitude" validate:"required_with=Latitude,omitempty,longitude"`
+	Geofence      *geo.Polygon `json:"geofence"`
+	ClearLocation bool         `json:"clear_location"`
+	ClearGeofence bool         `json:"clear_geofence"`
+}
+
+// MovePremiseDto is the request body for moving a premise and its subtree under a new parent.
+// An empty parent makes the premise a root.
+type MovePremiseDto struct {
+	ParentPremiseID string `json:"parent_premise_id" validate:"omitempty,uuid"`
+}
+
+// PremiseFilter holds the optional filters for listing premises
+type PremiseFilter struct {
+	Search          string `query:"q" validate:"omitempty,max=100"`
+	ParentPremiseID string `query:"parent_premise_id" validate:"omitempty,uuid"`
+	RootsOnly       bool   `query:"roots_only"`
+}
+
+// PremiseTreeNode is a premise with its nested children
+type PremiseTreeNode struct {
+	models.Premise
+	Children []*PremiseTreeNode `json:"children"`
+}
+
+// GeoPointQuery is a WGS84 position given as query parameters
+type GeoPointQuery struct {
+	Latitude  *float64 `query:"lat" validate:"required,latitude"`
+	Longitude *float64 `query:"lng" validate:"required,longitude"`
+}
+
+// Point returns the queried position
+func (q GeoPointQuery) Point() geo.Point {
+	return geo.Point{Lat: *q.Latitude, Lng: *q.Longitude}
+}
+
+// NearbyQuery selects everything within a radius in meters of a position
+type NearbyQuery struct {
+	GeoPointQuery
+	RadiusMeters float64 `query:"radius" validate:"omitempty,gt=0,max=50000"`
+}
+
+// NearbyPremise is a premise with its distance from the queried position
+type NearbyPremise struct {
+	models.Premise
+	DistanceMeters float64 `json:"distance_meters"`
+}
+
+// NearbyGuard is a guard assigned to a nearby premise. Guards assigned to several
+// nearby premises are reported once, with the nearest premise.
+type NearbyGuard struct {
+	models.User
+	PremiseID      string  `json:"premise_id"`
+	PremiseName    string  `json:"premise_name"`
+	DistanceMeters float64 `json:"distance_meters"`
+}
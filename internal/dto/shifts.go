@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// CreateShiftDto is the request body for scheduling a shift. Recurrence is an optional
+// RFC 5545 rule, e.g. "FREQ=WEEKLY;BYDAY=MO,TU,WE;UNTIL=20261231".
+type CreateShiftDto struct {
+	UserID     string    `json:"user_id" validate:"required,uuid"`
+	PremiseID  string    `json:"premise_id" validate:"required,uuid"`
+	StartsAt   time.Time `json:"starts_at" validate:"required"`
+	EndsAt     time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	Recurrence string    `json:"recurrence" validate:"max=200"`
+	Notes      string    `json:"notes" validate:"max=500"`
+}
+
+// ShiftFilter selects the shift occurrences in a time window, the next 7 days by default
+type ShiftFilter struct {
+	UserID    string     `query:"user_id" validate:"omitempty,uuid"`
+	PremiseID string     `query:"premise_id" validate:"omitempty,uuid"`
+	From      *time.Time `query:"from"`
+	To        *time.Time `query:"to"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+// UpdateProfileDto is the request body for partially updating the current user's profile.
+// Only the fields that are present are updated.
+type UpdateProfileDto struct {
+	Phone                    *string `json:"phone" validate:"omitempty,e164"`
+	EmployeeNumber           *string `json:"employee_number" validate:"omitempty,max=50"`
+	Locale                   *string `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	TimeZone                 *string `json:"time_zone" validate:"omitempty,timezone"`
+	EmergencyContactName     *string `json:"emergency_contact_name" validate:"omitempty,max=100"`
+	EmergencyContactPhone    *string `json:"emergency_contact_phone" validate:"omitempty,e164"`
+	EmergencyContactRelation *string `json:"emergency_contact_relation" validate:"omitempty,max=50"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+// UserFilter holds the optional filters for listing and exporting users
+type UserFilter struct {
+	Search    string `query:"q" validate:"omitempty,max=100"`
+	Role      string `query:"role" validate:"omitempty,role"`
+	Status    string `query:"status" validate:"omitempty,oneof=invited pending active suspended"`
+	IsActive  *bool  `query:"is_active"`
+	PremiseID string `query:"premise_id" validate:"omitempty,uuid"`
+}
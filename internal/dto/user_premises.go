@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// AssignPremiseDto is the request body for assigning a user to a premise
+type AssignPremiseDto struct {
+	PremiseID string     `json:"premise_id" validate:"required,uuid"`
+	Role      string     `json:"role" validate:"omitempty,oneof=guard supervisor"`
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+}
+
+// UpdatePremiseAssignmentDto is the request body for changing an existing assignment.
+// The dates replace the current ones, a missing date removes the bound.
+type UpdatePremiseAssignmentDto struct {
+	Role     string     `json:"role" validate:"omitempty,oneof=guard supervisor"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "scs-user/internal/models"
+
+// CreateWebhookDto is the request body for subscribing an endpoint to events. A secret is
+// generated if none is given.
+type CreateWebhookDto struct {
+	URL         string   `json:"url" validate:"required,url,startswith=http,max=2000"`
+	Description string   `json:"description" validate:"max=500"`
+	EventTypes  []string `json:"event_types" validate:"required,min=1,max=50,dive,required,max=100"`
+	Secret      string   `json:"secret" validate:"omitempty,min=16,max=200"`
+}
+
+// UpdateWebhookDto is the request body for partially updating a subscription. Enabling a
+// disabled subscription clears its failures.
+type UpdateWebhookDto struct {
+	URL         *string   `json:"url" validate:"omitempty,url,startswith=http,max=2000"`
+	Description *string   `json:"description" validate:"omitempty,max=500"`
+	EventTypes  *[]string `json:"event_types" validate:"omitempty,min=1,max=50,dive,required,max=100"`
+	Secret      *string   `json:"secret" validate:"omitempty,min=16,max=200"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+// WebhookDeliveriesQuery filters the delivery log of a subscription
+type WebhookDeliveriesQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+}
+
+// WebhookWithSecret is returned once when a subscription is created, the secret can not be
+// read afterwards
+type WebhookWithSecret struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
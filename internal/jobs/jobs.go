@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"scs-user/pkg/logger"
+	"time"
+)
+
+// RunPeriodically calls fn right away and then every interval until ctx is cancelled.
+// Errors are logged and do not stop the job.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, logger logger.Logger, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			logger.Errorf("Job %s failed: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			logger.Infof("Job %s stopped", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -22,6 +22,11 @@ func (mw *MiddlewareManager) ErrorHandlerMiddleware(next echo.HandlerFunc) echo.
 		// Log the error
 		mw.logger.Errorf("Request %s failed: %v", requestID, err)
 
+		// The response has already been (partially) sent, e.g. a streamed download
+		if c.Response().Committed {
+			return nil
+		}
+
 		// Handle different types of errors
 		if appErr, ok := errors.IsAppError(err); ok {
 			return mw.handleAppError(c, appErr, requestID)
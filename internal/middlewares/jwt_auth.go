@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"scs-user/pkg/errors"
 	"scs-user/pkg/utils"
 	"strings"
 
@@ -26,9 +27,39 @@ func (mw *MiddlewareManager) JWTAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid or expired token"})
 		}
 
+		// Only session tokens issued by login are accepted, and only while the session is active
+		if claims.ID == "" {
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "invalid or expired token"})
+		}
+		active, err := mw.sessions.IsSessionActive(c.Request().Context(), claims.ID)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "session has been revoked"})
+		}
+
 		// Store claims in context
 		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.ID)
 
 		return next(c)
 	}
 }
+
+// RequireRoles allows the request only if the authenticated user has one of the given roles.
+// It must be used after JWTAuth.
+func (mw *MiddlewareManager) RequireRoles(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, _ := c.Get("role").(string)
+			for _, allowed := range roles {
+				if role == allowed {
+					return next(c)
+				}
+			}
+			return errors.NewForbiddenError("Insufficient permissions")
+		}
+	}
+}
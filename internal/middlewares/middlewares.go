@@ -1,18 +1,25 @@
 package middleware
 
 import (
+	"context"
 	config "scs-user/config"
 	"scs-user/pkg/logger"
 )
 
+// SessionChecker reports whether a login session is still valid
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
 // Middleware manager
 type MiddlewareManager struct {
-	cfg     *config.Config
-	origins []string
-	logger  logger.Logger
+	cfg      *config.Config
+	origins  []string
+	logger   logger.Logger
+	sessions SessionChecker
 }
 
 // Middleware manager constructor
-func NewMiddlewareManager(cfg *config.Config, origins []string, logger logger.Logger) *MiddlewareManager {
-	return &MiddlewareManager{cfg: cfg, origins: origins, logger: logger}
+func NewMiddlewareManager(cfg *config.Config, origins []string, logger logger.Logger, sessions SessionChecker) *MiddlewareManager {
+	return &MiddlewareManager{cfg: cfg, origins: origins, logger: logger, sessions: sessions}
 }
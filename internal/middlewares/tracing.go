@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"scs-user/pkg/tracing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TracingMiddleware continues the trace of the request, or starts one, so the events published
+// while handling it carry its trace context
+func (mw *MiddlewareManager) TracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		traceParent := tracing.ChildOf(c.Request().Header.Get(tracing.Header))
+		c.SetRequest(c.Request().WithContext(tracing.WithTraceParent(c.Request().Context(), traceParent)))
+		c.Response().Header().Set(tracing.Header, traceParent)
+		return next(c)
+	}
+}
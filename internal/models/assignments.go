@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Assignment types
+const (
+	AssignmentTypePatrol        = "patrol"
+	AssignmentTypeAlarmResponse = "alarm_response"
+)
+
+// Assignment statuses
+const (
+	AssignmentStatusPending    = "pending"     // No step has been completed yet
+	AssignmentStatusInProgress = "in_progress" // Some steps have been completed
+	AssignmentStatusCompleted  = "completed"   // All steps have been completed
+	AssignmentStatusCancelled  = "cancelled"   // Cancelled by an operator
+)
+
+// Assignment is a checklist of steps a guard has to work through, e.g. a patrol round or an alarm response
+type Assignment struct {
+	Base
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User        *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PremiseID   *uuid.UUID `json:"premise_id,omitempty" gorm:"type:uuid;index"`
+	Premise     *Premise   `json:"premise,omitempty" gorm:"foreignKey:PremiseID;constraint:OnDelete:SET NULL"`
+	Type        string     `json:"type" gorm:"not null"`
+	Title       string     `json:"title" gorm:"not null"`
+	Description string     `json:"description"`
+	Status      string     `json:"status" gorm:"not null;default:'pending';index"`
+	// Sequential assignments require the steps to be completed in order
+	Sequential  bool             `json:"sequential"`
+	DueAt       *time.Time       `json:"due_at,omitempty"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedByID *uuid.UUID       `json:"created_by_id,omitempty" gorm:"type:uuid"`
+	Steps       []AssignmentStep `json:"steps" gorm:"foreignKey:AssignmentID;constraint:OnDelete:CASCADE"`
+}
+
+// IsOpen reports whether steps of the assignment can still be completed
+func (a *Assignment) IsOpen() bool {
+	return a.Status == AssignmentStatusPending || a.Status == AssignmentStatusInProgress
+}
+
+// CompletedSteps returns the number of completed steps
+func (a *Assignment) CompletedSteps() int {
+	completed := 0
+	for _, step := range a.Steps {
+		if step.IsCompleted() {
+			completed++
+		}
+	}
+	return completed
+}
+
+type AssignmentStep struct {
+	Base
+	AssignmentID uuid.UUID `json:"assignment_id" gorm:"type:uuid;not null;uniqueIndex:idx_assignment_steps_position"`
+	Position     int       `json:"position" gorm:"not null;uniqueIndex:idx_assignment_steps_position"`
+	Title        string    `json:"title" gorm:"not null"`
+	Description  string    `json:"description"`
+	// RequiresEvidence steps can only be completed with an attached photo or video
+	RequiresEvidence    bool       `json:"requires_evidence"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+	CompletedByID       *uuid.UUID `json:"completed_by_id,omitempty" gorm:"type:uuid"`
+	Note                string     `json:"note,omitempty"`
+	EvidenceKey         string     `json:"-"`
+	EvidenceContentType string     `json:"evidence_content_type,omitempty"`
+	EvidenceURL         string     `json:"evidence_url,omitempty" gorm:"-"`
+}
+
+func (s *AssignmentStep) IsCompleted() bool {
+	return s.CompletedAt != nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Certification statuses
+const (
+	CertificationStatusPending  = "pending"  // Waiting for an admin to check the document
+	CertificationStatusVerified = "verified" // Checked by an admin
+	CertificationStatusRejected = "rejected" // Refused by an admin, see RejectionReason
+)
+
+// Certification is a license or certificate held by a user, e.g. a security guard license
+type Certification struct {
+	Base
+	UserID              uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_certifications_user_type_number"`
+	User                *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Type                string     `json:"type" gorm:"not null;uniqueIndex:idx_certifications_user_type_number"`
+	Number              string     `json:"number" gorm:"not null;uniqueIndex:idx_certifications_user_type_number"`
+	Issuer              string     `json:"issuer" gorm:"not null"`
+	IssuedAt            time.Time  `json:"issued_at" gorm:"not null"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	Status              string     `json:"status" gorm:"not null;default:'pending'"`
+	DocumentKey         string     `json:"-"`
+	DocumentContentType string     `json:"document_content_type,omitempty"`
+	DocumentURL         string     `json:"document_url,omitempty" gorm:"-"`
+	VerifiedByID        *uuid.UUID `json:"verified_by_id,omitempty" gorm:"type:uuid"`
+	VerifiedAt          *time.Time `json:"verified_at,omitempty"`
+	RejectionReason     string     `json:"rejection_reason,omitempty"`
+	// ExpiryNotifiedAt is set once the certification.expiring event has been published
+	ExpiryNotifiedAt *time.Time `json:"-"`
+}
+
+// IsValidAt reports whether the certification is verified and not expired at the given time
+func (c *Certification) IsValidAt(t time.Time) bool {
+	return c.Status == CertificationStatusVerified && (c.ExpiresAt == nil || t.Before(*c.ExpiresAt))
+}
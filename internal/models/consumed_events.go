@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConsumedEvent records an event consumed from Kafka, so a redelivered event is applied only once
+type ConsumedEvent struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	Type       string    `json:"type" gorm:"not null"`
+	ConsumedAt time.Time `json:"consumed_at" gorm:"autoCreateTime"`
+}
+
+// PremiseVersion is the version of the last premise event of the premise service applied to the
+// replica. It outlives the premise so that events older than its deletion are ignored too.
+type PremiseVersion struct {
+	PremiseID uuid.UUID `json:"premise_id" gorm:"type:uuid;primaryKey"`
+	Version   int64     `json:"version" gorm:"not null"`
+	Deleted   bool      `json:"deleted" gorm:"not null;default:false"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email statuses
+const (
+	EmailStatusPending = "pending" // Waiting for its first or next attempt
+	EmailStatusSent    = "sent"    // Accepted by the mail provider
+	EmailStatusFailed  = "failed"  // Gave up after the maximum number of attempts
+)
+
+// Email is a rendered transactional email queued for sending. It is written in the same
+// transaction as the change it announces and sent by the email dispatcher afterwards.
+type Email struct {
+	Base
+	UserID   *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Template string     `json:"template" gorm:"not null"`
+	To       string     `json:"to" gorm:"not null"`
+	Subject  string     `json:"subject" gorm:"not null"`
+	// The bodies can contain single-use tokens, they are cleared once the email is sent or failed
+	Text          string     `json:"-" gorm:"not null"`
+	HTML          string     `json:"-" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null;default:'pending';index:idx_emails_due,where:status = 'pending'"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index:idx_emails_due,where:status = 'pending'"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "github.com/google/uuid"
+
+// Group is a team of users, e.g. "Night patrol North". Groups can be nested: the members of a
+// group include the members of its subgroups, and premises assigned to a group are inherited by
+// the members of the group and of all its subgroups.
+type Group struct {
+	Base
+	Name          string     `json:"name" gorm:"not null;uniqueIndex"`
+	Description   string     `json:"description"`
+	ParentGroupID *uuid.UUID `json:"parent_group_id,omitempty" gorm:"type:uuid;index"`
+	ParentGroup   *Group     `json:"-" gorm:"foreignKey:ParentGroupID;constraint:OnDelete:RESTRICT"`
+}
+
+type GroupMember struct {
+	Base
+	GroupID uuid.UUID `json:"group_id" gorm:"type:uuid;not null;uniqueIndex:idx_group_members_group_user"`
+	Group   *Group    `json:"-" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE"`
+	UserID  uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_group_members_group_user;index"`
+	User    *User     `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// GroupPremise assigns a premise to every member of a group
+type GroupPremise struct {
+	Base
+	GroupID   uuid.UUID `json:"group_id" gorm:"type:uuid;not null;uniqueIndex:idx_group_premises_group_premise"`
+	Group     *Group    `json:"group,omitempty" gorm:"foreignKey:GroupID;constraint:OnDelete:CASCADE"`
+	PremiseID uuid.UUID `json:"premise_id" gorm:"type:uuid;not null;uniqueIndex:idx_group_premises_group_premise;index"`
+	Premise   *Premise  `json:"premise,omitempty" gorm:"foreignKey:PremiseID;constraint:OnDelete:CASCADE"`
+	Role      string    `json:"role" gorm:"not null;default:'guard'"`
+}
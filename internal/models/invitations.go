@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation invites a user created by an admin to set their own password.
+// Only the SHA-256 hash of the invitation token is stored.
+type Invitation struct {
+	Base
+	UserID      *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	User        *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL"`
+	Email       string     `json:"email" gorm:"not null"`
+	InvitedByID uuid.UUID  `json:"invited_by_id" gorm:"type:uuid"`
+	TokenHash   string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	SentCount   int        `json:"sent_count"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsPending reports whether the invitation can still be accepted
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// OutboxEvent is an event waiting to be published to Kafka. It is written in the same transaction
+// as the change it describes and published by the outbox relay afterwards. Events with the same
+// key are published in the order of their ID.
+type OutboxEvent struct {
+	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Key           string     `json:"key" gorm:"not null;index"`
+	Type          string     `json:"type" gorm:"not null"`
+	Payload       []byte     `json:"payload" gorm:"type:jsonb"` // nil for tombstones
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null"`
+	LastError     string     `json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	PublishedAt   *time.Time `json:"published_at" gorm:"index:idx_outbox_events_pending,where:published_at IS NULL"`
+	// FailedAt is set once the event has failed its last attempt. It is no longer published and
+	// no longer holds back the later events of its key.
+	FailedAt *time.Time `json:"failed_at" gorm:"index:idx_outbox_events_failed,where:failed_at IS NOT NULL"`
+	// EventID is the ID of the event envelope, published as its idempotency key
+	EventID string `json:"event_id" gorm:"not null;default:''"`
+	// TraceParent is the trace context of the request that caused the event
+	TraceParent string `json:"trace_parent" gorm:"not null;default:''"`
+}
@@ -1,11 +1,45 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"scs-user/pkg/geo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type Premise struct {
 	Base
-	Name            string     `json:"name"`
+	Name            string     `json:"name" gorm:"not null"`
 	Address         string     `json:"address"`
-	ParentPremiseID *uuid.UUID `json:"parent_premise_id,omitempty"`
-	ParentPremise   *Premise   `json:"parent_premise,omitempty" gorm:"foreignKey:ParentPremiseID"`
+	ParentPremiseID *uuid.UUID `json:"parent_premise_id,omitempty" gorm:"type:uuid;index"`
+	ParentPremise   *Premise   `json:"parent_premise,omitempty" gorm:"foreignKey:ParentPremiseID;constraint:OnDelete:RESTRICT"`
+	Latitude        *float64   `json:"latitude,omitempty" gorm:"index:idx_premises_location"`
+	Longitude       *float64   `json:"longitude,omitempty" gorm:"index:idx_premises_location"`
+	// Geofence is the premise boundary, stored as a GeoJSON polygon
+	Geofence *geo.Polygon `json:"geofence,omitempty" gorm:"type:jsonb"`
+	// The bounding box of the geofence, used to prefilter point lookups in SQL
+	GeofenceMinLat *float64 `json:"-"`
+	GeofenceMinLng *float64 `json:"-"`
+	GeofenceMaxLat *float64 `json:"-"`
+	GeofenceMaxLng *float64 `json:"-"`
+}
+
+// Location returns the coordinates of the premise, or nil if they are not set
+func (p *Premise) Location() *geo.Point {
+	if p.Latitude == nil || p.Longitude == nil {
+		return nil
+	}
+	return &geo.Point{Lat: *p.Latitude, Lng: *p.Longitude}
+}
+
+// BeforeSave keeps the geofence bounding box in sync with the geofence
+func (p *Premise) BeforeSave(tx *gorm.DB) error {
+	if p.Geofence == nil {
+		p.GeofenceMinLat, p.GeofenceMinLng, p.GeofenceMaxLat, p.GeofenceMaxLng = nil, nil, nil, nil
+		return nil
+	}
+	box := p.Geofence.BoundingBox()
+	p.GeofenceMinLat, p.GeofenceMinLng = &box.MinLat, &box.MinLng
+	p.GeofenceMaxLat, p.GeofenceMaxLng = &box.MaxLat, &box.MaxLng
+	return nil
 }
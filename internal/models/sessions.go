@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login session. Access tokens carry the session ID and are only
+// accepted while the session is neither revoked nor expired.
+type Session struct {
+	Base
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	User      *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	UserAgent string     `json:"user_agent"`
+	IPAddress string     `json:"ip_address"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"scs-user/pkg/recurrence"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Shift schedules a user at a premise. Recurring shifts repeat the first occurrence
+// from StartsAt to EndsAt according to an RFC 5545 recurrence rule.
+type Shift struct {
+	Base
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	User       *User     `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PremiseID  uuid.UUID `json:"premise_id" gorm:"type:uuid;not null;index"`
+	Premise    *Premise  `json:"premise,omitempty" gorm:"foreignKey:PremiseID;constraint:OnDelete:CASCADE"`
+	StartsAt   time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt     time.Time `json:"ends_at" gorm:"not null"`
+	Recurrence string    `json:"recurrence,omitempty"`
+	// ScheduleEndsAt is the end of the last occurrence, nil for open-ended recurring shifts
+	ScheduleEndsAt *time.Time `json:"schedule_ends_at,omitempty" gorm:"index"`
+	Notes          string     `json:"notes,omitempty"`
+	CreatedByID    *uuid.UUID `json:"created_by_id,omitempty" gorm:"type:uuid"`
+}
+
+// ShiftOccurrence is a single, concrete occurrence of a shift
+type ShiftOccurrence struct {
+	ShiftID   uuid.UUID `json:"shift_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	PremiseID uuid.UUID `json:"premise_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+// Overlaps reports whether the two occurrences share any time
+func (o ShiftOccurrence) Overlaps(other ShiftOccurrence) bool {
+	return o.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(o.EndsAt)
+}
+
+func (s *Shift) Duration() time.Duration {
+	return s.EndsAt.Sub(s.StartsAt)
+}
+
+// Occurrences returns the occurrences of the shift that overlap [from, to)
+func (s *Shift) Occurrences(from time.Time, to time.Time) []ShiftOccurrence {
+	var starts []time.Time
+	rule, err := s.rule()
+	if err != nil || rule == nil {
+		starts = []time.Time{s.StartsAt}
+	} else {
+		// Occurrences starting up to one duration before the window still overlap it
+		starts = rule.Between(s.StartsAt, from.Add(-s.Duration()), to)
+	}
+
+	var occurrences []ShiftOccurrence
+	for _, start := range starts {
+		occurrence := ShiftOccurrence{
+			ShiftID:   s.ID,
+			UserID:    s.UserID,
+			PremiseID: s.PremiseID,
+			StartsAt:  start,
+			EndsAt:    start.Add(s.Duration()),
+		}
+		if occurrence.StartsAt.Before(to) && occurrence.EndsAt.After(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences
+}
+
+// SetScheduleEnd computes ScheduleEndsAt from the recurrence rule
+func (s *Shift) SetScheduleEnd() error {
+	rule, err := s.rule()
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		end := s.EndsAt
+		s.ScheduleEndsAt = &end
+		return nil
+	}
+	last, bounded := rule.Last(s.StartsAt)
+	if !bounded {
+		s.ScheduleEndsAt = nil
+		return nil
+	}
+	end := last.Add(s.Duration())
+	s.ScheduleEndsAt = &end
+	return nil
+}
+
+// rule returns the parsed recurrence rule, or nil for a one-off shift
+func (s *Shift) rule() (*recurrence.Rule, error) {
+	if s.Recurrence == "" {
+		return nil, nil
+	}
+	return recurrence.Parse(s.Recurrence)
+}
+
+// ShiftAttendance records a user clocking in and out of a shift occurrence
+type ShiftAttendance struct {
+	Base
+	ShiftID        uuid.UUID  `json:"shift_id" gorm:"type:uuid;not null;uniqueIndex:idx_shift_attendances_occurrence"`
+	Shift          *Shift     `json:"-" gorm:"foreignKey:ShiftID;constraint:OnDelete:CASCADE"`
+	ScheduledStart time.Time  `json:"scheduled_start" gorm:"not null;uniqueIndex:idx_shift_attendances_occurrence"`
+	ScheduledEnd   time.Time  `json:"scheduled_end" gorm:"not null"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_shift_attendances_open,where:clock_out_at IS NULL"`
+	User           *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PremiseID      uuid.UUID  `json:"premise_id" gorm:"type:uuid;not null;index"`
+	ClockInAt      time.Time  `json:"clock_in_at" gorm:"not null"`
+	ClockOutAt     *time.Time `json:"clock_out_at,omitempty"`
+}
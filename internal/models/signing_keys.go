@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// SigningKey is a secret that signs access tokens. The newest key signs new tokens, retired keys
+// only verify the tokens they signed until those expire.
+type SigningKey struct {
+	Base
+	Secret    string     `json:"-" gorm:"not null"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
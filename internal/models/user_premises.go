@@ -1,11 +1,35 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of a user at a premise
+const (
+	PremiseRoleGuard      = "guard"
+	PremiseRoleSupervisor = "supervisor"
+)
 
 type UserPremise struct {
 	Base
-	UserID    uuid.UUID `json:"user_id"`
-	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	PremiseID uuid.UUID `json:"premise_id"`
-	Premise   *Premise  `json:"premise,omitempty" gorm:"foreignKey:PremiseID"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_premises_user_premise"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PremiseID uuid.UUID  `json:"premise_id" gorm:"type:uuid;not null;uniqueIndex:idx_user_premises_user_premise;index"`
+	Premise   *Premise   `json:"premise,omitempty" gorm:"foreignKey:PremiseID;constraint:OnDelete:CASCADE"`
+	Role      string     `json:"role" gorm:"not null;default:'guard'"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+}
+
+// IsActiveAt reports whether the assignment is valid at the given time
+func (up *UserPremise) IsActiveAt(t time.Time) bool {
+	if up.StartsAt != nil && t.Before(*up.StartsAt) {
+		return false
+	}
+	if up.EndsAt != nil && !t.Before(*up.EndsAt) {
+		return false
+	}
+	return true
 }
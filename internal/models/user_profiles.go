@@ -0,0 +1,20 @@
+package models
+
+import "github.com/google/uuid"
+
+type UserProfile struct {
+	Base
+	UserID                   uuid.UUID         `json:"user_id" gorm:"type:uuid;uniqueIndex;not null"`
+	User                     *User             `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Phone                    string            `json:"phone"`
+	EmployeeNumber           *string           `json:"employee_number" gorm:"uniqueIndex"`
+	Locale                   string            `json:"locale"`
+	TimeZone                 string            `json:"time_zone"`
+	EmergencyContactName     string            `json:"emergency_contact_name"`
+	EmergencyContactPhone    string            `json:"emergency_contact_phone"`
+	EmergencyContactRelation string            `json:"emergency_contact_relation"`
+	AvatarKey                string            `json:"-"`
+	AvatarThumbnailKeys      map[string]string `json:"-" gorm:"serializer:json"`
+	AvatarURL                string            `json:"avatar_url,omitempty" gorm:"-"`
+	AvatarThumbnailURLs      map[string]string `json:"avatar_thumbnail_urls,omitempty" gorm:"-"`
+}
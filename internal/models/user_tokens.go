@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purposes of single-use user tokens
+const (
+	TokenPurposeEmailChange   = "email_change"
+	TokenPurposeVerification  = "verification"
+	TokenPurposePasswordReset = "password_reset"
+)
+
+// UserToken is a single-use, expiring token sent to a user, e.g. to confirm a new email address.
+// Only the SHA-256 hash of the token is stored.
+type UserToken struct {
+	Base
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	User       *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Purpose    string     `json:"purpose" gorm:"not null"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"`
+	Data       string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+}
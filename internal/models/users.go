@@ -1,10 +1,38 @@
 package models
 
+import "time"
+
+// User statuses
+const (
+	UserStatusInvited   = "invited"   // Invited by an admin, has not set a password yet
+	UserStatusPending   = "pending"   // Created with a password, has not verified the account yet
+	UserStatusActive    = "active"    // Can log in
+	UserStatusSuspended = "suspended" // Blocked by an admin
+)
+
 type User struct {
 	Base
 	Name     string `json:"name" gorm:"not null"`
 	Email    string `json:"email" gorm:"unique;not null"`
 	Password string `json:"-" gorm:"not null"`
 	Role     string `json:"role" gorm:"not null"`
-	IsActive bool   `json:"is_active"`
+	Status   string `json:"status" gorm:"not null"` // Set with SetStatus, there is no default
+	// IsActive is true only for users with the active status
+	IsActive bool `json:"is_active"`
+	// PendingEmail is the new address awaiting confirmation
+	PendingEmail *string `json:"pending_email,omitempty"`
+	// FailedLogins counts the failed logins since the last successful one or the last lockout
+	FailedLogins int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+// SetStatus updates the status and keeps IsActive in sync with it
+func (u *User) SetStatus(status string) {
+	u.Status = status
+	u.IsActive = status == UserStatusActive
+}
+
+// IsLocked reports whether the account is locked after too many failed logins
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(now)
 }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEventTypeAll subscribes a webhook to every event type
+const WebhookEventTypeAll = "*"
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryStatusPending   = "pending"   // Waiting for its first or next attempt
+	WebhookDeliveryStatusSucceeded = "succeeded" // Acknowledged by the endpoint with a 2xx response
+	WebhookDeliveryStatusFailed    = "failed"    // Gave up after the maximum number of attempts
+)
+
+// WebhookSubscription delivers the events of the subscribed types to an HTTP endpoint of a
+// partner that can not consume Kafka. Deliveries are signed with the secret of the subscription.
+type WebhookSubscription struct {
+	Base
+	URL         string   `json:"url" gorm:"not null"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types" gorm:"type:jsonb;serializer:json;not null"`
+	Secret      string   `json:"-" gorm:"not null"`
+	Enabled     bool     `json:"enabled" gorm:"not null;default:true"`
+	// ConsecutiveFailures counts the failed attempts since the last successful delivery, the
+	// subscription is disabled once it reaches the configured limit
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	CreatedByID         uuid.UUID  `json:"created_by_id" gorm:"type:uuid;not null"`
+}
+
+// WebhookDelivery is an event to deliver to a subscription, and the log of its attempts
+type WebhookDelivery struct {
+	Base
+	SubscriptionID uuid.UUID            `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	Subscription   *WebhookSubscription `json:"-" gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+	EventID        string               `json:"event_id" gorm:"not null"`
+	EventType      string               `json:"event_type" gorm:"not null"`
+	Payload        []byte               `json:"-" gorm:"type:jsonb;not null"`
+	Status         string               `json:"status" gorm:"not null;default:'pending';index:idx_webhook_deliveries_due,where:status = 'pending'"`
+	Attempts       int                  `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time            `json:"next_attempt_at" gorm:"not null;index:idx_webhook_deliveries_due,where:status = 'pending'"`
+	// The outcome of the last attempt
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastResponse   string     `json:"last_response,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastDurationMs *int64     `json:"last_duration_ms,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/dto"
+	"scs-user/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AssignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAssignmentRepository(db *gorm.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+// CreateAssignment creates the assignment together with its steps
+func (r *AssignmentRepository) CreateAssignment(ctx context.Context, assignment *models.Assignment) error {
+	if err := r.db.WithContext(ctx).Omit("User", "Premise").Create(assignment).Error; err != nil {
+		return fmt.Errorf("failed to create assignment: %w", err)
+	}
+	return nil
+}
+
+// GetAssignmentByID returns the assignment with its ordered steps, or nil if it does not exist
+func (r *AssignmentRepository) GetAssignmentByID(ctx context.Context, id string) (*models.Assignment, error) {
+	var assignment models.Assignment
+	if err := r.db.WithContext(ctx).
+		Preload("Premise").
+		Preload("Steps", orderStepsByPosition).
+		First(&assignment, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+	return &assignment, nil
+}
+
+// GetAssignmentForUpdate returns the assignment with its ordered steps and locks the
+// assignment row until the end of the transaction, or nil if it does not exist
+func (r *AssignmentRepository) GetAssignmentForUpdate(ctx context.Context, id string) (*models.Assignment, error) {
+	var assignment models.Assignment
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Steps", orderStepsByPosition).
+		First(&assignment, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+	return &assignment, nil
+}
+
+// GetByUser returns the assignments of the user, open ones due first
+func (r *AssignmentRepository) GetByUser(ctx context.Context, userID string, filter dto.AssignmentFilter) ([]models.Assignment, error) {
+	var assignments []models.Assignment
+	query := r.db.WithContext(ctx).
+		Preload("Premise").
+		Preload("Steps", orderStepsByPosition).
+		Where("user_id = ?", userID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if err := query.Order("due_at ASC NULLS LAST, created_at DESC").Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// UpdateAssignment saves the assignment without touching its steps
+func (r *AssignmentRepository) UpdateAssignment(ctx context.Context, assignment *models.Assignment) error {
+	if err := r.db.WithContext(ctx).Omit("User", "Premise", "Steps").Save(assignment).Error; err != nil {
+		return fmt.Errorf("failed to save assignment: %w", err)
+	}
+	return nil
+}
+
+// CompleteStep marks the step as completed unless it already is, and reports whether it was updated
+func (r *AssignmentRepository) CompleteStep(ctx context.Context, step *models.AssignmentStep, completedByID uuid.UUID, completedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AssignmentStep{}).
+		Where("id = ? AND completed_at IS NULL", step.ID).
+		Updates(map[string]interface{}{
+			"completed_at":          completedAt,
+			"completed_by_id":       completedByID,
+			"note":                  step.Note,
+			"evidence_key":          step.EvidenceKey,
+			"evidence_content_type": step.EvidenceContentType,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to complete assignment step: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	step.CompletedAt = &completedAt
+	step.CompletedByID = &completedByID
+	return true, nil
+}
+
+func orderStepsByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("assignment_steps.position")
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CertificationRepository struct {
+	db *gorm.DB
+}
+
+func NewCertificationRepository(db *gorm.DB) *CertificationRepository {
+	return &CertificationRepository{db: db}
+}
+
+func (r *CertificationRepository) CreateCertification(ctx context.Context, certification *models.Certification) error {
+	if err := r.db.WithContext(ctx).Omit("User").Create(certification).Error; err != nil {
+		return fmt.Errorf("failed to create certification: %w", err)
+	}
+	return nil
+}
+
+// GetCertificationByID returns the certification, or nil if it does not exist
+func (r *CertificationRepository) GetCertificationByID(ctx context.Context, id string) (*models.Certification, error) {
+	var certification models.Certification
+	if err := r.db.WithContext(ctx).First(&certification, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get certification: %w", err)
+	}
+	return &certification, nil
+}
+
+// LockCertification returns the certification and locks its row until the end of the transaction,
+// or nil if it does not exist
+func (r *CertificationRepository) LockCertification(ctx context.Context, id string) (*models.Certification, error) {
+	var certification models.Certification
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&certification, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock certification: %w", err)
+	}
+	return &certification, nil
+}
+
+func (r *CertificationRepository) GetByUser(ctx context.Context, userID string) ([]models.Certification, error) {
+	var certifications []models.Certification
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("type, expires_at DESC").Find(&certifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user certifications: %w", err)
+	}
+	return certifications, nil
+}
+
+func (r *CertificationRepository) UpdateCertification(ctx context.Context, certification *models.Certification) error {
+	if err := r.db.WithContext(ctx).Omit("User").Save(certification).Error; err != nil {
+		return fmt.Errorf("failed to save certification: %w", err)
+	}
+	return nil
+}
+
+func (r *CertificationRepository) DeleteCertification(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Certification{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete certification: %w", err)
+	}
+	return nil
+}
+
+// GetExpiring returns the verified certifications expiring in (from, until], soonest first.
+// If unnotifiedOnly is set, certifications whose expiry has already been announced are skipped.
+func (r *CertificationRepository) GetExpiring(ctx context.Context, from time.Time, until time.Time, unnotifiedOnly bool) ([]models.Certification, error) {
+	var certifications []models.Certification
+	query := r.db.WithContext(ctx).
+		Where("status = ?", models.CertificationStatusVerified).
+		Where("expires_at > ? AND expires_at <= ?", from, until)
+	if unnotifiedOnly {
+		query = query.Where("expiry_notified_at IS NULL")
+	}
+	if err := query.Order("expires_at").Find(&certifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expiring certifications: %w", err)
+	}
+	return certifications, nil
+}
+
+// MarkExpiryNotified sets the expiry notification time unless it is already set, and reports
+// whether it was set by this call. This lets concurrent replicas announce each expiry only once.
+func (r *CertificationRepository) MarkExpiryNotified(ctx context.Context, id string, notifiedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Certification{}).
+		Where("id = ? AND expiry_notified_at IS NULL", id).
+		Update("expiry_notified_at", notifiedAt)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark certification expiry notified: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetValidTypes returns the types of the user's certifications that are verified and not expired at the given time
+func (r *CertificationRepository) GetValidTypes(ctx context.Context, userID string, at time.Time) ([]string, error) {
+	var types []string
+	if err := r.db.WithContext(ctx).Model(&models.Certification{}).
+		Distinct("type").
+		Where("user_id = ? AND status = ?", userID, models.CertificationStatusVerified).
+		Where("(expires_at IS NULL OR expires_at > ?)", at).
+		Pluck("type", &types).Error; err != nil {
+		return nil, fmt.Errorf("failed to get valid certification types: %w", err)
+	}
+	return types, nil
+}
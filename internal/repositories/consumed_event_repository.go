@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"scs-user/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConsumedEventRepository struct {
+	db *gorm.DB
+}
+
+func NewConsumedEventRepository(db *gorm.DB) *ConsumedEventRepository {
+	return &ConsumedEventRepository{db: db}
+}
+
+// MarkConsumed records the event and reports whether it was new. Use the repository of a unit of
+// work so that the event is only recorded if the change it causes is committed.
+func (r *ConsumedEventRepository) MarkConsumed(ctx context.Context, id string, eventType string) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ConsumedEvent{ID: id, Type: eventType})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark event consumed: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailRepository(db *gorm.DB) *EmailRepository {
+	return &EmailRepository{db: db}
+}
+
+// AddEmail queues the email. Use the repository of a unit of work to queue it together with the
+// change it announces.
+func (r *EmailRepository) AddEmail(ctx context.Context, email *models.Email) error {
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = time.Now()
+	}
+	if err := r.db.WithContext(ctx).Create(email).Error; err != nil {
+		return fmt.Errorf("failed to add email: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue locks the oldest pending emails that are due, skipping the ones locked by other
+// replicas. Use the repository of a unit of work so that the lock is held until the results are
+// recorded.
+func (r *EmailRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]models.Email, error) {
+	var emails []models.Email
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", models.EmailStatusPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&emails).Error; err != nil {
+		return nil, fmt.Errorf("failed to claim due emails: %w", err)
+	}
+	return emails, nil
+}
+
+// SaveAttempt stores the outcome of a sending attempt, and the cleared bodies of a finished email
+func (r *EmailRepository) SaveAttempt(ctx context.Context, email *models.Email) error {
+	if err := r.db.WithContext(ctx).Model(email).Select(
+		"Status", "Attempts", "NextAttemptAt", "LastError", "SentAt", "Text", "HTML",
+	).Updates(email).Error; err != nil {
+		return fmt.Errorf("failed to save email attempt: %w", err)
+	}
+	return nil
+}
+
+// DeleteFinished deletes the sent and failed emails created before the given time and returns
+// how many were deleted
+func (r *EmailRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status <> ? AND created_at < ?", models.EmailStatusPending, before).
+		Delete(&models.Email{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete finished emails: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
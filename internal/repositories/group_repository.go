@@ -0,0 +1,310 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/dto"
+	"scs-user/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GroupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository(db *gorm.DB) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+func (r *GroupRepository) CreateGroup(ctx context.Context, group *models.Group) error {
+	if err := r.db.WithContext(ctx).Omit("ParentGroup").Create(group).Error; err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+	return nil
+}
+
+// GetGroupByID returns the group, or nil if it does not exist
+func (r *GroupRepository) GetGroupByID(ctx context.Context, id string) (*models.Group, error) {
+	var group models.Group
+	if err := r.db.WithContext(ctx).First(&group, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return &group, nil
+}
+
+// LockGroups returns the groups with the given IDs and locks their rows until the end of the
+// transaction. The rows are locked in ID order, so that transactions locking the same groups do
+// not deadlock.
+func (r *GroupRepository) LockGroups(ctx context.Context, ids []uuid.UUID) ([]models.Group, error) {
+	var groups []models.Group
+	if len(ids) == 0 {
+		return groups, nil
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (r *GroupRepository) GetGroups(ctx context.Context, filter dto.GroupFilter, page int, limit int) ([]models.Group, error) {
+	var groups []models.Group
+	query := applyGroupFilter(r.db.WithContext(ctx), filter)
+	if err := query.Order("name").Limit(limit).Offset((page - 1) * limit).Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (r *GroupRepository) GetGroupsCount(ctx context.Context, filter dto.GroupFilter) (int64, error) {
+	var count int64
+	query := applyGroupFilter(r.db.WithContext(ctx).Model(&models.Group{}), filter)
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to get groups count: %w", err)
+	}
+	return count, nil
+}
+
+func (r *GroupRepository) UpdateGroup(ctx context.Context, group *models.Group) error {
+	if err := r.db.WithContext(ctx).Omit("ParentGroup").Save(group).Error; err != nil {
+		return fmt.Errorf("failed to save group: %w", err)
+	}
+	return nil
+}
+
+func (r *GroupRepository) DeleteGroup(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Group{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	return nil
+}
+
+// CountChildren returns the number of direct subgroups of the group
+func (r *GroupRepository) CountChildren(ctx context.Context, id string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Group{}).Where("parent_group_id = ?", id).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count subgroups: %w", err)
+	}
+	return count, nil
+}
+
+// IsInSubtree reports whether candidateID is rootID itself or one of its subgroups
+func (r *GroupRepository) IsInSubtree(ctx context.Context, rootID string, candidateID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE subtree AS (
+			SELECT g.id, 0 AS depth FROM groups g WHERE g.id = ?
+			UNION ALL
+			SELECT g.id, s.depth + 1 FROM groups g
+			JOIN subtree s ON g.parent_group_id = s.id
+			WHERE s.depth < ?
+		)
+		SELECT COUNT(*) FROM subtree WHERE id = ?`, rootID, maxTreeDepth, candidateID).
+		Scan(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check group subtree: %w", err)
+	}
+	return count > 0, nil
+}
+
+// AddMembers adds the users to the group, skipping users who already are members
+func (r *GroupRepository) AddMembers(ctx context.Context, groupID uuid.UUID, userIDs []uuid.UUID) error {
+	members := make([]models.GroupMember, len(userIDs))
+	for i, userID := range userIDs {
+		members[i] = models.GroupMember{GroupID: groupID, UserID: userID}
+	}
+	if err := r.db.WithContext(ctx).
+		Omit("Group", "User").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&members).Error; err != nil {
+		return fmt.Errorf("failed to add group members: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember removes the user from the group and reports whether the user was a member
+func (r *GroupRepository) RemoveMember(ctx context.Context, groupID string, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&models.GroupMember{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to remove group member: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ResolveMembers returns the distinct users of the group, including the members of all its
+// subgroups unless directOnly is set
+func (r *GroupRepository) ResolveMembers(ctx context.Context, groupID string, directOnly bool, activeOnly bool) ([]models.User, error) {
+	var users []models.User
+	depth := maxTreeDepth
+	if directOnly {
+		depth = 0
+	}
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT g.id, 0 AS depth FROM groups g WHERE g.id = ?
+			UNION ALL
+			SELECT g.id, s.depth + 1 FROM groups g
+			JOIN subtree s ON g.parent_group_id = s.id
+			WHERE s.depth < ?
+		)
+		SELECT * FROM users u
+		WHERE u.id IN (SELECT m.user_id FROM group_members m WHERE m.group_id IN (SELECT id FROM subtree))`
+	if activeOnly {
+		query += " AND u.is_active"
+	}
+	query += " ORDER BY u.name"
+	if err := r.db.WithContext(ctx).Raw(query, groupID, depth).Scan(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve group members: %w", err)
+	}
+	return users, nil
+}
+
+// GetUserGroups returns the groups the user is a direct member of
+func (r *GroupRepository) GetUserGroups(ctx context.Context, userID string) ([]models.Group, error) {
+	var groups []models.Group
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN group_members ON group_members.group_id = groups.id").
+		Where("group_members.user_id = ?", userID).
+		Order("groups.name").
+		Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (r *GroupRepository) AddPremise(ctx context.Context, groupPremise *models.GroupPremise) error {
+	if err := r.db.WithContext(ctx).Omit("Group", "Premise").Create(groupPremise).Error; err != nil {
+		return fmt.Errorf("failed to assign group premise: %w", err)
+	}
+	return nil
+}
+
+// RemovePremise removes the premise from the group and reports whether it was assigned
+func (r *GroupRepository) RemovePremise(ctx context.Context, groupID string, premiseID string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("group_id = ? AND premise_id = ?", groupID, premiseID).Delete(&models.GroupPremise{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to remove group premise: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *GroupRepository) GetGroupPremises(ctx context.Context, groupID string) ([]models.GroupPremise, error) {
+	var groupPremises []models.GroupPremise
+	if err := r.db.WithContext(ctx).Preload("Premise").Where("group_id = ?", groupID).Order("created_at").Find(&groupPremises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get group premises: %w", err)
+	}
+	return groupPremises, nil
+}
+
+// GetInheritedPremises returns the premise assignments of every group the user belongs to,
+// directly or through a subgroup
+func (r *GroupRepository) GetInheritedPremises(ctx context.Context, userID string) ([]models.GroupPremise, error) {
+	groupIDs, err := r.getUserGroupIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var groupPremises []models.GroupPremise
+	if len(groupIDs) == 0 {
+		return groupPremises, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Preload("Group").
+		Preload("Premise").
+		Where("group_id IN ?", groupIDs).
+		Find(&groupPremises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get inherited premises: %w", err)
+	}
+	return groupPremises, nil
+}
+
+// GetInheritedPremisesByUsers returns the premise assignments the users inherit from their groups,
+// directly or through a subgroup, by user
+func (r *GroupRepository) GetInheritedPremisesByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.GroupPremise, error) {
+	inherited := make(map[uuid.UUID][]models.GroupPremise)
+	if len(userIDs) == 0 {
+		return inherited, nil
+	}
+	var rows []struct {
+		UserID    uuid.UUID
+		GroupID   uuid.UUID
+		PremiseID uuid.UUID
+		Role      string
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT m.user_id, g.id, g.parent_group_id, 0 AS depth FROM groups g
+			JOIN group_members m ON m.group_id = g.id
+			WHERE m.user_id IN ?
+			UNION ALL
+			SELECT a.user_id, g.id, g.parent_group_id, a.depth + 1 FROM groups g
+			JOIN ancestors a ON g.id = a.parent_group_id
+			WHERE a.depth < ?
+		)
+		SELECT DISTINCT a.user_id, gp.group_id, gp.premise_id, gp.role FROM ancestors a
+		JOIN group_premises gp ON gp.group_id = a.id
+		ORDER BY a.user_id, gp.premise_id, gp.group_id`, userIDs, maxTreeDepth).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inherited premises: %w", err)
+	}
+	for _, row := range rows {
+		inherited[row.UserID] = append(inherited[row.UserID], models.GroupPremise{GroupID: row.GroupID, PremiseID: row.PremiseID, Role: row.Role})
+	}
+	return inherited, nil
+}
+
+// InheritsPremise reports whether the user is assigned to the premise through a group
+func (r *GroupRepository) InheritsPremise(ctx context.Context, userID string, premiseID string) (bool, error) {
+	groupIDs, err := r.getUserGroupIDs(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if len(groupIDs) == 0 {
+		return false, nil
+	}
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.GroupPremise{}).
+		Where("group_id IN ? AND premise_id = ?", groupIDs, premiseID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check inherited premise: %w", err)
+	}
+	return count > 0, nil
+}
+
+// getUserGroupIDs returns the groups the user is a member of together with all their ancestors
+func (r *GroupRepository) getUserGroupIDs(ctx context.Context, userID string) ([]uuid.UUID, error) {
+	var groupIDs []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT g.id, g.parent_group_id, 0 AS depth FROM groups g
+			JOIN group_members m ON m.group_id = g.id
+			WHERE m.user_id = ?
+			UNION ALL
+			SELECT g.id, g.parent_group_id, a.depth + 1 FROM groups g
+			JOIN ancestors a ON g.id = a.parent_group_id
+			WHERE a.depth < ?
+		)
+		SELECT DISTINCT id FROM ancestors`, userID, maxTreeDepth).
+		Scan(&groupIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user groups: %w", err)
+	}
+	return groupIDs, nil
+}
+
+// applyGroupFilter adds the where clauses of the filter to the query
+func applyGroupFilter(query *gorm.DB, filter dto.GroupFilter) *gorm.DB {
+	if filter.Search != "" {
+		query = query.Where("groups.name ILIKE ?", "%"+filter.Search+"%")
+	}
+	if filter.ParentGroupID != "" {
+		query = query.Where("groups.parent_group_id = ?", filter.ParentGroupID)
+	}
+	return query
+}
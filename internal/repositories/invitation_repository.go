@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+func (r *InvitationRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	if err := r.db.WithContext(ctx).Create(invitation).Error; err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *InvitationRepository) GetInvitationByID(ctx context.Context, id string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.db.WithContext(ctx).First(&invitation, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return &invitation, nil
+}
+
+// GetInvitationByTokenHash returns the invitation with the token hash, or nil if there is none
+func (r *InvitationRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.db.WithContext(ctx).First(&invitation, "token_hash = ?", tokenHash).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return &invitation, nil
+}
+
+// LockInvitation returns the invitation and locks its row until the end of the transaction, or
+// nil if it does not exist
+func (r *InvitationRepository) LockInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	return r.lockInvitation(ctx, "id = ?", id)
+}
+
+// LockInvitationByTokenHash returns the invitation with the token hash and locks its row until the
+// end of the transaction, or nil if there is none
+func (r *InvitationRepository) LockInvitationByTokenHash(ctx context.Context, tokenHash string) (*models.Invitation, error) {
+	return r.lockInvitation(ctx, "token_hash = ?", tokenHash)
+}
+
+func (r *InvitationRepository) lockInvitation(ctx context.Context, query string, arg string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&invitation, query, arg).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock invitation: %w", err)
+	}
+	return &invitation, nil
+}
+
+// UpdateOpenInvitation updates the columns of the invitation only while it is neither accepted
+// nor revoked, and reports whether it was
+func (r *InvitationRepository) UpdateOpenInvitation(ctx context.Context, id string, columns map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Updates(columns)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update invitation: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *InvitationRepository) UpdateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	if err := r.db.WithContext(ctx).Save(invitation).Error; err != nil {
+		return fmt.Errorf("failed to save invitation: %w", err)
+	}
+	return nil
+}
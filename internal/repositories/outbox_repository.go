@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// outboxRelayLockID is the advisory lock held by the replica that is relaying the outbox
+const outboxRelayLockID = 7234501
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// AddEvent stores the event in the outbox. Use the repository of a unit of work to store it
+// together with the change it describes.
+func (r *OutboxRepository) AddEvent(ctx context.Context, event *models.OutboxEvent) error {
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = time.Now()
+	}
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to add outbox event: %w", err)
+	}
+	return nil
+}
+
+// TryLockRelay takes the relay lock for the current transaction and reports whether it was free.
+// Only one replica relays at a time so that events of the same key stay in order.
+func (r *OutboxRepository) TryLockRelay(ctx context.Context) (bool, error) {
+	var locked bool
+	if err := r.db.WithContext(ctx).Raw("SELECT pg_try_advisory_xact_lock(?)", outboxRelayLockID).Scan(&locked).Error; err != nil {
+		return false, fmt.Errorf("failed to lock outbox relay: %w", err)
+	}
+	return locked, nil
+}
+
+// GetPending returns the oldest due events in publishing order. Events are left out while an
+// earlier unpublished event of their key is waiting for a retry, so that a key that is held back
+// does not fill the batch and stall all other keys.
+func (r *OutboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	if err := r.db.WithContext(ctx).
+		Where("published_at IS NULL AND failed_at IS NULL").
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events AS earlier WHERE earlier.key = outbox_events.key
+			AND earlier.id <= outbox_events.id AND earlier.published_at IS NULL AND earlier.failed_at IS NULL
+			AND earlier.next_attempt_at > ?)`, now).
+		Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get pending outbox events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, ids []int64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("published_at", at).Error; err != nil {
+		return fmt.Errorf("failed to mark outbox events published: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publishing attempt and when to retry it
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// MarkAbandoned records the last failed publishing attempt and sets the event aside as failed
+func (r *OutboxRepository) MarkAbandoned(ctx context.Context, id int64, at time.Time, lastError string) error {
+	if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"failed_at":  at,
+		"last_error": lastError,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to mark outbox event abandoned: %w", err)
+	}
+	return nil
+}
+
+// GetBacklog returns the number of unpublished events, when the oldest of them was created and
+// how many of them have been set aside as failed
+func (r *OutboxRepository) GetBacklog(ctx context.Context) (int64, *time.Time, int64, error) {
+	var backlog struct {
+		Count  int64
+		Oldest *time.Time
+		Failed int64
+	}
+	if err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Select("COUNT(*) FILTER (WHERE failed_at IS NULL) AS count, MIN(created_at) FILTER (WHERE failed_at IS NULL) AS oldest, COUNT(*) FILTER (WHERE failed_at IS NOT NULL) AS failed").
+		Where("published_at IS NULL").
+		Scan(&backlog).Error; err != nil {
+		return 0, nil, 0, fmt.Errorf("failed to get outbox backlog: %w", err)
+	}
+	return backlog.Count, backlog.Oldest, backlog.Failed, nil
+}
+
+// DeletePublished deletes the events published before the given time and returns how many were deleted
+func (r *OutboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("published_at < ?", before).Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/dto"
+	"scs-user/internal/models"
+	"scs-user/pkg/geo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxTreeDepth bounds the recursive queries in case the hierarchy ever contains a cycle
+const maxTreeDepth = 64
+
+type PremiseRepository struct {
+	db *gorm.DB
+}
+
+func NewPremiseRepository(db *gorm.DB) *PremiseRepository {
+	return &PremiseRepository{db: db}
+}
+
+func (r *PremiseRepository) CreatePremise(ctx context.Context, premise *models.Premise) error {
+	if err := r.db.WithContext(ctx).Omit("ParentPremise").Create(premise).Error; err != nil {
+		return fmt.Errorf("failed to create premise: %w", err)
+	}
+	return nil
+}
+
+// GetPremiseByID returns the premise, or nil if it does not exist
+func (r *PremiseRepository) GetPremiseByID(ctx context.Context, id string) (*models.Premise, error) {
+	var premise models.Premise
+	if err := r.db.WithContext(ctx).First(&premise, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get premise: %w", err)
+	}
+	return &premise, nil
+}
+
+// LockPremises returns the premises with the given IDs and locks their rows until the end of the
+// transaction. The rows are locked in ID order, so that transactions locking the same premises
+// do not deadlock.
+func (r *PremiseRepository) LockPremises(ctx context.Context, ids []uuid.UUID) ([]models.Premise, error) {
+	var premises []models.Premise
+	if len(ids) == 0 {
+		return premises, nil
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&premises).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock premises: %w", err)
+	}
+	return premises, nil
+}
+
+// PremiseExists reports whether a premise with the given ID exists
+func (r *PremiseRepository) PremiseExists(ctx context.Context, id string) (bool, error) {
+	premise, err := r.GetPremiseByID(ctx, id)
+	return premise != nil, err
+}
+
+// CountExistingPremises returns how many of the given premise IDs exist
+func (r *PremiseRepository) CountExistingPremises(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	var count int64
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Premise{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count premises: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PremiseRepository) GetPremises(ctx context.Context, filter dto.PremiseFilter, page int, limit int) ([]models.Premise, error) {
+	var premises []models.Premise
+	query := applyPremiseFilter(r.db.WithContext(ctx), filter)
+	if err := query.Order("name").Limit(limit).Offset((page - 1) * limit).Find(&premises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get premises: %w", err)
+	}
+	return premises, nil
+}
+
+func (r *PremiseRepository) GetPremisesCount(ctx context.Context, filter dto.PremiseFilter) (int64, error) {
+	var count int64
+	query := applyPremiseFilter(r.db.WithContext(ctx).Model(&models.Premise{}), filter)
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to get premises count: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PremiseRepository) UpdatePremise(ctx context.Context, premise *models.Premise) error {
+	if err := r.db.WithContext(ctx).Omit("ParentPremise").Save(premise).Error; err != nil {
+		return fmt.Errorf("failed to save premise: %w", err)
+	}
+	return nil
+}
+
+func (r *PremiseRepository) DeletePremise(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Premise{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete premise: %w", err)
+	}
+	return nil
+}
+
+// GetChildren returns the direct children of the premise
+func (r *PremiseRepository) GetChildren(ctx context.Context, id string) ([]models.Premise, error) {
+	var premises []models.Premise
+	if err := r.db.WithContext(ctx).Where("parent_premise_id = ?", id).Order("name").Find(&premises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get child premises: %w", err)
+	}
+	return premises, nil
+}
+
+// CountChildren returns the number of direct children of the premise
+func (r *PremiseRepository) CountChildren(ctx context.Context, id string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Premise{}).Where("parent_premise_id = ?", id).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count child premises: %w", err)
+	}
+	return count, nil
+}
+
+// GetAncestors returns the ancestors of the premise ordered from the root down to its parent
+func (r *PremiseRepository) GetAncestors(ctx context.Context, id string) ([]models.Premise, error) {
+	var premises []models.Premise
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT p.*, 0 AS depth FROM premises p WHERE p.id = ?
+			UNION ALL
+			SELECT p.*, a.depth + 1 FROM premises p
+			JOIN ancestors a ON p.id = a.parent_premise_id
+			WHERE a.depth < ?
+		)
+		SELECT * FROM ancestors WHERE depth > 0 ORDER BY depth DESC`, id, maxTreeDepth).
+		Scan(&premises).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premise ancestors: %w", err)
+	}
+	return premises, nil
+}
+
+// GetSubtree returns the premise and all of its descendants ordered by depth
+func (r *PremiseRepository) GetSubtree(ctx context.Context, id string) ([]models.Premise, error) {
+	var premises []models.Premise
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE subtree AS (
+			SELECT p.*, 0 AS depth FROM premises p WHERE p.id = ?
+			UNION ALL
+			SELECT p.*, s.depth + 1 FROM premises p
+			JOIN subtree s ON p.parent_premise_id = s.id
+			WHERE s.depth < ?
+		)
+		SELECT * FROM subtree ORDER BY depth, name`, id, maxTreeDepth).
+		Scan(&premises).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premise subtree: %w", err)
+	}
+	return premises, nil
+}
+
+// IsInSubtree reports whether candidateID is rootID itself or one of its descendants
+func (r *PremiseRepository) IsInSubtree(ctx context.Context, rootID string, candidateID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE subtree AS (
+			SELECT p.id, 0 AS depth FROM premises p WHERE p.id = ?
+			UNION ALL
+			SELECT p.id, s.depth + 1 FROM premises p
+			JOIN subtree s ON p.parent_premise_id = s.id
+			WHERE s.depth < ?
+		)
+		SELECT COUNT(*) FROM subtree WHERE id = ?`, rootID, maxTreeDepth, candidateID).
+		Scan(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check premise subtree: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetPremisesWithGeofenceAt returns the premises whose geofence bounding box contains the point.
+// The caller still has to test the point against the geofence itself.
+func (r *PremiseRepository) GetPremisesWithGeofenceAt(ctx context.Context, point geo.Point) ([]models.Premise, error) {
+	var premises []models.Premise
+	if err := r.db.WithContext(ctx).
+		Where("geofence IS NOT NULL").
+		Where("geofence_min_lat <= ? AND geofence_max_lat >= ?", point.Lat, point.Lat).
+		Where("geofence_min_lng <= ? AND geofence_max_lng >= ?", point.Lng, point.Lng).
+		Find(&premises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get premises by geofence: %w", err)
+	}
+	return premises, nil
+}
+
+// GetPremisesInBoundingBox returns the premises whose coordinates lie inside the box
+func (r *PremiseRepository) GetPremisesInBoundingBox(ctx context.Context, box geo.BoundingBox) ([]models.Premise, error) {
+	var premises []models.Premise
+	if err := r.db.WithContext(ctx).
+		Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat).
+		Where("longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng).
+		Find(&premises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get premises by location: %w", err)
+	}
+	return premises, nil
+}
+
+// GetPremiseVersion returns the replicated version of the premise, or nil if no event of the
+// premise was applied yet
+func (r *PremiseRepository) GetPremiseVersion(ctx context.Context, id uuid.UUID) (*models.PremiseVersion, error) {
+	var version models.PremiseVersion
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&version, "premise_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get premise version: %w", err)
+	}
+	return &version, nil
+}
+
+// SavePremiseVersion stores the replicated version of the premise
+func (r *PremiseRepository) SavePremiseVersion(ctx context.Context, version *models.PremiseVersion) error {
+	if err := r.db.WithContext(ctx).Save(version).Error; err != nil {
+		return fmt.Errorf("failed to save premise version: %w", err)
+	}
+	return nil
+}
+
+// applyPremiseFilter adds the where clauses of the filter to the query
+func applyPremiseFilter(query *gorm.DB, filter dto.PremiseFilter) *gorm.DB {
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("premises.name ILIKE ? OR premises.address ILIKE ?", pattern, pattern)
+	}
+	if filter.ParentPremiseID != "" {
+		query = query.Where("premises.parent_premise_id = ?", filter.ParentPremiseID)
+	}
+	if filter.RootsOnly {
+		query = query.Where("premises.parent_premise_id IS NULL")
+	}
+	return query
+}
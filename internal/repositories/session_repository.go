@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// IsSessionActive reports whether the session exists and is neither revoked nor expired
+func (r *SessionRepository) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RevokeUserSessions revokes all active sessions of the user except the given one.
+// Pass an empty exceptSessionID to revoke every session.
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID string, exceptSessionID string) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptSessionID != "" {
+		query = query.Where("id <> ?", exceptSessionID)
+	}
+	result := query.Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
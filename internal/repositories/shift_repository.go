@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ShiftRepository struct {
+	db *gorm.DB
+}
+
+func NewShiftRepository(db *gorm.DB) *ShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+func (r *ShiftRepository) CreateShift(ctx context.Context, shift *models.Shift) error {
+	if err := r.db.WithContext(ctx).Omit("User", "Premise").Create(shift).Error; err != nil {
+		return fmt.Errorf("failed to create shift: %w", err)
+	}
+	return nil
+}
+
+// GetShiftByID returns the shift, or nil if it does not exist
+func (r *ShiftRepository) GetShiftByID(ctx context.Context, id string) (*models.Shift, error) {
+	var shift models.Shift
+	if err := r.db.WithContext(ctx).First(&shift, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get shift: %w", err)
+	}
+	return &shift, nil
+}
+
+func (r *ShiftRepository) DeleteShift(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.Shift{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete shift: %w", err)
+	}
+	return nil
+}
+
+// GetShiftsInWindow returns the shifts of the user and/or premise whose schedule may have
+// occurrences in [from, to). Empty IDs are not filtered on.
+func (r *ShiftRepository) GetShiftsInWindow(ctx context.Context, userID string, premiseID string, from time.Time, to time.Time) ([]models.Shift, error) {
+	var shifts []models.Shift
+	query := r.db.WithContext(ctx).
+		Where("starts_at < ?", to).
+		Where("(schedule_ends_at IS NULL OR schedule_ends_at > ?)", from)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if premiseID != "" {
+		query = query.Where("premise_id = ?", premiseID)
+	}
+	if err := query.Order("starts_at").Find(&shifts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get shifts: %w", err)
+	}
+	return shifts, nil
+}
+
+func (r *ShiftRepository) CreateAttendance(ctx context.Context, attendance *models.ShiftAttendance) error {
+	if err := r.db.WithContext(ctx).Omit("Shift", "User").Create(attendance).Error; err != nil {
+		return fmt.Errorf("failed to create shift attendance: %w", err)
+	}
+	return nil
+}
+
+// GetOpenAttendance returns the attendance the user is clocked in with, or nil if the user is not clocked in
+func (r *ShiftRepository) GetOpenAttendance(ctx context.Context, userID string) (*models.ShiftAttendance, error) {
+	var attendance models.ShiftAttendance
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND clock_out_at IS NULL", userID).
+		First(&attendance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get shift attendance: %w", err)
+	}
+	return &attendance, nil
+}
+
+// GetOnDutyAtPremise returns the open attendances of active users at the premise with their users.
+// Attendances whose occurrence ended before endedAfter count as forgotten clock-outs and are left out.
+func (r *ShiftRepository) GetOnDutyAtPremise(ctx context.Context, premiseID string, endedAfter time.Time) ([]models.ShiftAttendance, error) {
+	var attendances []models.ShiftAttendance
+	if err := r.db.WithContext(ctx).
+		Joins("User").
+		Where("shift_attendances.premise_id = ? AND shift_attendances.clock_out_at IS NULL", premiseID).
+		Where("shift_attendances.scheduled_end > ?", endedAfter).
+		Where(`"User".status = ?`, models.UserStatusActive).
+		Order("shift_attendances.clock_in_at").
+		Find(&attendances).Error; err != nil {
+		return nil, fmt.Errorf("failed to get on-duty users: %w", err)
+	}
+	return attendances, nil
+}
+
+// CloseAttendance clocks the attendance out at the given time, and reports whether it was still open
+func (r *ShiftRepository) CloseAttendance(ctx context.Context, id string, clockOutAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.ShiftAttendance{}).
+		Where("id = ? AND clock_out_at IS NULL", id).
+		Update("clock_out_at", clockOutAt)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to close shift attendance: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountOpenAttendances returns the number of users clocked in to the shift
+func (r *ShiftRepository) CountOpenAttendances(ctx context.Context, shiftID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.ShiftAttendance{}).
+		Where("shift_id = ? AND clock_out_at IS NULL", shiftID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count shift attendances: %w", err)
+	}
+	return count, nil
+}
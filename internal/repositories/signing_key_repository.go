@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+func (r *SigningKeyRepository) CreateKey(ctx context.Context, key *models.SigningKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+	return nil
+}
+
+// GetKeys returns the keys that are not retired or were retired after the given time, newest first
+func (r *SigningKeyRepository) GetKeys(ctx context.Context, retiredAfter time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	if err := r.db.WithContext(ctx).
+		Where("retired_at IS NULL OR retired_at > ?", retiredAfter).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RetireKeys retires all keys except the given one
+func (r *SigningKeyRepository) RetireKeys(ctx context.Context, exceptID string, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.SigningKey{}).
+		Where("id <> ? AND retired_at IS NULL", exceptID).
+		Update("retired_at", at)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to retire signing keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteRetired deletes the keys retired before the given time
+func (r *SigningKeyRepository) DeleteRetired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("retired_at < ?", before).Delete(&models.SigningKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete retired signing keys: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
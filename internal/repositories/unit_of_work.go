@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories groups the repositories that share one database handle
+type Repositories struct {
+	Users          *UserRepository
+	UserPremises   *UserPremiseRepository
+	Premises       *PremiseRepository
+	Invitations    *InvitationRepository
+	UserTokens     *UserTokenRepository
+	Assignments    *AssignmentRepository
+	Shifts         *ShiftRepository
+	Certifications *CertificationRepository
+	Groups         *GroupRepository
+	Outbox         *OutboxRepository
+	ConsumedEvents *ConsumedEventRepository
+	Webhooks       *WebhookRepository
+	Emails         *EmailRepository
+	UserProfiles   *UserProfileRepository
+	SigningKeys    *SigningKeyRepository
+}
+
+func newRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Users:          NewUserRepository(db),
+		UserPremises:   NewUserPremiseRepository(db),
+		Premises:       NewPremiseRepository(db),
+		Invitations:    NewInvitationRepository(db),
+		UserTokens:     NewUserTokenRepository(db),
+		Assignments:    NewAssignmentRepository(db),
+		Shifts:         NewShiftRepository(db),
+		Certifications: NewCertificationRepository(db),
+		Groups:         NewGroupRepository(db),
+		Outbox:         NewOutboxRepository(db),
+		ConsumedEvents: NewConsumedEventRepository(db),
+		Webhooks:       NewWebhookRepository(db),
+		Emails:         NewEmailRepository(db),
+		UserProfiles:   NewUserProfileRepository(db),
+		SigningKeys:    NewSigningKeyRepository(db),
+	}
+}
+
+// UnitOfWork runs several repository operations in a single database transaction
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do calls fn with repositories bound to a new transaction. The transaction is
+// committed if fn returns nil and rolled back otherwise.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos *Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(newRepositories(tx))
+	})
+}
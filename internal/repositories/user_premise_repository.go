@@ -2,8 +2,12 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"scs-user/internal/models"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -15,17 +19,162 @@ func NewUserPremiseRepository(db *gorm.DB) *UserPremiseRepository {
 	return &UserPremiseRepository{db: db}
 }
 
-func (r *UserPremiseRepository) AssignPremises(ctx context.Context, userPremise *models.UserPremise) (error) {
-	if err := r.db.WithContext(ctx).Create(userPremise).Error; err != nil {
+func (r *UserPremiseRepository) AssignPremises(ctx context.Context, userPremise *models.UserPremise) error {
+	if err := r.db.WithContext(ctx).Omit("User", "Premise").Create(userPremise).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
 func (r *UserPremiseRepository) CheckExist(ctx context.Context, userPremise *models.UserPremise) (bool, error) {
-	existingUserPremise := &models.UserPremise{}
-	if err := r.db.WithContext(ctx).Where("guard_id = ? AND premise_id = ?", userPremise.UserID, userPremise.PremiseID).First(existingUserPremise).Error; err == nil {
-		return true, nil
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.UserPremise{}).
+		Where("user_id = ? AND premise_id = ?", userPremise.UserID, userPremise.PremiseID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check user premise: %w", err)
 	}
-	return false, nil
+	return count > 0, nil
+}
+
+// GetUserPremise returns the assignment of the user to the premise, or nil if there is none
+func (r *UserPremiseRepository) GetUserPremise(ctx context.Context, userID string, premiseID string) (*models.UserPremise, error) {
+	var userPremise models.UserPremise
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND premise_id = ?", userID, premiseID).
+		First(&userPremise).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user premise: %w", err)
+	}
+	return &userPremise, nil
+}
+
+// GetByUser returns the premise assignments of the user. If activeAt is set, only
+// assignments valid at that time are returned.
+func (r *UserPremiseRepository) GetByUser(ctx context.Context, userID string, activeAt *time.Time) ([]models.UserPremise, error) {
+	var userPremises []models.UserPremise
+	query := applyActiveAt(r.db.WithContext(ctx).Preload("Premise").Where("user_id = ?", userID), activeAt)
+	if err := query.Order("created_at").Find(&userPremises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user premises: %w", err)
+	}
+	return userPremises, nil
+}
+
+// GetByUsers returns the premise assignments of the users
+func (r *UserPremiseRepository) GetByUsers(ctx context.Context, userIDs []uuid.UUID) ([]models.UserPremise, error) {
+	var userPremises []models.UserPremise
+	if len(userIDs) == 0 {
+		return userPremises, nil
+	}
+	if err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Order("created_at").Find(&userPremises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user premises: %w", err)
+	}
+	return userPremises, nil
+}
+
+// GetByPremise returns the user assignments of the premise. If activeAt is set, only
+// assignments valid at that time are returned.
+func (r *UserPremiseRepository) GetByPremise(ctx context.Context, premiseID string, activeAt *time.Time) ([]models.UserPremise, error) {
+	var userPremises []models.UserPremise
+	query := applyActiveAt(r.db.WithContext(ctx).Preload("User").Where("premise_id = ?", premiseID), activeAt)
+	if err := query.Order("created_at").Find(&userPremises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get premise users: %w", err)
+	}
+	return userPremises, nil
+}
+
+// GetByPremises returns the assignments of active users with the given role to any of the premises.
+// If activeAt is set, only assignments valid at that time are returned.
+func (r *UserPremiseRepository) GetByPremises(ctx context.Context, premiseIDs []uuid.UUID, userRole string, activeAt *time.Time) ([]models.UserPremise, error) {
+	var userPremises []models.UserPremise
+	if len(premiseIDs) == 0 {
+		return userPremises, nil
+	}
+	query := r.db.WithContext(ctx).
+		Joins("User").
+		Where("user_premises.premise_id IN ?", premiseIDs).
+		Where(`"User".role = ? AND "User".is_active`, userRole)
+	if err := applyActiveAt(query, activeAt).Find(&userPremises).Error; err != nil {
+		return nil, fmt.Errorf("failed to get premise users: %w", err)
+	}
+	return userPremises, nil
+}
+
+// UpdateUserPremise updates the role and validity period of the assignment of the user to the
+// premise, and reports whether the assignment still exists
+func (r *UserPremiseRepository) UpdateUserPremise(ctx context.Context, userPremise *models.UserPremise) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.UserPremise{}).
+		Where("user_id = ? AND premise_id = ?", userPremise.UserID, userPremise.PremiseID).
+		Updates(map[string]interface{}{
+			"role":      userPremise.Role,
+			"starts_at": userPremise.StartsAt,
+			"ends_at":   userPremise.EndsAt,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update user premise: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RemoveUserPremise removes the assignment of the user to the premise
+func (r *UserPremiseRepository) RemoveUserPremise(ctx context.Context, userID string, premiseID string) error {
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND premise_id = ?", userID, premiseID).
+		Delete(&models.UserPremise{}).Error; err != nil {
+		return fmt.Errorf("failed to remove user premise: %w", err)
+	}
+	return nil
+}
+
+// RemoveUserPremises removes all premise assignments of the user
+func (r *UserPremiseRepository) RemoveUserPremises(ctx context.Context, userID string) error {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserPremise{}).Error; err != nil {
+		return fmt.Errorf("failed to remove user premises: %w", err)
+	}
+	return nil
+}
+
+// CountByPremise returns the number of users assigned to the premise
+func (r *UserPremiseRepository) CountByPremise(ctx context.Context, premiseID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.UserPremise{}).Where("premise_id = ?", premiseID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count premise users: %w", err)
+	}
+	return count, nil
+}
+
+// GetPremiseNamesByUserIDs returns the names of the premises assigned to each of the given users
+func (r *UserPremiseRepository) GetPremiseNamesByUserIDs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	var rows []struct {
+		UserID uuid.UUID
+		Name   string
+	}
+	result := make(map[uuid.UUID][]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Table("user_premises").
+		Select("user_premises.user_id, premises.name").
+		Joins("JOIN premises ON premises.id = user_premises.premise_id").
+		Where("user_premises.user_id IN ?", userIDs).
+		Order("premises.name").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get premise names: %w", err)
+	}
+	for _, row := range rows {
+		result[row.UserID] = append(result[row.UserID], row.Name)
+	}
+	return result, nil
+}
+
+// applyActiveAt restricts the query to assignments valid at the given time
+func applyActiveAt(query *gorm.DB, activeAt *time.Time) *gorm.DB {
+	if activeAt == nil {
+		return query
+	}
+	return query.
+		Where("(user_premises.starts_at IS NULL OR user_premises.starts_at <= ?)", *activeAt).
+		Where("(user_premises.ends_at IS NULL OR user_premises.ends_at > ?)", *activeAt)
 }
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type UserProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewUserProfileRepository(db *gorm.DB) *UserProfileRepository {
+	return &UserProfileRepository{db: db}
+}
+
+// GetByUserID returns the profile of the user, or nil if the user has no profile yet
+func (r *UserProfileRepository) GetByUserID(ctx context.Context, userID string) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	if err := r.db.WithContext(ctx).First(&profile, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+	return &profile, nil
+}
+
+func (r *UserProfileRepository) SaveProfile(ctx context.Context, profile *models.UserProfile) error {
+	if err := r.db.WithContext(ctx).Save(profile).Error; err != nil {
+		return fmt.Errorf("failed to save user profile: %w", err)
+	}
+	return nil
+}
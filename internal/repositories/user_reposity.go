@@ -2,12 +2,19 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"scs-user/internal/dto"
 	"scs-user/internal/models"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// bootstrapAdminLockID is the advisory lock held while checking for and creating the first admin
+const bootstrapAdminLockID = 7234503
+
 type UserRepository struct {
 	db *gorm.DB
 }
@@ -22,22 +29,38 @@ func (r *UserRepository) CreateUser(ctx context.Context, User *models.User) (*mo
 	}
 	return User, nil
 }
-func (r *UserRepository) GetUsers(ctx context.Context, page int, limit int) ([]models.User, error) {
+func (r *UserRepository) GetUsers(ctx context.Context, filter dto.UserFilter, page int, limit int) ([]models.User, error) {
 	var Users []models.User
-	if err := r.db.WithContext(ctx).Limit(limit).Offset((page - 1) * limit).Find(&Users).Error; err != nil {
+	query := applyUserFilter(r.db.WithContext(ctx), filter)
+	if err := query.Order("created_at").Limit(limit).Offset((page - 1) * limit).Find(&Users).Error; err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 	return Users, nil
 }
 
-func (r *UserRepository) GetUsersCount(ctx context.Context) (int64, error) {
+func (r *UserRepository) GetUsersCount(ctx context.Context, filter dto.UserFilter) (int64, error) {
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&models.User{}).Count(&count).Error; err != nil {
+	query := applyUserFilter(r.db.WithContext(ctx).Model(&models.User{}), filter)
+	if err := query.Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to get users count: %w", err)
 	}
 	return count, nil
 }
 
+// StreamUsers iterates over all users matching the filter in batches,
+// so that the full result set is never held in memory.
+func (r *UserRepository) StreamUsers(ctx context.Context, filter dto.UserFilter, batchSize int, fn func(users []models.User) error) error {
+	var batch []models.User
+	query := applyUserFilter(r.db.WithContext(ctx), filter)
+	result := query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to stream users: %w", result.Error)
+	}
+	return nil
+}
+
 func (r *UserRepository) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	var User models.User
 	if err := r.db.WithContext(ctx).First(&User, "id = ?", id).Error; err != nil {
@@ -45,6 +68,41 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id string) (*models.Us
 	}
 	return &User, nil
 }
+
+// LockUser returns the user and locks its row until the end of the transaction, or nil if it
+// does not exist
+func (r *UserRepository) LockUser(ctx context.Context, id string) (*models.User, error) {
+	var User models.User
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&User, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+	return &User, nil
+}
+
+// LockAdminBootstrap waits for the bootstrap lock and holds it until the end of the transaction,
+// so that replicas bootstrapping at the same time do not both find no admin
+func (r *UserRepository) LockAdminBootstrap(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?)", bootstrapAdminLockID).Error; err != nil {
+		return fmt.Errorf("failed to lock admin bootstrap: %w", err)
+	}
+	return nil
+}
+
+// LockUsers returns the users with the given IDs and locks their rows until the end of the transaction
+func (r *UserRepository) LockUsers(ctx context.Context, ids []uuid.UUID) ([]models.User, error) {
+	var Users []models.User
+	if len(ids) == 0 {
+		return Users, nil
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&Users).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+	return Users, nil
+}
+
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var User models.User
 	if err := r.db.WithContext(ctx).First(&User, "email = ?", email).Error; err != nil {
@@ -58,3 +116,64 @@ func (r *UserRepository) UpdateUser(ctx context.Context, User *models.User) erro
 	}
 	return nil
 }
+
+// UpdateUserColumns updates the columns of the user only while it has the status, and reports
+// whether it had. Unlike UpdateUser, it does not write back columns changed by others.
+func (r *UserRepository) UpdateUserColumns(ctx context.Context, id string, status string, columns map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ? AND status = ?", id, status).Updates(columns)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update user: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ResetFailedLogins clears the failed logins and the lockout of the user after a successful login
+func (r *UserRepository) ResetFailedLogins(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_logins": 0,
+		"locked_until":  nil,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) DeleteUser(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// CountExistingUsers returns how many of the given user IDs exist
+func (r *UserRepository) CountExistingUsers(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	var count int64
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// applyUserFilter adds the where clauses of the filter to the query
+func applyUserFilter(query *gorm.DB, filter dto.UserFilter) *gorm.DB {
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("users.name ILIKE ? OR users.email ILIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("users.role = ?", filter.Role)
+	}
+	if filter.Status != "" {
+		query = query.Where("users.status = ?", filter.Status)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("users.is_active = ?", *filter.IsActive)
+	}
+	if filter.PremiseID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM user_premises up WHERE up.user_id = users.id AND up.premise_id = ?)", filter.PremiseID)
+	}
+	return query
+}
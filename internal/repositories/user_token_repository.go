@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+func (r *UserTokenRepository) CreateToken(ctx context.Context, token *models.UserToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+	return nil
+}
+
+// GetValidToken returns the unconsumed, unexpired token with the given hash and purpose, or nil
+func (r *UserTokenRepository) GetValidToken(ctx context.Context, purpose string, tokenHash string) (*models.UserToken, error) {
+	var token models.UserToken
+	if err := r.db.WithContext(ctx).
+		Where("purpose = ? AND token_hash = ? AND consumed_at IS NULL AND expires_at > ?", purpose, tokenHash, time.Now()).
+		First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return &token, nil
+}
+
+// ConsumeToken marks the token as used. It returns false if the token was already consumed.
+func (r *UserTokenRepository) ConsumeToken(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.UserToken{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to consume token: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateUserTokens consumes all outstanding tokens of the user for the purpose
+func (r *UserTokenRepository) InvalidateUserTokens(ctx context.Context, userID string, purpose string) error {
+	if err := r.db.WithContext(ctx).Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+		Update("consumed_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to invalidate tokens: %w", err)
+	}
+	return nil
+}
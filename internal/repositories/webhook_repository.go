@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"scs-user/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	if err := r.db.WithContext(ctx).Create(subscription).Error; err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscriptionByID returns the subscription, or nil if it does not exist
+func (r *WebhookRepository) GetSubscriptionByID(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.db.WithContext(ctx).First(&subscription, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return &subscription, nil
+}
+
+func (r *WebhookRepository) GetSubscriptions(ctx context.Context, page int, limit int) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.WithContext(ctx).Order("created_at").Limit(limit).Offset((page - 1) * limit).Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookRepository) GetSubscriptionsCount(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.WebhookSubscription{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to get webhook subscriptions count: %w", err)
+	}
+	return count, nil
+}
+
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	if err := r.db.WithContext(ctx).Save(subscription).Error; err != nil {
+		return fmt.Errorf("failed to save webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription deletes the subscription together with its delivery log
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	if err := r.db.WithContext(ctx).Delete(&models.WebhookSubscription{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscriptionsForEvent returns the enabled subscriptions of the event type, including the ones
+// subscribed to every event type
+func (r *WebhookRepository) GetSubscriptionsForEvent(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	eventTypeJSON, _ := json.Marshal([]string{eventType})
+	allJSON, _ := json.Marshal([]string{models.WebhookEventTypeAll})
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.WithContext(ctx).
+		Where("enabled AND (event_types @> ?::jsonb OR event_types @> ?::jsonb)", string(eventTypeJSON), string(allJSON)).
+		Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions of event type: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// CreateDeliveries queues the deliveries for the delivery worker
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Omit("Subscription").Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := r.db.WithContext(ctx).Omit("Subscription").Create(delivery).Error; err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue locks the oldest pending deliveries that are due, skipping the ones locked by other
+// replicas, and loads their subscriptions. Deliveries of disabled subscriptions stay pending until
+// the subscription is enabled again. Use the repository of a unit of work so that the lock is
+// held until the results are recorded.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED", Table: clause.Table{Name: "webhook_deliveries"}}).
+		Joins("Subscription").
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", models.WebhookDeliveryStatusPending, now).
+		Where(`"Subscription".enabled`).
+		Order("webhook_deliveries.next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to claim due webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// SaveAttempt stores the outcome of a delivery attempt
+func (r *WebhookRepository) SaveAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if err := r.db.WithContext(ctx).Model(delivery).Select(
+		"Status", "Attempts", "NextAttemptAt", "LastStatusCode", "LastResponse", "LastError", "LastDurationMs", "DeliveredAt",
+	).Updates(delivery).Error; err != nil {
+		return fmt.Errorf("failed to save webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// ResetFailures clears the consecutive failures of the subscription after a successful delivery
+func (r *WebhookRepository) ResetFailures(ctx context.Context, subscriptionID string) error {
+	if err := r.db.WithContext(ctx).Model(&models.WebhookSubscription{}).
+		Where("id = ? AND consecutive_failures > 0", subscriptionID).
+		Update("consecutive_failures", 0).Error; err != nil {
+		return fmt.Errorf("failed to reset webhook subscription failures: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt of the subscription and returns its consecutive failures
+func (r *WebhookRepository) RecordFailure(ctx context.Context, subscriptionID string) (int, error) {
+	var subscription models.WebhookSubscription
+	if err := r.db.WithContext(ctx).Model(&subscription).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "consecutive_failures"}}}).
+		Where("id = ?", subscriptionID).
+		Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+		return 0, fmt.Errorf("failed to record webhook subscription failure: %w", err)
+	}
+	return subscription.ConsecutiveFailures, nil
+}
+
+// DisableSubscription disables an enabled subscription and reports whether it was enabled
+func (r *WebhookRepository) DisableSubscription(ctx context.Context, subscriptionID string, at time.Time, reason string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.WebhookSubscription{}).
+		Where("id = ? AND enabled", subscriptionID).
+		Updates(map[string]interface{}{
+			"enabled":         false,
+			"disabled_at":     at,
+			"disabled_reason": reason,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to disable webhook subscription: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetDeliveries returns the delivery log of the subscription, newest first
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, subscriptionID string, status string, page int, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := applyDeliveryFilter(r.db.WithContext(ctx), subscriptionID, status)
+	if err := query.Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) GetDeliveriesCount(ctx context.Context, subscriptionID string, status string) (int64, error) {
+	var count int64
+	query := applyDeliveryFilter(r.db.WithContext(ctx).Model(&models.WebhookDelivery{}), subscriptionID, status)
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to get webhook deliveries count: %w", err)
+	}
+	return count, nil
+}
+
+// GetDeliveryByID returns the delivery of the subscription, or nil if it does not exist
+func (r *WebhookRepository) GetDeliveryByID(ctx context.Context, subscriptionID string, id string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.WithContext(ctx).First(&delivery, "id = ? AND subscription_id = ?", id, subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// DeleteFinished deletes the succeeded and failed deliveries created before the given time and
+// returns how many were deleted
+func (r *WebhookRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status <> ? AND created_at < ?", models.WebhookDeliveryStatusPending, before).
+		Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete finished webhook deliveries: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func applyDeliveryFilter(query *gorm.DB, subscriptionID string, status string) *gorm.DB {
+	query = query.Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return query
+}
@@ -1,38 +1,125 @@
 package server
 
 import (
+	"context"
 	"net/http"
 	controller "scs-user/internal/controllers"
 	my_middleware "scs-user/internal/middlewares"
 	repository "scs-user/internal/repositories"
 	service "scs-user/internal/services"
+	kafka_client "scs-user/pkg/kafka"
+	"scs-user/pkg/mail"
+	"scs-user/pkg/storage"
 
 	"github.com/labstack/echo/v4/middleware"
 
 	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func (s *Server) MapHandlers(e *echo.Echo) error {
 	// Init repositories
 	userRepo := repository.NewUserRepository(s.db)
 	userPremiseRepo := repository.NewUserPremiseRepository(s.db)
+	userProfileRepo := repository.NewUserProfileRepository(s.db)
+	sessionRepo := repository.NewSessionRepository(s.db)
+	userTokenRepo := repository.NewUserTokenRepository(s.db)
+	invitationRepo := repository.NewInvitationRepository(s.db)
+	premiseRepo := repository.NewPremiseRepository(s.db)
+	assignmentRepo := repository.NewAssignmentRepository(s.db)
+	shiftRepo := repository.NewShiftRepository(s.db)
+	certificationRepo := repository.NewCertificationRepository(s.db)
+	groupRepo := repository.NewGroupRepository(s.db)
+	outboxRepo := repository.NewOutboxRepository(s.db)
+	webhookRepo := repository.NewWebhookRepository(s.db)
+	emailRepo := repository.NewEmailRepository(s.db)
+	signingKeyRepo := repository.NewSigningKeyRepository(s.db)
+	uow := repository.NewUnitOfWork(s.db)
+
+	// Init storage
+	blobStore, err := storage.NewBlobStore(s.cfg)
+	if err != nil {
+		return err
+	}
+
+	// Init email delivery
+	emailTemplates, err := mail.NewTemplates(s.cfg.Mail.DefaultLocale)
+	if err != nil {
+		return err
+	}
+	mailSender, err := mail.NewSender(s.cfg, s.logger)
+	if err != nil {
+		return err
+	}
+	notifier := service.NewNotifier(s.cfg, emailTemplates)
 
 	// Init service
-	userService := service.NewUserService(*userRepo, *userPremiseRepo, *s.producer)
-	authService := service.NewAuthService(*userRepo)
+	userService := service.NewUserService(*userRepo, *userPremiseRepo, *userTokenRepo, *uow, notifier)
+	authService := service.NewAuthService(s.cfg, *userRepo, *sessionRepo, *userTokenRepo, *uow, notifier)
+	accountService := service.NewAccountService(*userRepo, *sessionRepo, *userTokenRepo, *uow, notifier)
+	invitationService := service.NewInvitationService(*userRepo, *invitationRepo, *uow, notifier)
+	premiseService := service.NewPremiseService(*premiseRepo, *userPremiseRepo, *uow)
+	userPremiseService := service.NewUserPremiseService(*userRepo, *premiseRepo, *userPremiseRepo, *uow)
+	profileService := service.NewProfileService(s.cfg, *userRepo, *userProfileRepo, blobStore)
+	assignmentService := service.NewAssignmentService(s.cfg, *userRepo, *premiseRepo, *userPremiseRepo, *groupRepo, *assignmentRepo, *uow, blobStore)
+	shiftService := service.NewShiftService(s.cfg, *userRepo, *premiseRepo, *userPremiseRepo, *groupRepo, *shiftRepo, *certificationRepo, *uow)
+	certificationService := service.NewCertificationService(s.cfg, *userRepo, *certificationRepo, blobStore, *uow)
+	groupService := service.NewGroupService(*groupRepo, *userRepo, *premiseRepo, *userPremiseRepo, *uow)
+	premiseReplicaService := service.NewPremiseReplicaService(*uow, s.logger)
+	webhookService := service.NewWebhookService(s.cfg, *webhookRepo)
+	signingKeyService := service.NewSigningKeyService(s.cfg, *signingKeyRepo, *uow)
 	// Init handlers
 	userHandler := controller.NewUserHandler(*userService)
 	authHandler := controller.NewAuthHandler(*authService)
+	profileHandler := controller.NewProfileHandler(*profileService)
+	accountHandler := controller.NewAccountHandler(*accountService)
+	invitationHandler := controller.NewInvitationHandler(*invitationService)
+	premiseHandler := controller.NewPremiseHandler(*premiseService)
+	userPremiseHandler := controller.NewUserPremiseHandler(*userPremiseService)
+	assignmentHandler := controller.NewAssignmentHandler(*assignmentService)
+	shiftHandler := controller.NewShiftHandler(*shiftService)
+	certificationHandler := controller.NewCertificationHandler(*certificationService)
+	groupHandler := controller.NewGroupHandler(*groupService)
+	webhookHandler := controller.NewWebhookHandler(*webhookService)
+
+	eventEncoder, err := service.NewEventEncoder(&s.cfg.Kafka)
+	if err != nil {
+		return err
+	}
+	outboxRelay := service.NewOutboxRelay(s.cfg, *outboxRepo, *uow, s.producer, eventEncoder, s.logger)
+	webhookDispatcher := service.NewWebhookDispatcher(s.cfg, *webhookRepo, *uow, s.logger)
+	emailDispatcher := service.NewEmailDispatcher(s.cfg, *emailRepo, *uow, mailSender, s.logger)
+
+	// Load the token signing keys before serving, the job picks up rotations
+	if err := signingKeyService.Load(context.Background()); err != nil {
+		return err
+	}
+
+	// Start background jobs
+	s.startJob("outbox-relay", s.cfg.Outbox.PollInterval, outboxRelay.Relay)
+	s.startJob("outbox-cleanup", s.cfg.Outbox.CleanupInterval, outboxRelay.Cleanup)
+	s.startJob("webhook-delivery", s.cfg.Webhook.PollInterval, webhookDispatcher.Deliver)
+	s.startJob("webhook-cleanup", s.cfg.Webhook.CleanupInterval, webhookDispatcher.Cleanup)
+	s.startJob("email-delivery", s.cfg.Mail.PollInterval, emailDispatcher.Send)
+	s.startJob("email-cleanup", s.cfg.Mail.CleanupInterval, emailDispatcher.Cleanup)
+	s.startJob("signing-key-refresh", s.cfg.Auth.SigningKeyRefreshInterval, signingKeyService.Load)
+	s.startJob("certification-expiry", s.cfg.Certification.ExpiryCheckInterval, certificationService.NotifyExpiring)
+
+	// Start consuming events
+	registry := kafka_client.NewRegistry()
+	premiseReplicaService.RegisterHandlers(registry)
+	s.startConsumer(registry)
 
 	// Enable CORS for all origins
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
 		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 		AllowCredentials: false,
 	}))
 
-	mw := my_middleware.NewMiddlewareManager(s.cfg, []string{"*"}, s.logger)
+	mw := my_middleware.NewMiddlewareManager(s.cfg, []string{"*"}, s.logger, sessionRepo)
+	e.Use(mw.TracingMiddleware)
 	e.Use(mw.RequestLoggerMiddleware)
 	e.Use(mw.ErrorHandlerMiddleware)
 	e.Use(mw.ResponseStandardizer)
@@ -41,12 +128,35 @@ func (s *Server) MapHandlers(e *echo.Echo) error {
 	health := v1.Group("/health")
 	usersGroup := v1.Group("/users")
 	authGroup := v1.Group("/auth")
+	invitationsGroup := v1.Group("/invitations")
+	premisesGroup := v1.Group("/premises")
+	assignmentsGroup := v1.Group("/assignments")
+	shiftsGroup := v1.Group("/shifts")
+	certificationsGroup := v1.Group("/certifications")
+	groupsGroup := v1.Group("/groups")
+	webhooksGroup := v1.Group("/webhooks")
+	filesGroup := v1.Group("/files")
 
 	health.GET("", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
 	})
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
 	userHandler.RegisterRoutes(usersGroup, mw)
+	profileHandler.RegisterRoutes(usersGroup, mw)
+	accountHandler.RegisterRoutes(usersGroup, mw)
+	invitationHandler.RegisterRoutes(invitationsGroup, mw)
+	premiseHandler.RegisterRoutes(premisesGroup, mw)
+	userPremiseHandler.RegisterRoutes(usersGroup, premisesGroup, mw)
+	assignmentHandler.RegisterRoutes(usersGroup, assignmentsGroup, mw)
+	shiftHandler.RegisterRoutes(usersGroup, premisesGroup, shiftsGroup, mw)
+	certificationHandler.RegisterRoutes(usersGroup, certificationsGroup, mw)
+	groupHandler.RegisterRoutes(usersGroup, groupsGroup, mw)
+	webhookHandler.RegisterRoutes(webhooksGroup, mw)
 	authHandler.RegisterRoutes(authGroup)
+	// Files of the local store are served by the API, S3 signed URLs point to the bucket directly
+	if localStore, ok := blobStore.(*storage.LocalStore); ok {
+		controller.NewFileHandler(localStore).RegisterRoutes(filesGroup)
+	}
 
 	return nil
 
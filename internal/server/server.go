@@ -4,8 +4,10 @@ import (
 	"context"
 	"net/http"
 	config "scs-user/config"
+	"scs-user/internal/jobs"
 	kafka_client "scs-user/pkg/kafka"
 	logger "scs-user/pkg/logger"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -22,11 +24,19 @@ type Server struct {
 	cfg      *config.Config
 	db       *gorm.DB
 	logger   logger.Logger
-	producer *kafka_client.Producer
+	producer kafka_client.Publisher
+	// consumer is nil when no topics are consumed, dlq receives the messages it fails to handle
+	consumer *kafka_client.Consumer
+	dlq      kafka_client.Publisher
+	// Background jobs run until Shutdown cancels their context
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	jobs       sync.WaitGroup
 }
 
-func NewServer(cfg *config.Config, db *gorm.DB, logger logger.Logger, producer *kafka_client.Producer) *Server {
-	return &Server{cfg: cfg, db: db, logger: logger, Echo: echo.New(), producer: producer}
+func NewServer(cfg *config.Config, db *gorm.DB, logger logger.Logger, producer kafka_client.Publisher, consumer *kafka_client.Consumer, dlq kafka_client.Publisher) *Server {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	return &Server{cfg: cfg, db: db, logger: logger, Echo: echo.New(), producer: producer, consumer: consumer, dlq: dlq, jobsCtx: jobsCtx, cancelJobs: cancelJobs}
 }
 func (s *Server) Run() error {
 	// Map handlers
@@ -46,5 +56,51 @@ func (s *Server) Run() error {
 	return s.Echo.StartServer(server)
 }
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.Echo.Shutdown(ctx)
+	err := s.Echo.Shutdown(ctx)
+
+	// Wait for the running jobs and the consumer to finish their current run
+	s.cancelJobs()
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.logger.Warn("Timed out waiting for background jobs to stop")
+	}
+	return err
+}
+
+// startJob runs fn periodically in the background until the server shuts down
+func (s *Server) startJob(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		jobs.RunPeriodically(s.jobsCtx, name, interval, s.logger, fn)
+	}()
+}
+
+// startConsumer dispatches the consumed messages to the handlers of the registry until the server shuts down
+func (s *Server) startConsumer(registry *kafka_client.Registry) {
+	if s.consumer == nil {
+		return
+	}
+	runner := kafka_client.NewRunner(s.consumer, s.dlq, registry, kafka_client.RunnerConfig{
+		MaxRetries:      s.cfg.Kafka.ConsumerMaxRetries,
+		RetryBackoff:    s.cfg.Kafka.ConsumerRetryBackoff,
+		MaxRetryBackoff: s.cfg.Kafka.ConsumerMaxRetryBackoff,
+		DLQSuffix:       s.cfg.Kafka.DLQSuffix,
+	}, s.logger)
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		s.logger.Infof("Consuming topics %v with %d handlers", s.cfg.Kafka.ConsumerTopics, registry.Len())
+		if err := runner.Run(s.jobsCtx); err != nil {
+			s.logger.Errorf("Consumer stopped: %v", err)
+			return
+		}
+		s.logger.Info("Consumer stopped")
+	}()
 }
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+	"scs-user/pkg/utils"
+	"strings"
+	"time"
+)
+
+const emailChangeTokenTTL = 24 * time.Hour
+
+// AccountService handles self-service changes of a user's credentials
+type AccountService struct {
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+	tokenRepo   repositories.UserTokenRepository
+	uow         repositories.UnitOfWork
+	notifier    *Notifier
+}
+
+func NewAccountService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, tokenRepo repositories.UserTokenRepository, uow repositories.UnitOfWork, notifier *Notifier) *AccountService {
+	return &AccountService{userRepo: userRepo, sessionRepo: sessionRepo, tokenRepo: tokenRepo, uow: uow, notifier: notifier}
+}
+
+// ChangePassword changes the password of the user and revokes all sessions except the current one
+func (s *AccountService) ChangePassword(ctx context.Context, userID string, sessionID string, changePasswordDto *dto.ChangePasswordDto) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.NewNotFoundError("user")
+	}
+	if err := utils.VerifyPassword(user.Password, changePasswordDto.CurrentPassword); err != nil {
+		return errors.NewUnauthorizedError("Current password is incorrect")
+	}
+
+	hashedPassword, err := utils.HashPassword(changePasswordDto.NewPassword)
+	if err != nil {
+		return errors.NewInternalError("Failed to hash password", err)
+	}
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		locked, err := s.lockActiveUser(ctx, repos, user)
+		if err != nil {
+			return err
+		}
+		locked.Password = hashedPassword
+		if err := repos.Users.UpdateUser(ctx, locked); err != nil {
+			return errors.NewDatabaseError("update user", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.sessionRepo.RevokeUserSessions(ctx, userID, sessionID); err != nil {
+		return errors.NewDatabaseError("revoke sessions", err)
+	}
+	return nil
+}
+
+// RequestEmailChange stores the new address as pending and sends a verification token to it.
+// The email is only changed once the token is confirmed.
+func (s *AccountService) RequestEmailChange(ctx context.Context, userID string, changeEmailDto *dto.ChangeEmailDto) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.NewNotFoundError("user")
+	}
+	if err := utils.VerifyPassword(user.Password, changeEmailDto.CurrentPassword); err != nil {
+		return errors.NewUnauthorizedError("Current password is incorrect")
+	}
+
+	newEmail := strings.TrimSpace(changeEmailDto.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return errors.NewBadRequestError("New email must be different from the current email")
+	}
+	if _, err := s.userRepo.GetUserByEmail(ctx, newEmail); err == nil {
+		return errors.NewConflictError("User with this email already exists")
+	}
+
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return errors.NewInternalError("Failed to generate token", err)
+	}
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		user, err := s.lockActiveUser(ctx, repos, user)
+		if err != nil {
+			return err
+		}
+		// Only the most recent request can be confirmed
+		if err := repos.UserTokens.InvalidateUserTokens(ctx, userID, models.TokenPurposeEmailChange); err != nil {
+			return errors.NewDatabaseError("invalidate tokens", err)
+		}
+		userToken := &models.UserToken{
+			UserID:    user.ID,
+			Purpose:   models.TokenPurposeEmailChange,
+			TokenHash: utils.HashToken(token),
+			Data:      newEmail,
+			ExpiresAt: time.Now().Add(emailChangeTokenTTL),
+		}
+		if err := repos.UserTokens.CreateToken(ctx, userToken); err != nil {
+			return errors.NewDatabaseError("create token", err)
+		}
+
+		user.PendingEmail = &newEmail
+		if err := repos.Users.UpdateUser(ctx, user); err != nil {
+			return errors.NewDatabaseError("update user", err)
+		}
+		if err := s.notifier.SendEmailChange(ctx, repos, user, newEmail, token, userToken.ExpiresAt); err != nil {
+			return err
+		}
+		return publishEvent(ctx, repos.Outbox, user.ID.String(), events.UserEmailChangeRequested, events.UserEmailChangeRequestedData{
+			UserID:    user.ID.String(),
+			Email:     newEmail,
+			ExpiresAt: userToken.ExpiresAt,
+		})
+	})
+}
+
+// ConfirmEmailChange swaps the user's email to the pending address and notifies the old address
+func (s *AccountService) ConfirmEmailChange(ctx context.Context, token string) error {
+	userToken, err := s.tokenRepo.GetValidToken(ctx, models.TokenPurposeEmailChange, utils.HashToken(token))
+	if err != nil {
+		return errors.NewDatabaseError("get token", err)
+	}
+	if userToken == nil {
+		return errors.NewBadRequestError("Invalid or expired token")
+	}
+
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		consumed, err := repos.UserTokens.ConsumeToken(ctx, userToken.ID.String())
+		if err != nil {
+			return errors.NewDatabaseError("consume token", err)
+		}
+		if !consumed {
+			return errors.NewBadRequestError("Invalid or expired token")
+		}
+		user, err := repos.Users.LockUser(ctx, userToken.UserID.String())
+		if err != nil {
+			return errors.NewDatabaseError("lock user", err)
+		}
+		// Suspended users must not change their email through a link sent before the suspension
+		if user == nil || user.Status != models.UserStatusActive || user.PendingEmail == nil || *user.PendingEmail != userToken.Data {
+			return errors.NewBadRequestError("Invalid or expired token")
+		}
+
+		oldEmail := user.Email
+		user.Email = userToken.Data
+		user.PendingEmail = nil
+		if err := repos.Users.UpdateUser(ctx, user); err != nil {
+			if isDuplicateEmailError(err) {
+				return errors.NewConflictError("User with this email already exists")
+			}
+			return errors.NewDatabaseError("update user", err)
+		}
+		if err := s.notifier.SendEmailChanged(ctx, repos, user, oldEmail); err != nil {
+			return err
+		}
+
+		err = publishEvent(ctx, repos.Outbox, user.ID.String(), events.UserEmailChanged, events.UserEmailChangedData{
+			UserID:   user.ID.String(),
+			OldEmail: oldEmail,
+			NewEmail: user.Email,
+		})
+		if err != nil {
+			return err
+		}
+		return publishUserSnapshot(ctx, repos, user.ID)
+	})
+}
+
+// lockActiveUser locks the user the current password was verified against. It fails if the user
+// has been suspended or the password has been changed since.
+func (s *AccountService) lockActiveUser(ctx context.Context, repos *repositories.Repositories, verified *models.User) (*models.User, error) {
+	user, err := repos.Users.LockUser(ctx, verified.ID.String())
+	if err != nil {
+		return nil, errors.NewDatabaseError("lock user", err)
+	}
+	if user == nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	if user.Status != models.UserStatusActive {
+		return nil, errors.NewUnauthorizedError("User is not active")
+	}
+	if user.Password != verified.Password {
+		return nil, errors.NewUnauthorizedError("Current password is incorrect")
+	}
+	return user, nil
+}
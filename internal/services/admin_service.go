@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+	"scs-user/pkg/utils"
+	"strings"
+)
+
+// AdminService holds the operations operators run from the command line, outside of the API
+// and its authentication
+type AdminService struct {
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+	uow         repositories.UnitOfWork
+}
+
+func NewAdminService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, uow repositories.UnitOfWork) *AdminService {
+	return &AdminService{userRepo: userRepo, sessionRepo: sessionRepo, uow: uow}
+}
+
+// FindUser returns the user with the given ID or email address
+func (s *AdminService) FindUser(ctx context.Context, idOrEmail string) (*models.User, error) {
+	var user *models.User
+	var err error
+	if strings.Contains(idOrEmail, "@") {
+		user, err = s.userRepo.GetUserByEmail(ctx, idOrEmail)
+	} else {
+		user, err = s.userRepo.GetUserByID(ctx, idOrEmail)
+	}
+	if err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	return user, nil
+}
+
+// CreateAdmin creates an active, city-wide admin. The operator vouches for the address, so no
+// verification email is sent.
+func (s *AdminService) CreateAdmin(ctx context.Context, createAdminDto *dto.CreateAdminDto) (*models.User, error) {
+	var user *models.User
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		var err error
+		user, err = createAdmin(ctx, repos, createAdminDto)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// BootstrapAdmin creates the admin only if there is no admin yet, so that it can run on every
+// deployment. It returns nil if an admin already exists.
+func (s *AdminService) BootstrapAdmin(ctx context.Context, createAdminDto *dto.CreateAdminDto) (*models.User, error) {
+	var user *models.User
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Users.LockAdminBootstrap(ctx); err != nil {
+			return errors.NewDatabaseError("lock admin bootstrap", err)
+		}
+		admins, err := repos.Users.GetUsersCount(ctx, dto.UserFilter{Role: "admin"})
+		if err != nil {
+			return errors.NewDatabaseError("count admins", err)
+		}
+		if admins > 0 {
+			return nil
+		}
+		user, err = createAdmin(ctx, repos, createAdminDto)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetPassword sets a new password for the user, lifts a lockout and revokes all sessions
+func (s *AdminService) SetPassword(ctx context.Context, userID string, setPasswordDto *dto.SetPasswordDto) error {
+	hashedPassword, err := utils.HashPassword(setPasswordDto.Password)
+	if err != nil {
+		return errors.NewInternalError("Failed to hash password", err)
+	}
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		user, err := repos.Users.LockUser(ctx, userID)
+		if err != nil {
+			return errors.NewDatabaseError("lock user", err)
+		}
+		if user == nil {
+			return errors.NewNotFoundError("user")
+		}
+		user.Password = hashedPassword
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+		if err := repos.Users.UpdateUser(ctx, user); err != nil {
+			return errors.NewDatabaseError("update user", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.sessionRepo.RevokeUserSessions(ctx, userID, ""); err != nil {
+		return errors.NewDatabaseError("revoke sessions", err)
+	}
+	return nil
+}
+
+// SetStatus activates or suspends the user. Suspending revokes all sessions of the user.
+func (s *AdminService) SetStatus(ctx context.Context, userID string, status string) (*models.User, error) {
+	if status != models.UserStatusActive && status != models.UserStatusSuspended {
+		return nil, errors.NewBadRequestError("Status must be active or suspended")
+	}
+	var user *models.User
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		var err error
+		user, err = repos.Users.LockUser(ctx, userID)
+		if err != nil {
+			return errors.NewDatabaseError("lock user", err)
+		}
+		if user == nil {
+			return errors.NewNotFoundError("user")
+		}
+		// Invited users have no password yet, they activate by accepting the invitation
+		if status == models.UserStatusActive && user.Status == models.UserStatusInvited {
+			return errors.NewBadRequestError("Invited users are activated by accepting the invitation")
+		}
+		if user.Status == status {
+			return nil
+		}
+		user.SetStatus(status)
+		if err := repos.Users.UpdateUser(ctx, user); err != nil {
+			return errors.NewDatabaseError("update user", err)
+		}
+		return publishUserSnapshot(ctx, repos, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status == models.UserStatusSuspended {
+		if _, err := s.sessionRepo.RevokeUserSessions(ctx, userID, ""); err != nil {
+			return nil, errors.NewDatabaseError("revoke sessions", err)
+		}
+	}
+	return user, nil
+}
+
+// RevokeSessions revokes all sessions of the user and returns how many were active
+func (s *AdminService) RevokeSessions(ctx context.Context, userID string) (int64, error) {
+	revoked, err := s.sessionRepo.RevokeUserSessions(ctx, userID, "")
+	if err != nil {
+		return 0, errors.NewDatabaseError("revoke sessions", err)
+	}
+	return revoked, nil
+}
+
+func createAdmin(ctx context.Context, repos *repositories.Repositories, createAdminDto *dto.CreateAdminDto) (*models.User, error) {
+	hashedPassword, err := utils.HashPassword(createAdminDto.Password)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to hash password", err)
+	}
+	user := &models.User{
+		Name:     createAdminDto.Name,
+		Email:    strings.TrimSpace(createAdminDto.Email),
+		Password: hashedPassword,
+		Role:     "admin",
+	}
+	user.SetStatus(models.UserStatusActive)
+
+	createdUser, err := repos.Users.CreateUser(ctx, user)
+	if err != nil {
+		if isDuplicateEmailError(err) {
+			return nil, errors.NewConflictError("User with this email already exists")
+		}
+		return nil, errors.NewDatabaseError("create user", err)
+	}
+	err = publishEvent(ctx, repos.Outbox, createdUser.ID.String(), events.UserCreated, events.UserCreatedData{
+		UserID:     createdUser.ID.String(),
+		Email:      createdUser.Email,
+		Name:       createdUser.Name,
+		Role:       createdUser.Role,
+		Status:     createdUser.Status,
+		PremiseIDs: []string{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := publishUserSnapshot(ctx, repos, createdUser.ID); err != nil {
+		return nil, err
+	}
+	return createdUser, nil
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	config "scs-user/config"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/storage"
+	"scs-user/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const evidenceKeyPrefix = "evidence"
+
+// AssignmentService manages the checklists guards work through and the completion of their steps
+type AssignmentService struct {
+	cfg             *config.Config
+	userRepo        repositories.UserRepository
+	premiseRepo     repositories.PremiseRepository
+	userPremiseRepo repositories.UserPremiseRepository
+	groupRepo       repositories.GroupRepository
+	assignmentRepo  repositories.AssignmentRepository
+	uow             repositories.UnitOfWork
+	blobStore       storage.BlobStore
+}
+
+func NewAssignmentService(cfg *config.Config, userRepo repositories.UserRepository, premiseRepo repositories.PremiseRepository, userPremiseRepo repositories.UserPremiseRepository, groupRepo repositories.GroupRepository, assignmentRepo repositories.AssignmentRepository, uow repositories.UnitOfWork, blobStore storage.BlobStore) *AssignmentService {
+	return &AssignmentService{
+		cfg:             cfg,
+		userRepo:        userRepo,
+		premiseRepo:     premiseRepo,
+		userPremiseRepo: userPremiseRepo,
+		groupRepo:       groupRepo,
+		assignmentRepo:  assignmentRepo,
+		uow:             uow,
+		blobStore:       blobStore,
+	}
+}
+
+// CreateAssignment assigns a checklist to a guard. If a premise is given the guard has to be assigned to it.
+func (s *AssignmentService) CreateAssignment(ctx context.Context, createdByID string, createAssignmentDto *dto.CreateAssignmentDto) (*models.Assignment, error) {
+	user, err := s.userRepo.GetUserByID(ctx, createAssignmentDto.UserID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	if !user.IsActive {
+		return nil, errors.NewBadRequestError("User is not active")
+	}
+
+	assignment := &models.Assignment{
+		UserID:      user.ID,
+		Type:        createAssignmentDto.Type,
+		Title:       createAssignmentDto.Title,
+		Description: createAssignmentDto.Description,
+		Status:      models.AssignmentStatusPending,
+		Sequential:  createAssignmentDto.Sequential == nil || *createAssignmentDto.Sequential,
+		DueAt:       createAssignmentDto.DueAt,
+	}
+	if createdBy, err := uuid.Parse(createdByID); err == nil {
+		assignment.CreatedByID = &createdBy
+	}
+	if createAssignmentDto.PremiseID != "" {
+		premise, err := getPremise(ctx, &s.premiseRepo, createAssignmentDto.PremiseID)
+		if err != nil {
+			return nil, err
+		}
+		assigned, err := isAssignedToPremise(ctx, s.userPremiseRepo, s.groupRepo, user.ID.String(), premise.ID.String(), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if !assigned {
+			return nil, errors.NewBadRequestError("User is not assigned to this premise")
+		}
+		assignment.PremiseID = &premise.ID
+	}
+	for i, step := range createAssignmentDto.Steps {
+		assignment.Steps = append(assignment.Steps, models.AssignmentStep{
+			Position:         i + 1,
+			Title:            step.Title,
+			Description:      step.Description,
+			RequiresEvidence: step.RequiresEvidence,
+		})
+	}
+
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Assignments.CreateAssignment(ctx, assignment); err != nil {
+			return errors.NewDatabaseError("create assignment", err)
+		}
+		return s.publishAssignmentEvent(ctx, repos.Outbox, "assignment.created", assignment, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// GetAssignment returns the assignment. Guards can only see their own assignments.
+func (s *AssignmentService) GetAssignment(ctx context.Context, id string, userID string, role string) (*models.Assignment, error) {
+	assignment, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if role == "guard" && assignment.UserID.String() != userID {
+		return nil, errors.NewNotFoundError("assignment")
+	}
+	if err := s.setEvidenceURLs(ctx, assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (s *AssignmentService) GetUserAssignments(ctx context.Context, userID string, filter dto.AssignmentFilter) ([]models.Assignment, error) {
+	assignments, err := s.assignmentRepo.GetByUser(ctx, userID, filter)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user assignments", err)
+	}
+	for i := range assignments {
+		if err := s.setEvidenceURLs(ctx, &assignments[i]); err != nil {
+			return nil, err
+		}
+	}
+	return assignments, nil
+}
+
+// CancelAssignment cancels an assignment that has not been completed yet
+func (s *AssignmentService) CancelAssignment(ctx context.Context, id string) (*models.Assignment, error) {
+	assignment, err := s.getAssignment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !assignment.IsOpen() {
+		return nil, errors.NewConflictError(fmt.Sprintf("Assignment is already %s", assignment.Status))
+	}
+
+	assignment.Status = models.AssignmentStatusCancelled
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Assignments.UpdateAssignment(ctx, assignment); err != nil {
+			return errors.NewDatabaseError("cancel assignment", err)
+		}
+		return s.publishAssignmentEvent(ctx, repos.Outbox, "assignment.cancelled", assignment, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// CompleteStep completes a step of the user's assignment with an optional evidence file.
+// Steps of sequential assignments have to be completed in order, and steps that require
+// evidence can't be completed without it.
+func (s *AssignmentService) CompleteStep(ctx context.Context, userID string, assignmentID string, stepID string, completeStepDto *dto.CompleteStepDto, evidence *multipart.FileHeader) (*models.Assignment, error) {
+	assignment, err := s.getAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.UserID.String() != userID {
+		return nil, errors.NewNotFoundError("assignment")
+	}
+	step := findStep(assignment, stepID)
+	if step == nil {
+		return nil, errors.NewNotFoundError("assignment step")
+	}
+	if step.RequiresEvidence && evidence == nil {
+		return nil, errors.NewBadRequestError("This step requires an evidence file")
+	}
+	if err := checkStepCompletable(assignment, step); err != nil {
+		return nil, err
+	}
+
+	// The evidence is stored before the transaction so the row lock isn't held during the upload
+	var evidenceKey, evidenceContentType string
+	if evidence != nil {
+		evidenceKey, evidenceContentType, err = s.storeEvidence(ctx, assignment.ID, step.ID, evidence)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		locked, err := repos.Assignments.GetAssignmentForUpdate(ctx, assignmentID)
+		if err != nil {
+			return errors.NewDatabaseError("get assignment", err)
+		}
+		if locked == nil {
+			return errors.NewNotFoundError("assignment")
+		}
+		// Check again under the lock, another step may have been completed in the meantime
+		step := findStep(locked, stepID)
+		if err := checkStepCompletable(locked, step); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		step.Note = completeStepDto.Note
+		step.EvidenceKey = evidenceKey
+		step.EvidenceContentType = evidenceContentType
+		completed, err := repos.Assignments.CompleteStep(ctx, step, locked.UserID, now)
+		if err != nil {
+			return errors.NewDatabaseError("complete assignment step", err)
+		}
+		if !completed {
+			return errors.NewConflictError("Step is already completed")
+		}
+
+		if locked.Status == models.AssignmentStatusPending {
+			locked.Status = models.AssignmentStatusInProgress
+			locked.StartedAt = &now
+		}
+		if locked.CompletedSteps() == len(locked.Steps) {
+			locked.Status = models.AssignmentStatusCompleted
+			locked.CompletedAt = &now
+		}
+		if err := repos.Assignments.UpdateAssignment(ctx, locked); err != nil {
+			return errors.NewDatabaseError("update assignment", err)
+		}
+		if err := s.publishAssignmentEvent(ctx, repos.Outbox, "assignment.step_completed", locked, step); err != nil {
+			return err
+		}
+		if locked.Status == models.AssignmentStatusCompleted {
+			if err := s.publishAssignmentEvent(ctx, repos.Outbox, "assignment.completed", locked, nil); err != nil {
+				return err
+			}
+		}
+		locked.Premise = assignment.Premise
+		assignment = locked
+		return nil
+	})
+	if err != nil {
+		if evidenceKey != "" {
+			s.blobStore.Delete(ctx, evidenceKey)
+		}
+		return nil, err
+	}
+
+	if err := s.setEvidenceURLs(ctx, assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (s *AssignmentService) getAssignment(ctx context.Context, id string) (*models.Assignment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequestError("Invalid assignment id")
+	}
+	assignment, err := s.assignmentRepo.GetAssignmentByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get assignment", err)
+	}
+	if assignment == nil {
+		return nil, errors.NewNotFoundError("assignment")
+	}
+	return assignment, nil
+}
+
+// storeEvidence validates the evidence photo or video and stores it, returning its key and content type
+func (s *AssignmentService) storeEvidence(ctx context.Context, assignmentID uuid.UUID, stepID uuid.UUID, file *multipart.FileHeader) (string, string, error) {
+	if file.Size > s.cfg.Upload.MaxEvidenceSize {
+		return "", "", errors.NewBadRequestError(fmt.Sprintf("file size %d bytes exceeds maximum allowed size %d bytes", file.Size, s.cfg.Upload.MaxEvidenceSize))
+	}
+	contentType, err := utils.DetectContentType(file)
+	if err != nil {
+		return "", "", errors.NewBadRequestError(err.Error())
+	}
+	if !utils.AllowedImageTypes[contentType] && !utils.AllowedVideoTypes[contentType] {
+		return "", "", errors.NewBadRequestError(fmt.Sprintf("file type %s is not allowed, evidence must be a photo or a video", contentType))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", "", errors.NewInternalError("Failed to open evidence", err)
+	}
+	defer src.Close()
+
+	key := fmt.Sprintf("%s/%s/%s/%s%s", evidenceKeyPrefix, assignmentID, stepID, uuid.New(), strings.ToLower(filepath.Ext(file.Filename)))
+	if err := s.blobStore.Put(ctx, key, src, file.Size, contentType); err != nil {
+		return "", "", errors.NewInternalError("Failed to store evidence", err)
+	}
+	return key, contentType, nil
+}
+
+// setEvidenceURLs fills the signed evidence URLs of the assignment steps
+func (s *AssignmentService) setEvidenceURLs(ctx context.Context, assignment *models.Assignment) error {
+	for i := range assignment.Steps {
+		step := &assignment.Steps[i]
+		if step.EvidenceKey == "" {
+			continue
+		}
+		url, err := s.blobStore.SignedURL(ctx, step.EvidenceKey, s.cfg.Storage.SignedURLTTL)
+		if err != nil {
+			return errors.NewInternalError("Failed to sign evidence URL", err)
+		}
+		step.EvidenceURL = url
+	}
+	return nil
+}
+
+func (s *AssignmentService) publishAssignmentEvent(ctx context.Context, outbox *repositories.OutboxRepository, eventType string, assignment *models.Assignment, step *models.AssignmentStep) error {
+	payload := map[string]interface{}{
+		"id":              assignment.ID.String(),
+		"user_id":         assignment.UserID.String(),
+		"premise_id":      nil,
+		"type":            assignment.Type,
+		"title":           assignment.Title,
+		"status":          assignment.Status,
+		"completed_steps": assignment.CompletedSteps(),
+		"total_steps":     len(assignment.Steps),
+	}
+	if assignment.PremiseID != nil {
+		payload["premise_id"] = assignment.PremiseID.String()
+	}
+	if step != nil {
+		payload["step"] = map[string]interface{}{
+			"id":           step.ID.String(),
+			"position":     step.Position,
+			"title":        step.Title,
+			"completed_at": step.CompletedAt,
+			"has_evidence": step.EvidenceKey != "",
+		}
+	}
+	return publishEvent(ctx, outbox, assignment.ID.String(), eventType, payload)
+}
+
+// checkStepCompletable applies the ordering rules to the step of the assignment
+func checkStepCompletable(assignment *models.Assignment, step *models.AssignmentStep) error {
+	if !assignment.IsOpen() {
+		return errors.NewConflictError(fmt.Sprintf("Assignment is already %s", assignment.Status))
+	}
+	if step.IsCompleted() {
+		return errors.NewConflictError("Step is already completed")
+	}
+	if !assignment.Sequential {
+		return nil
+	}
+	for _, previous := range assignment.Steps {
+		if previous.Position < step.Position && !previous.IsCompleted() {
+			return errors.NewConflictError(fmt.Sprintf("Step %d (%s) has to be completed first", previous.Position, previous.Title))
+		}
+	}
+	return nil
+}
+
+// findStep returns the step of the assignment with the given ID, or nil if there is none
+func findStep(assignment *models.Assignment, stepID string) *models.AssignmentStep {
+	for i := range assignment.Steps {
+		if assignment.Steps[i].ID.String() == stepID {
+			return &assignment.Steps[i]
+		}
+	}
+	return nil
+}
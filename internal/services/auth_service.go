@@ -2,36 +2,68 @@ package services
 
 import (
 	"context"
+	config "scs-user/config"
 	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
 	repositories "scs-user/internal/repositories"
 	"scs-user/pkg/errors"
 	"scs-user/pkg/utils"
+	"strings"
+	"time"
 )
 
 type AuthService struct {
-	userRepo repositories.UserRepository
+	cfg         *config.Config
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+	tokenRepo   repositories.UserTokenRepository
+	uow         repositories.UnitOfWork
+	notifier    *Notifier
 }
 
-func NewAuthService(userRepo repositories.UserRepository) *AuthService {
-	return &AuthService{userRepo: userRepo}
+func NewAuthService(cfg *config.Config, userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, tokenRepo repositories.UserTokenRepository, uow repositories.UnitOfWork, notifier *Notifier) *AuthService {
+	return &AuthService{cfg: cfg, userRepo: userRepo, sessionRepo: sessionRepo, tokenRepo: tokenRepo, uow: uow, notifier: notifier}
 }
 
-func (s *AuthService) Login(ctx context.Context, loginDto *dto.LoginRequest) (*dto.LoginResponse, error) {
+func (s *AuthService) Login(ctx context.Context, loginDto *dto.LoginRequest, userAgent string, ipAddress string) (*dto.LoginResponse, error) {
 	user, err := s.userRepo.GetUserByEmail(ctx, loginDto.Email)
 	if err != nil {
 		return nil, errors.NewUnauthorizedError("User not found")
 	}
+	// A locked account does not even check the password, so that guessing can not continue
+	if user.IsLocked(time.Now()) {
+		return nil, errors.NewUnauthorizedError("Account is locked after too many failed logins, try again later")
+	}
 
 	// Verify the password
 	if err := utils.VerifyPassword(user.Password, loginDto.Password); err != nil {
+		if err := s.recordFailedLogin(ctx, user.ID.String()); err != nil {
+			return nil, err
+		}
 		return nil, errors.NewUnauthorizedError("Invalid credentials")
 	}
 	if !user.IsActive {
 		return nil, errors.NewUnauthorizedError("User is not active")
 	}
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ResetFailedLogins(ctx, user.ID.String()); err != nil {
+			return nil, errors.NewDatabaseError("reset failed logins", err)
+		}
+	}
+
+	// Every login starts a new session which can be revoked independently
+	session := &models.Session{
+		UserID:    user.ID,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		ExpiresAt: time.Now().Add(utils.TokenTTL),
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, errors.NewDatabaseError("create session", err)
+	}
 
 	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID.String(), user.Role)
+	token, err := utils.GenerateToken(user.ID.String(), user.Role, session.ID.String())
 	if err != nil {
 		return nil, errors.NewInternalError("Failed to generate token", err)
 	}
@@ -40,11 +72,132 @@ func (s *AuthService) Login(ctx context.Context, loginDto *dto.LoginRequest) (*d
 }
 
 func (s *AuthService) ValidateToken(ctx context.Context, token string) (*dto.ValidateTokenResponse, error) {
-	err := utils.ValidateToken(token)
-	if err != nil {
+	claims, err := utils.ParseToken(token)
+	if err != nil || claims.ID == "" {
 		return nil, errors.NewUnauthorizedError("Invalid token")
 	}
+	active, err := s.sessionRepo.IsSessionActive(ctx, claims.ID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("check session", err)
+	}
+	if !active {
+		return nil, errors.NewUnauthorizedError("Session has been revoked")
+	}
 	return &dto.ValidateTokenResponse{
 		Valid: true,
 	}, nil
 }
+
+// ForgotPassword emails a password reset link to an active user. It succeeds for unknown
+// addresses too, so that it can not be used to find out which addresses have an account.
+func (s *AuthService) ForgotPassword(ctx context.Context, forgotPasswordDto *dto.ForgotPasswordDto) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, strings.TrimSpace(forgotPasswordDto.Email))
+	if err != nil || user.Status != models.UserStatusActive {
+		return nil
+	}
+
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return errors.NewInternalError("Failed to generate token", err)
+	}
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		// Only the most recent link can be used
+		if err := repos.UserTokens.InvalidateUserTokens(ctx, user.ID.String(), models.TokenPurposePasswordReset); err != nil {
+			return errors.NewDatabaseError("invalidate tokens", err)
+		}
+		userToken := &models.UserToken{
+			UserID:    user.ID,
+			Purpose:   models.TokenPurposePasswordReset,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(s.cfg.Auth.PasswordResetTTL),
+		}
+		if err := repos.UserTokens.CreateToken(ctx, userToken); err != nil {
+			return errors.NewDatabaseError("create token", err)
+		}
+		return s.notifier.SendPasswordReset(ctx, repos, user, token, userToken.ExpiresAt)
+	})
+}
+
+// ResetPassword sets the new password with the token of a reset link, unlocks the account and
+// revokes all sessions of the user
+func (s *AuthService) ResetPassword(ctx context.Context, resetPasswordDto *dto.ResetPasswordDto) error {
+	userToken, err := s.tokenRepo.GetValidToken(ctx, models.TokenPurposePasswordReset, utils.HashToken(resetPasswordDto.Token))
+	if err != nil {
+		return errors.NewDatabaseError("get token", err)
+	}
+	if userToken == nil {
+		return errors.NewBadRequestError("Invalid or expired token")
+	}
+	hashedPassword, err := utils.HashPassword(resetPasswordDto.Password)
+	if err != nil {
+		return errors.NewInternalError("Failed to hash password", err)
+	}
+
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		consumed, err := repos.UserTokens.ConsumeToken(ctx, userToken.ID.String())
+		if err != nil {
+			return errors.NewDatabaseError("consume token", err)
+		}
+		if !consumed {
+			return errors.NewBadRequestError("Invalid or expired token")
+		}
+		user, err := repos.Users.LockUser(ctx, userToken.UserID.String())
+		if err != nil {
+			return errors.NewDatabaseError("lock user", err)
+		}
+		// Suspended users must not regain access through a link sent before the suspension
+		if user == nil || user.Status != models.UserStatusActive {
+			return errors.NewBadRequestError("Invalid or expired token")
+		}
+		user.Password = hashedPassword
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+		if err := repos.Users.UpdateUser(ctx, user); err != nil {
+			return errors.NewDatabaseError("update user", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.sessionRepo.RevokeUserSessions(ctx, userToken.UserID.String(), ""); err != nil {
+		return errors.NewDatabaseError("revoke sessions", err)
+	}
+	return nil
+}
+
+// recordFailedLogin counts a failed login of the user. Once the limit is reached the account is
+// locked for the lockout duration and the user is notified.
+func (s *AuthService) recordFailedLogin(ctx context.Context, userID string) error {
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		user, err := repos.Users.LockUser(ctx, userID)
+		if err != nil {
+			return errors.NewDatabaseError("lock user", err)
+		}
+		now := time.Now()
+		// Logins that were already running when the account got locked do not count
+		if user == nil || user.IsLocked(now) {
+			return nil
+		}
+		user.FailedLogins++
+		if user.FailedLogins < s.cfg.Auth.MaxFailedLogins {
+			if err := repos.Users.UpdateUser(ctx, user); err != nil {
+				return errors.NewDatabaseError("update user", err)
+			}
+			return nil
+		}
+
+		lockedUntil := now.Add(s.cfg.Auth.LockoutDuration)
+		user.FailedLogins = 0
+		user.LockedUntil = &lockedUntil
+		if err := repos.Users.UpdateUser(ctx, user); err != nil {
+			return errors.NewDatabaseError("update user", err)
+		}
+		// Only active users can act on the email
+		if user.Status != models.UserStatusActive {
+			return nil
+		}
+		return s.notifier.SendLockout(ctx, repos, user, lockedUntil)
+	})
+}
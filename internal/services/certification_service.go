@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	config "scs-user/config"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/storage"
+	"scs-user/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const certificationKeyPrefix = "certifications"
+
+// CertificationService records the licenses and certificates of users and announces their expiry
+type CertificationService struct {
+	cfg               *config.Config
+	userRepo          repositories.UserRepository
+	certificationRepo repositories.CertificationRepository
+	blobStore         storage.BlobStore
+	uow               repositories.UnitOfWork
+}
+
+func NewCertificationService(cfg *config.Config, userRepo repositories.UserRepository, certificationRepo repositories.CertificationRepository, blobStore storage.BlobStore, uow repositories.UnitOfWork) *CertificationService {
+	return &CertificationService{cfg: cfg, userRepo: userRepo, certificationRepo: certificationRepo, blobStore: blobStore, uow: uow}
+}
+
+// CreateCertification records a certification of the user. It stays pending until an admin verifies it.
+func (s *CertificationService) CreateCertification(ctx context.Context, userID string, createCertificationDto *dto.CreateCertificationDto) (*models.Certification, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	if createCertificationDto.ExpiresAt != nil && !createCertificationDto.ExpiresAt.After(createCertificationDto.IssuedAt) {
+		return nil, errors.NewBadRequestError("expires_at must be after issued_at")
+	}
+
+	certification := &models.Certification{
+		UserID:    user.ID,
+		Type:      strings.ToLower(createCertificationDto.Type),
+		Number:    createCertificationDto.Number,
+		Issuer:    createCertificationDto.Issuer,
+		IssuedAt:  createCertificationDto.IssuedAt,
+		ExpiresAt: createCertificationDto.ExpiresAt,
+		Status:    models.CertificationStatusPending,
+	}
+	if err := s.certificationRepo.CreateCertification(ctx, certification); err != nil {
+		if isDuplicateKeyError(err, "idx_certifications_user_type_number") {
+			return nil, errors.NewConflictError("This certification is already recorded")
+		}
+		return nil, errors.NewDatabaseError("create certification", err)
+	}
+	return certification, nil
+}
+
+func (s *CertificationService) GetUserCertifications(ctx context.Context, userID string) ([]models.Certification, error) {
+	certifications, err := s.certificationRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user certifications", err)
+	}
+	for i := range certifications {
+		if err := s.setDocumentURL(ctx, &certifications[i]); err != nil {
+			return nil, err
+		}
+	}
+	return certifications, nil
+}
+
+// GetCertification returns the certification. Users other than admins and operators can only see their own.
+func (s *CertificationService) GetCertification(ctx context.Context, id string, userID string, role string) (*models.Certification, error) {
+	certification, err := s.getCertification(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setDocumentURL(ctx, certification); err != nil {
+		return nil, err
+	}
+	return certification, nil
+}
+
+// UploadDocument stores a scan of the certification. The certification has to be verified again afterwards.
+func (s *CertificationService) UploadDocument(ctx context.Context, id string, userID string, role string, file *multipart.FileHeader) (*models.Certification, error) {
+	certification, err := s.getCertification(ctx, id, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateImageFile(file, s.cfg.Upload.MaxDocumentSize); err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+	contentType, err := utils.DetectContentType(file)
+	if err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to open document", err)
+	}
+	defer src.Close()
+	key := fmt.Sprintf("%s/%s/%s/%s%s", certificationKeyPrefix, certification.UserID, certification.ID, uuid.New(), utils.ImageExtension(contentType))
+	if err := s.blobStore.Put(ctx, key, src, file.Size, contentType); err != nil {
+		return nil, errors.NewInternalError("Failed to store document", err)
+	}
+
+	var oldKey string
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		// The document replaced is the one stored when the upload is recorded, not when it started
+		certification, err = s.lockCertification(ctx, repos, id, userID, role)
+		if err != nil {
+			return err
+		}
+		oldKey = certification.DocumentKey
+		certification.DocumentKey = key
+		certification.DocumentContentType = contentType
+		certification.Status = models.CertificationStatusPending
+		certification.VerifiedByID = nil
+		certification.VerifiedAt = nil
+		certification.RejectionReason = ""
+		if err := repos.Certifications.UpdateCertification(ctx, certification); err != nil {
+			return errors.NewDatabaseError("update certification", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.blobStore.Delete(ctx, key)
+		return nil, err
+	}
+	if oldKey != "" {
+		// The old document is no longer referenced, a failed delete only leaves an orphaned object
+		s.blobStore.Delete(ctx, oldKey)
+	}
+	if err := s.setDocumentURL(ctx, certification); err != nil {
+		return nil, err
+	}
+	return certification, nil
+}
+
+// VerifyCertification marks the certification as checked by the admin
+func (s *CertificationService) VerifyCertification(ctx context.Context, id string, adminID string) (*models.Certification, error) {
+	var certification *models.Certification
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		var err error
+		certification, err = s.lockCertification(ctx, repos, id, adminID, "admin")
+		if err != nil {
+			return err
+		}
+		if certification.DocumentKey == "" {
+			return errors.NewBadRequestError("Certification has no document to verify")
+		}
+		// Concurrent verifications must not announce it twice
+		if certification.Status == models.CertificationStatusVerified {
+			return errors.NewConflictError("Certification is already verified")
+		}
+
+		now := time.Now()
+		certification.Status = models.CertificationStatusVerified
+		certification.VerifiedAt = &now
+		certification.RejectionReason = ""
+		if verifiedBy, err := uuid.Parse(adminID); err == nil {
+			certification.VerifiedByID = &verifiedBy
+		}
+		if err := repos.Certifications.UpdateCertification(ctx, certification); err != nil {
+			return errors.NewDatabaseError("verify certification", err)
+		}
+		return s.publishCertificationEvent(ctx, repos.Outbox, "certification.verified", certification)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certification, nil
+}
+
+// RejectCertification refuses the certification with a reason shown to the user
+func (s *CertificationService) RejectCertification(ctx context.Context, id string, adminID string, rejectCertificationDto *dto.RejectCertificationDto) (*models.Certification, error) {
+	var certification *models.Certification
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		var err error
+		certification, err = s.lockCertification(ctx, repos, id, adminID, "admin")
+		if err != nil {
+			return err
+		}
+
+		certification.Status = models.CertificationStatusRejected
+		certification.RejectionReason = rejectCertificationDto.Reason
+		certification.VerifiedByID = nil
+		certification.VerifiedAt = nil
+		if err := repos.Certifications.UpdateCertification(ctx, certification); err != nil {
+			return errors.NewDatabaseError("reject certification", err)
+		}
+		return s.publishCertificationEvent(ctx, repos.Outbox, "certification.rejected", certification)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certification, nil
+}
+
+func (s *CertificationService) DeleteCertification(ctx context.Context, id string, userID string, role string) error {
+	var documentKey string
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		certification, err := s.lockCertification(ctx, repos, id, userID, role)
+		if err != nil {
+			return err
+		}
+		if err := repos.Certifications.DeleteCertification(ctx, id); err != nil {
+			return errors.NewDatabaseError("delete certification", err)
+		}
+		documentKey = certification.DocumentKey
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if documentKey != "" {
+		s.blobStore.Delete(ctx, documentKey)
+	}
+	return nil
+}
+
+// GetExpiringCertifications returns the verified certifications expiring within the next days,
+// the configured notice period by default
+func (s *CertificationService) GetExpiringCertifications(ctx context.Context, days int) ([]models.Certification, error) {
+	if days == 0 {
+		days = s.cfg.Certification.ExpiryNoticeDays
+	}
+	now := time.Now()
+	certifications, err := s.certificationRepo.GetExpiring(ctx, now, now.AddDate(0, 0, days), false)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get expiring certifications", err)
+	}
+	return certifications, nil
+}
+
+// NotifyExpiring publishes certification.expiring once for every verified certification that
+// expires within the notice period. It is run periodically by the server.
+func (s *CertificationService) NotifyExpiring(ctx context.Context) error {
+	now := time.Now()
+	certifications, err := s.certificationRepo.GetExpiring(ctx, now, now.AddDate(0, 0, s.cfg.Certification.ExpiryNoticeDays), true)
+	if err != nil {
+		return err
+	}
+	for i := range certifications {
+		certification := &certifications[i]
+		err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+			claimed, err := repos.Certifications.MarkExpiryNotified(ctx, certification.ID.String(), now)
+			if err != nil || !claimed {
+				// Not claimed if another replica announced it in the meantime
+				return err
+			}
+			return s.publishCertificationEvent(ctx, repos.Outbox, "certification.expiring", certification)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRequiredCertifications returns a forbidden error unless the user holds a valid certification
+// of every required type at the given time
+func checkRequiredCertifications(ctx context.Context, certificationRepo repositories.CertificationRepository, required []string, userID string, at time.Time) error {
+	if len(required) == 0 {
+		return nil
+	}
+	validTypes, err := certificationRepo.GetValidTypes(ctx, userID, at)
+	if err != nil {
+		return errors.NewDatabaseError("get valid certifications", err)
+	}
+	valid := make(map[string]bool, len(validTypes))
+	for _, certificationType := range validTypes {
+		valid[certificationType] = true
+	}
+	var missing []string
+	for _, certificationType := range required {
+		certificationType = strings.ToLower(strings.TrimSpace(certificationType))
+		if certificationType != "" && !valid[certificationType] {
+			missing = append(missing, certificationType)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.NewForbiddenError(fmt.Sprintf("Missing valid certification: %s", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// getCertification returns the certification if the user may access it
+func (s *CertificationService) getCertification(ctx context.Context, id string, userID string, role string) (*models.Certification, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequestError("Invalid certification id")
+	}
+	certification, err := s.certificationRepo.GetCertificationByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get certification", err)
+	}
+	if certification == nil {
+		return nil, errors.NewNotFoundError("certification")
+	}
+	if role != "admin" && role != "operator" && certification.UserID.String() != userID {
+		return nil, errors.NewNotFoundError("certification")
+	}
+	return certification, nil
+}
+
+// lockCertification locks the certification in the unit of work if the user may access it, so that
+// it is checked and updated without another request changing or deleting it in between
+func (s *CertificationService) lockCertification(ctx context.Context, repos *repositories.Repositories, id string, userID string, role string) (*models.Certification, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequestError("Invalid certification id")
+	}
+	certification, err := repos.Certifications.LockCertification(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("lock certification", err)
+	}
+	if certification == nil {
+		return nil, errors.NewNotFoundError("certification")
+	}
+	if role != "admin" && role != "operator" && certification.UserID.String() != userID {
+		return nil, errors.NewNotFoundError("certification")
+	}
+	return certification, nil
+}
+
+// setDocumentURL fills the signed document URL of the certification
+func (s *CertificationService) setDocumentURL(ctx context.Context, certification *models.Certification) error {
+	if certification.DocumentKey == "" {
+		return nil
+	}
+	url, err := s.blobStore.SignedURL(ctx, certification.DocumentKey, s.cfg.Storage.SignedURLTTL)
+	if err != nil {
+		return errors.NewInternalError("Failed to sign document URL", err)
+	}
+	certification.DocumentURL = url
+	return nil
+}
+
+func (s *CertificationService) publishCertificationEvent(ctx context.Context, outbox *repositories.OutboxRepository, eventType string, certification *models.Certification) error {
+	payload := map[string]interface{}{
+		"id":         certification.ID.String(),
+		"user_id":    certification.UserID.String(),
+		"type":       certification.Type,
+		"number":     certification.Number,
+		"issuer":     certification.Issuer,
+		"status":     certification.Status,
+		"issued_at":  certification.IssuedAt,
+		"expires_at": certification.ExpiresAt,
+	}
+	if certification.Status == models.CertificationStatusRejected {
+		payload["rejection_reason"] = certification.RejectionReason
+	}
+	return publishEvent(ctx, outbox, certification.UserID.String(), eventType, payload)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	config "scs-user/config"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/logger"
+	"scs-user/pkg/mail"
+	"scs-user/pkg/utils"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// maxEmailErrorLength bounds the error stored with a queued email
+const maxEmailErrorLength = 1000
+
+var emailAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "email_send_attempts_total",
+	Help: "Number of attempts to send a queued email by template and result.",
+}, []string{"template", "result"})
+
+// EmailDispatcher sends the queued emails through the mail provider. Failed attempts are retried
+// with exponential backoff until the maximum number of attempts is reached.
+type EmailDispatcher struct {
+	cfg       *config.Config
+	emailRepo repositories.EmailRepository
+	uow       repositories.UnitOfWork
+	sender    mail.Sender
+	logger    logger.Logger
+}
+
+func NewEmailDispatcher(cfg *config.Config, emailRepo repositories.EmailRepository, uow repositories.UnitOfWork, sender mail.Sender, logger logger.Logger) *EmailDispatcher {
+	return &EmailDispatcher{cfg: cfg, emailRepo: emailRepo, uow: uow, sender: sender, logger: logger}
+}
+
+// Send sends a batch of due emails. It is run periodically by the server; the claimed emails stay
+// locked until their outcome is recorded, so replicas never send one twice at once.
+func (d *EmailDispatcher) Send(ctx context.Context) error {
+	return d.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		emails, err := repos.Emails.ClaimDue(ctx, time.Now(), d.cfg.Mail.BatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range emails {
+			if err := d.send(ctx, repos.Emails, &emails[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Cleanup deletes the finished emails past the retention period
+func (d *EmailDispatcher) Cleanup(ctx context.Context) error {
+	deleted, err := d.emailRepo.DeleteFinished(ctx, time.Now().Add(-d.cfg.Mail.Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		d.logger.Infof("Deleted %d finished emails", deleted)
+	}
+	return nil
+}
+
+// send attempts the email and records the outcome. An error is only returned if the context was
+// cancelled or the outcome could not be recorded.
+func (d *EmailDispatcher) send(ctx context.Context, emails *repositories.EmailRepository, email *models.Email) error {
+	err := d.sender.Send(ctx, &mail.Message{
+		From:    d.cfg.Mail.From,
+		To:      email.To,
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	email.Attempts++
+	if err == nil {
+		emailAttempts.WithLabelValues(email.Template, "sent").Inc()
+		email.Status = models.EmailStatusSent
+		email.SentAt = &now
+		email.LastError = ""
+		clearEmailBodies(email)
+		return emails.SaveAttempt(ctx, email)
+	}
+
+	emailAttempts.WithLabelValues(email.Template, "failed").Inc()
+	d.logger.Warnf("Failed to send %s email %s, attempt %d: %v", email.Template, email.ID, email.Attempts, err)
+	email.LastError = truncate(err.Error(), maxEmailErrorLength)
+	if email.Attempts >= d.cfg.Mail.MaxAttempts {
+		email.Status = models.EmailStatusFailed
+		clearEmailBodies(email)
+		d.logger.Errorf("Gave up sending %s email %s after %d attempts", email.Template, email.ID, email.Attempts)
+	} else {
+		email.NextAttemptAt = now.Add(utils.Backoff(email.Attempts, d.cfg.Mail.RetryBaseDelay, d.cfg.Mail.RetryMaxDelay))
+	}
+	return emails.SaveAttempt(ctx, email)
+}
+
+// clearEmailBodies drops the bodies of a finished email, so that the single-use tokens in its
+// links are not kept until the email is deleted
+func clearEmailBodies(email *models.Email) {
+	email.Text = ""
+	email.HTML = ""
+}
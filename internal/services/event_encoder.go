@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	config "scs-user/config"
+	"scs-user/internal/models"
+	"scs-user/pkg/events"
+	kafka_client "scs-user/pkg/kafka"
+)
+
+// Formats of the published event values
+const (
+	ValueFormatJSON       = "json"
+	ValueFormatJSONSchema = "json-schema"
+)
+
+// EventEncoder encodes the payloads of outbox events as the values of their Kafka messages. With
+// the json-schema format, the envelope schema of every catalogued event type is registered under
+// its RecordSubject, so that a topic can carry several event types, and its ID is embedded in the
+// wire format. Uncatalogued events and tombstones are published as they are.
+type EventEncoder struct {
+	routes       map[string]string
+	defaultTopic string
+	serializers  map[string]kafka_client.Serializer
+}
+
+// NewEventEncoder returns the encoder of the configured value format
+func NewEventEncoder(cfg *config.KafkaConfig) (*EventEncoder, error) {
+	encoder := &EventEncoder{routes: cfg.TopicRoutes, defaultTopic: cfg.DefaultTopic, serializers: make(map[string]kafka_client.Serializer)}
+	switch cfg.ValueFormat {
+	case ValueFormatJSON:
+		return encoder, nil
+	case ValueFormatJSONSchema:
+		if cfg.SchemaRegistryURL == "" {
+			return nil, fmt.Errorf("KAFKA_SCHEMA_REGISTRY_URL is required for the %s value format", ValueFormatJSONSchema)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported KAFKA_VALUE_FORMAT %q, expected %s or %s", cfg.ValueFormat, ValueFormatJSON, ValueFormatJSONSchema)
+	}
+
+	registry := kafka_client.NewSchemaRegistryClient(cfg.SchemaRegistryURL, &http.Client{Timeout: cfg.SchemaRegistryTimeout})
+	for _, definition := range events.Catalogue() {
+		schema, err := json.Marshal(definition.EnvelopeSchema())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the schema of %s: %w", definition.Type, err)
+		}
+		encoder.serializers[definition.Type] = kafka_client.NewJSONRecordSerializer(registry, string(schema), definition.Type)
+	}
+	return encoder, nil
+}
+
+// Encode returns the message value of the event
+func (e *EventEncoder) Encode(ctx context.Context, event *models.OutboxEvent) ([]byte, error) {
+	serializer, ok := e.serializers[event.Type]
+	if !ok || event.Payload == nil {
+		return event.Payload, nil
+	}
+	topic := kafka_client.RouteTopic(e.routes, e.defaultTopic, event.Type)
+	return serializer.Serialize(ctx, topic, json.RawMessage(event.Payload))
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+	"scs-user/pkg/tracing"
+
+	"github.com/google/uuid"
+)
+
+// eventSource is the CloudEvents source of the events published by this service
+const eventSource = "/scs-user"
+
+// publishEvent records an event about the entity with the given ID in the outbox. Pass the
+// outbox of the unit of work that makes the change, the event is then only published to Kafka by
+// the outbox relay if the change is committed. Catalogued event types take their typed payload
+// from pkg/events.
+func publishEvent(ctx context.Context, outbox *repositories.OutboxRepository, key string, eventType string, data any) error {
+	envelope := events.New(eventSource, eventType, key, data)
+	messageBytes, err := json.Marshal(envelope)
+	if err != nil {
+		return errors.NewInternalError("Failed to marshal message", err)
+	}
+
+	event := &models.OutboxEvent{
+		Key:         key,
+		Type:        eventType,
+		EventID:     envelope.ID,
+		TraceParent: tracing.FromContext(ctx),
+		Payload:     messageBytes,
+	}
+	if err := outbox.AddEvent(ctx, event); err != nil {
+		return errors.NewDatabaseError("add outbox event", err)
+	}
+	return nil
+}
+
+// publishTombstone records a tombstone of the entity with the given ID in the outbox, removing
+// the entity from compacted topics
+func publishTombstone(ctx context.Context, outbox *repositories.OutboxRepository, key string, eventType string) error {
+	event := &models.OutboxEvent{
+		Key:         key,
+		Type:        eventType,
+		EventID:     uuid.New().String(),
+		TraceParent: tracing.FromContext(ctx),
+	}
+	if err := outbox.AddEvent(ctx, event); err != nil {
+		return errors.NewDatabaseError("add outbox event", err)
+	}
+	return nil
+}
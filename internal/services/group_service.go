@@ -0,0 +1,503 @@
+package services
+
+import (
+	"context"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/internal/types"
+	"scs-user/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GroupService manages teams of users, their nesting and the premises their members inherit
+type GroupService struct {
+	groupRepo       repositories.GroupRepository
+	userRepo        repositories.UserRepository
+	premiseRepo     repositories.PremiseRepository
+	userPremiseRepo repositories.UserPremiseRepository
+	uow             repositories.UnitOfWork
+}
+
+func NewGroupService(groupRepo repositories.GroupRepository, userRepo repositories.UserRepository, premiseRepo repositories.PremiseRepository, userPremiseRepo repositories.UserPremiseRepository, uow repositories.UnitOfWork) *GroupService {
+	return &GroupService{groupRepo: groupRepo, userRepo: userRepo, premiseRepo: premiseRepo, userPremiseRepo: userPremiseRepo, uow: uow}
+}
+
+func (s *GroupService) CreateGroup(ctx context.Context, createGroupDto *dto.CreateGroupDto) (*models.Group, error) {
+	group := &models.Group{
+		Name:        createGroupDto.Name,
+		Description: createGroupDto.Description,
+	}
+	if createGroupDto.ParentGroupID != "" {
+		parentID, err := uuid.Parse(createGroupDto.ParentGroupID)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid group id")
+		}
+		group.ParentGroupID = &parentID
+	}
+
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		// The parent stays locked until the subgroup exists, so that it is not deleted in between
+		if group.ParentGroupID != nil {
+			if _, err := lockGroup(ctx, repos, *group.ParentGroupID, "parent group"); err != nil {
+				return err
+			}
+		}
+		if err := repos.Groups.CreateGroup(ctx, group); err != nil {
+			if isDuplicateKeyError(err, "name") {
+				return errors.NewConflictError("A group with this name already exists")
+			}
+			return errors.NewDatabaseError("create group", err)
+		}
+		return s.publishGroupEvent(ctx, repos.Outbox, "group.created", group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *GroupService) GetGroups(ctx context.Context, filter dto.GroupFilter, page int, limit int) (*types.PaginateResponse[models.Group], error) {
+	groups, err := s.groupRepo.GetGroups(ctx, filter, page, limit)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get groups", err)
+	}
+	total, err := s.groupRepo.GetGroupsCount(ctx, filter)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get groups count", err)
+	}
+	totalPages := int(total) / limit
+	if total%int64(limit) != 0 {
+		totalPages++
+	}
+	return &types.PaginateResponse[models.Group]{
+		Pagination: types.Pagination{
+			TotalPages: totalPages,
+			Page:       page,
+			Limit:      limit,
+		},
+		Data: groups,
+	}, nil
+}
+
+func (s *GroupService) GetGroup(ctx context.Context, id string) (*models.Group, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequestError("Invalid group id")
+	}
+	group, err := s.groupRepo.GetGroupByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get group", err)
+	}
+	if group == nil {
+		return nil, errors.NewNotFoundError("group")
+	}
+	return group, nil
+}
+
+// UpdateGroup renames the group or moves it under another parent. The group and the new parent stay
+// locked from the cycle check to the update, so that concurrent moves can not create a cycle together.
+func (s *GroupService) UpdateGroup(ctx context.Context, id string, updateGroupDto *dto.UpdateGroupDto) (*models.Group, error) {
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.NewBadRequestError("Invalid group id")
+	}
+	ids := []uuid.UUID{groupID}
+	var parentID *uuid.UUID
+	if updateGroupDto.ParentGroupID != nil && *updateGroupDto.ParentGroupID != "" {
+		newParentID, err := uuid.Parse(*updateGroupDto.ParentGroupID)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid group id")
+		}
+		parentID = &newParentID
+		ids = append(ids, newParentID)
+	}
+
+	var group *models.Group
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		locked, err := repos.Groups.LockGroups(ctx, ids)
+		if err != nil {
+			return errors.NewDatabaseError("lock groups", err)
+		}
+		parentFound := false
+		for i := range locked {
+			if locked[i].ID == groupID {
+				group = &locked[i]
+			} else {
+				parentFound = true
+			}
+		}
+		if group == nil {
+			return errors.NewNotFoundError("group")
+		}
+		if updateGroupDto.Name != nil {
+			group.Name = *updateGroupDto.Name
+		}
+		if updateGroupDto.Description != nil {
+			group.Description = *updateGroupDto.Description
+		}
+		if updateGroupDto.ParentGroupID != nil {
+			if parentID != nil {
+				if !parentFound && *parentID != groupID {
+					return errors.NewNotFoundError("parent group")
+				}
+				// Nesting a group below itself or one of its subgroups would create a cycle
+				cycle, err := repos.Groups.IsInSubtree(ctx, id, parentID.String())
+				if err != nil {
+					return errors.NewDatabaseError("check group subtree", err)
+				}
+				if cycle {
+					return errors.NewBadRequestError("Group can not be nested below itself or one of its subgroups")
+				}
+			}
+			group.ParentGroupID = parentID
+		}
+
+		if err := repos.Groups.UpdateGroup(ctx, group); err != nil {
+			if isDuplicateKeyError(err, "name") {
+				return errors.NewConflictError("A group with this name already exists")
+			}
+			return errors.NewDatabaseError("update group", err)
+		}
+		if err := s.publishGroupEvent(ctx, repos.Outbox, "group.updated", group); err != nil {
+			return err
+		}
+		if updateGroupDto.ParentGroupID == nil {
+			return nil
+		}
+		// The members of the subtree inherit the premises of other ancestors now
+		memberIDs, err := groupMemberIDs(ctx, repos, id, false)
+		if err != nil {
+			return err
+		}
+		return publishUserSnapshots(ctx, repos, memberIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup deletes a group without subgroups together with its memberships and premise assignments.
+// Subgroups are created and moved with their parent locked, so none can appear once it is counted.
+func (s *GroupService) DeleteGroup(ctx context.Context, id string) error {
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.NewBadRequestError("Invalid group id")
+	}
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		group, err := lockGroup(ctx, repos, groupID, "group")
+		if err != nil {
+			return err
+		}
+		children, err := repos.Groups.CountChildren(ctx, id)
+		if err != nil {
+			return errors.NewDatabaseError("count subgroups", err)
+		}
+		if children > 0 {
+			return errors.NewConflictError("Group has subgroups, move or delete them first")
+		}
+		memberIDs, err := groupMemberIDs(ctx, repos, id, true)
+		if err != nil {
+			return err
+		}
+		if err := repos.Groups.DeleteGroup(ctx, id); err != nil {
+			return errors.NewDatabaseError("delete group", err)
+		}
+		if err := s.publishGroupEvent(ctx, repos.Outbox, "group.deleted", group); err != nil {
+			return err
+		}
+		return publishUserSnapshots(ctx, repos, memberIDs)
+	})
+}
+
+// GetMembers resolves the group to its users, including the members of its subgroups unless only direct members are requested
+func (s *GroupService) GetMembers(ctx context.Context, id string, query dto.GroupMembersQuery) ([]models.User, error) {
+	if _, err := s.GetGroup(ctx, id); err != nil {
+		return nil, err
+	}
+	users, err := s.groupRepo.ResolveMembers(ctx, id, query.DirectOnly, query.ActiveOnly)
+	if err != nil {
+		return nil, errors.NewDatabaseError("resolve group members", err)
+	}
+	return users, nil
+}
+
+// AddMembers adds the users to the group. Users who already are members are skipped.
+func (s *GroupService) AddMembers(ctx context.Context, id string, addGroupMembersDto *dto.AddGroupMembersDto) error {
+	group, err := s.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	userIDs, err := parseUserIDs(addGroupMembersDto.UserIDs)
+	if err != nil {
+		return err
+	}
+	count, err := s.userRepo.CountExistingUsers(ctx, userIDs)
+	if err != nil {
+		return errors.NewDatabaseError("check users", err)
+	}
+	if count != int64(len(userIDs)) {
+		return errors.NewNotFoundError("user")
+	}
+
+	ids := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		ids[i] = userID.String()
+	}
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Groups.AddMembers(ctx, group.ID, userIDs); err != nil {
+			return errors.NewDatabaseError("add group members", err)
+		}
+		err := publishEvent(ctx, repos.Outbox, group.ID.String(), "group.members_added", map[string]interface{}{
+			"group_id": group.ID.String(),
+			"user_ids": ids,
+		})
+		if err != nil {
+			return err
+		}
+		return publishUserSnapshots(ctx, repos, userIDs)
+	})
+}
+
+func (s *GroupService) RemoveMember(ctx context.Context, id string, userID string) error {
+	group, err := s.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	memberID, err := uuid.Parse(userID)
+	if err != nil {
+		return errors.NewBadRequestError("Invalid user id")
+	}
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		removed, err := repos.Groups.RemoveMember(ctx, id, userID)
+		if err != nil {
+			return errors.NewDatabaseError("remove group member", err)
+		}
+		if !removed {
+			return errors.NewNotFoundError("group member")
+		}
+		err = publishEvent(ctx, repos.Outbox, group.ID.String(), "group.member_removed", map[string]interface{}{
+			"group_id": group.ID.String(),
+			"user_id":  userID,
+		})
+		if err != nil {
+			return err
+		}
+		return publishUserSnapshot(ctx, repos, memberID)
+	})
+}
+
+func (s *GroupService) GetGroupPremises(ctx context.Context, id string) ([]models.GroupPremise, error) {
+	if _, err := s.GetGroup(ctx, id); err != nil {
+		return nil, err
+	}
+	groupPremises, err := s.groupRepo.GetGroupPremises(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get group premises", err)
+	}
+	return groupPremises, nil
+}
+
+// AssignPremise assigns the premise to every member of the group and its subgroups
+func (s *GroupService) AssignPremise(ctx context.Context, id string, assignGroupPremiseDto *dto.AssignGroupPremiseDto) (*models.GroupPremise, error) {
+	group, err := s.GetGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	premise, err := getPremise(ctx, &s.premiseRepo, assignGroupPremiseDto.PremiseID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupPremise := &models.GroupPremise{
+		GroupID:   group.ID,
+		PremiseID: premise.ID,
+		Role:      assignGroupPremiseDto.Role,
+	}
+	if groupPremise.Role == "" {
+		groupPremise.Role = models.PremiseRoleGuard
+	}
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Groups.AddPremise(ctx, groupPremise); err != nil {
+			if isDuplicateKeyError(err, "idx_group_premises_group_premise") {
+				return errors.NewConflictError("Premise is already assigned to this group")
+			}
+			return errors.NewDatabaseError("assign group premise", err)
+		}
+		if err := s.publishGroupPremiseEvent(ctx, repos.Outbox, "group.premise_assigned", groupPremise); err != nil {
+			return err
+		}
+		memberIDs, err := groupMemberIDs(ctx, repos, id, false)
+		if err != nil {
+			return err
+		}
+		return publishUserSnapshots(ctx, repos, memberIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	groupPremise.Premise = premise
+	return groupPremise, nil
+}
+
+func (s *GroupService) UnassignPremise(ctx context.Context, id string, premiseID string) error {
+	group, err := s.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+	premiseUUID, err := uuid.Parse(premiseID)
+	if err != nil {
+		return errors.NewBadRequestError("Invalid premise id")
+	}
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		removed, err := repos.Groups.RemovePremise(ctx, id, premiseID)
+		if err != nil {
+			return errors.NewDatabaseError("remove group premise", err)
+		}
+		if !removed {
+			return errors.NewNotFoundError("group premise")
+		}
+		if err := s.publishGroupPremiseEvent(ctx, repos.Outbox, "group.premise_unassigned", &models.GroupPremise{GroupID: group.ID, PremiseID: premiseUUID}); err != nil {
+			return err
+		}
+		memberIDs, err := groupMemberIDs(ctx, repos, id, false)
+		if err != nil {
+			return err
+		}
+		return publishUserSnapshots(ctx, repos, memberIDs)
+	})
+}
+
+// GetUserGroups returns the groups the user is a direct member of
+func (s *GroupService) GetUserGroups(ctx context.Context, userID string) ([]models.Group, error) {
+	groups, err := s.groupRepo.GetUserGroups(ctx, userID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user groups", err)
+	}
+	return groups, nil
+}
+
+// GetEffectivePremises returns the premises the user is currently assigned to, directly or
+// through a group. Direct assignments take precedence over inherited ones.
+func (s *GroupService) GetEffectivePremises(ctx context.Context, userID string) ([]dto.EffectivePremise, error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	now := time.Now()
+	userPremises, err := s.userPremiseRepo.GetByUser(ctx, userID, &now)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user premises", err)
+	}
+	groupPremises, err := s.groupRepo.GetInheritedPremises(ctx, userID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get inherited premises", err)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(userPremises)+len(groupPremises))
+	premises := make([]dto.EffectivePremise, 0, len(userPremises)+len(groupPremises))
+	for _, userPremise := range userPremises {
+		seen[userPremise.PremiseID] = true
+		premises = append(premises, dto.EffectivePremise{
+			Premise: *userPremise.Premise,
+			Role:    userPremise.Role,
+			Source:  "direct",
+		})
+	}
+	for _, groupPremise := range groupPremises {
+		if seen[groupPremise.PremiseID] {
+			continue
+		}
+		seen[groupPremise.PremiseID] = true
+		premises = append(premises, dto.EffectivePremise{
+			Premise:   *groupPremise.Premise,
+			Role:      groupPremise.Role,
+			Source:    "group",
+			GroupID:   groupPremise.GroupID.String(),
+			GroupName: groupPremise.Group.Name,
+		})
+	}
+	return premises, nil
+}
+
+func (s *GroupService) publishGroupEvent(ctx context.Context, outbox *repositories.OutboxRepository, eventType string, group *models.Group) error {
+	payload := map[string]interface{}{
+		"id":              group.ID.String(),
+		"name":            group.Name,
+		"description":     group.Description,
+		"parent_group_id": nil,
+	}
+	if group.ParentGroupID != nil {
+		payload["parent_group_id"] = group.ParentGroupID.String()
+	}
+	return publishEvent(ctx, outbox, group.ID.String(), eventType, payload)
+}
+
+func (s *GroupService) publishGroupPremiseEvent(ctx context.Context, outbox *repositories.OutboxRepository, eventType string, groupPremise *models.GroupPremise) error {
+	return publishEvent(ctx, outbox, groupPremise.GroupID.String(), eventType, map[string]interface{}{
+		"group_id":   groupPremise.GroupID.String(),
+		"premise_id": groupPremise.PremiseID.String(),
+		"role":       groupPremise.Role,
+	})
+}
+
+// isAssignedToPremise reports whether the user is assigned to the premise at the given time,
+// directly or through one of the user's groups
+func isAssignedToPremise(ctx context.Context, userPremiseRepo repositories.UserPremiseRepository, groupRepo repositories.GroupRepository, userID string, premiseID string, at time.Time) (bool, error) {
+	userPremise, err := userPremiseRepo.GetUserPremise(ctx, userID, premiseID)
+	if err != nil {
+		return false, errors.NewDatabaseError("get user premise", err)
+	}
+	if userPremise != nil && userPremise.IsActiveAt(at) {
+		return true, nil
+	}
+	inherited, err := groupRepo.InheritsPremise(ctx, userID, premiseID)
+	if err != nil {
+		return false, errors.NewDatabaseError("check inherited premise", err)
+	}
+	return inherited, nil
+}
+
+// parseUserIDs parses and deduplicates the user IDs
+func parseUserIDs(ids []string) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	userIDs := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid user id")
+		}
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+// lockGroup locks the group in the unit of work, a not found error naming it if it does not exist
+func lockGroup(ctx context.Context, repos *repositories.Repositories, id uuid.UUID, name string) (*models.Group, error) {
+	locked, err := repos.Groups.LockGroups(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, errors.NewDatabaseError("lock groups", err)
+	}
+	if len(locked) == 0 {
+		return nil, errors.NewNotFoundError(name)
+	}
+	return &locked[0], nil
+}
+
+// groupMemberIDs returns the IDs of the members of the group, including the members of its subgroups
+// unless directOnly is set. Their snapshots change with the premises the group passes on.
+func groupMemberIDs(ctx context.Context, repos *repositories.Repositories, id string, directOnly bool) ([]uuid.UUID, error) {
+	members, err := repos.Groups.ResolveMembers(ctx, id, directOnly, false)
+	if err != nil {
+		return nil, errors.NewDatabaseError("resolve group members", err)
+	}
+	ids := make([]uuid.UUID, len(members))
+	for i, member := range members {
+		ids[i] = member.ID
+	}
+	return ids, nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+	"scs-user/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+// InvitationService invites users who then choose their own password
+type InvitationService struct {
+	userRepo       repositories.UserRepository
+	invitationRepo repositories.InvitationRepository
+	uow            repositories.UnitOfWork
+	notifier       *Notifier
+}
+
+func NewInvitationService(userRepo repositories.UserRepository, invitationRepo repositories.InvitationRepository, uow repositories.UnitOfWork, notifier *Notifier) *InvitationService {
+	return &InvitationService{userRepo: userRepo, invitationRepo: invitationRepo, uow: uow, notifier: notifier}
+}
+
+// InviteUser creates an invited user with the given premises and sends the invitation
+func (s *InvitationService) InviteUser(ctx context.Context, invitedByID string, inviteUserDto *dto.InviteUserDto) (*models.Invitation, error) {
+	inviterID, err := uuid.Parse(invitedByID)
+	if err != nil {
+		return nil, errors.NewBadRequestError("Invalid user id")
+	}
+	premiseIDs, err := parsePremiseIDs(inviteUserDto.PremiseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Name:  inviteUserDto.Name,
+		Email: strings.TrimSpace(inviteUserDto.Email),
+		Role:  inviteUserDto.Role,
+	}
+	user.SetStatus(models.UserStatusInvited)
+	invitation := &models.Invitation{
+		Email:       user.Email,
+		InvitedByID: inviterID,
+	}
+	token, err := s.issueToken(invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	// The user, its premises and the invitation are created together or not at all
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := ensurePremisesExist(ctx, repos.Premises, premiseIDs); err != nil {
+			return err
+		}
+		createdUser, err := repos.Users.CreateUser(ctx, user)
+		if err != nil {
+			if isDuplicateEmailError(err) {
+				return errors.NewConflictError("User with this email already exists")
+			}
+			return errors.NewDatabaseError("create user", err)
+		}
+		for _, premiseID := range premiseIDs {
+			userPremise := &models.UserPremise{UserID: createdUser.ID, PremiseID: premiseID}
+			if err := repos.UserPremises.AssignPremises(ctx, userPremise); err != nil {
+				return errors.NewDatabaseError("add user to premise", err)
+			}
+		}
+		invitation.UserID = &createdUser.ID
+		if err := repos.Invitations.CreateInvitation(ctx, invitation); err != nil {
+			return errors.NewDatabaseError("create invitation", err)
+		}
+		if err := publishUserSnapshot(ctx, repos, createdUser.ID); err != nil {
+			return err
+		}
+		return s.publishInvitation(ctx, repos, createdUser, invitation, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// ResendInvitation issues a new token, which invalidates the previous one, and sends the invitation again
+func (s *InvitationService) ResendInvitation(ctx context.Context, invitationID string) (*models.Invitation, error) {
+	var invitation *models.Invitation
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		var user *models.User
+		var err error
+		invitation, user, err = lockOpenInvitation(ctx, repos, invitationID)
+		if err != nil {
+			return err
+		}
+		token, err := s.issueToken(invitation)
+		if err != nil {
+			return err
+		}
+		updated, err := repos.Invitations.UpdateOpenInvitation(ctx, invitation.ID.String(), map[string]interface{}{
+			"token_hash": invitation.TokenHash,
+			"expires_at": invitation.ExpiresAt,
+			"sent_count": invitation.SentCount,
+		})
+		if err != nil {
+			return errors.NewDatabaseError("update invitation", err)
+		}
+		if !updated {
+			return invitationClosedError()
+		}
+		return s.publishInvitation(ctx, repos, user, invitation, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// RevokeInvitation revokes the invitation and removes the invited user so the email can be invited again
+func (s *InvitationService) RevokeInvitation(ctx context.Context, invitationID string) error {
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		invitation, user, err := lockOpenInvitation(ctx, repos, invitationID)
+		if err != nil {
+			return err
+		}
+		revoked, err := repos.Invitations.UpdateOpenInvitation(ctx, invitation.ID.String(), map[string]interface{}{
+			"revoked_at": time.Now(),
+			"user_id":    nil,
+		})
+		if err != nil {
+			return errors.NewDatabaseError("update invitation", err)
+		}
+		if !revoked {
+			return invitationClosedError()
+		}
+		if err := repos.UserPremises.RemoveUserPremises(ctx, user.ID.String()); err != nil {
+			return errors.NewDatabaseError("remove user premises", err)
+		}
+		if err := repos.Users.DeleteUser(ctx, user.ID.String()); err != nil {
+			return errors.NewDatabaseError("delete user", err)
+		}
+		err = publishEvent(ctx, repos.Outbox, user.ID.String(), events.UserInvitationRevoked, events.UserInvitationRevokedData{
+			UserID: user.ID.String(),
+			Email:  invitation.Email,
+		})
+		if err != nil {
+			return err
+		}
+		// The user is gone, its snapshot becomes a tombstone
+		return publishUserSnapshot(ctx, repos, user.ID)
+	})
+}
+
+// AcceptInvitation sets the password chosen by the invitee and activates the user. The invitation
+// and the user are locked and checked again in the transaction, so an invitation that is revoked
+// or accepted at the same time can not be accepted.
+func (s *InvitationService) AcceptInvitation(ctx context.Context, acceptInvitationDto *dto.AcceptInvitationDto) error {
+	hashedPassword, err := utils.HashPassword(acceptInvitationDto.Password)
+	if err != nil {
+		return errors.NewInternalError("Failed to hash password", err)
+	}
+	invalid := errors.NewBadRequestError("Invalid or expired invitation")
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		invitation, err := repos.Invitations.LockInvitationByTokenHash(ctx, utils.HashToken(acceptInvitationDto.Token))
+		if err != nil {
+			return errors.NewDatabaseError("get invitation", err)
+		}
+		if invitation == nil || !invitation.IsPending(time.Now()) || invitation.UserID == nil {
+			return invalid
+		}
+		user, err := repos.Users.LockUser(ctx, invitation.UserID.String())
+		if err != nil {
+			return errors.NewDatabaseError("lock user", err)
+		}
+		if user == nil || user.Status != models.UserStatusInvited {
+			return invalid
+		}
+
+		activated, err := repos.Users.UpdateUserColumns(ctx, user.ID.String(), models.UserStatusInvited, map[string]interface{}{
+			"password":  hashedPassword,
+			"status":    models.UserStatusActive,
+			"is_active": true,
+		})
+		if err != nil {
+			return errors.NewDatabaseError("update user", err)
+		}
+		accepted, err := repos.Invitations.UpdateOpenInvitation(ctx, invitation.ID.String(), map[string]interface{}{
+			"accepted_at": time.Now(),
+		})
+		if err != nil {
+			return errors.NewDatabaseError("update invitation", err)
+		}
+		if !activated || !accepted {
+			return invalid
+		}
+		return publishUserSnapshot(ctx, repos, user.ID)
+	})
+}
+
+// invitationClosedError is returned for invitations that have already been accepted or revoked
+func invitationClosedError() error {
+	return errors.NewConflictError("Invitation has already been accepted or revoked")
+}
+
+// lockOpenInvitation locks an invitation that has been neither accepted nor revoked, together
+// with its invited user
+func lockOpenInvitation(ctx context.Context, repos *repositories.Repositories, invitationID string) (*models.Invitation, *models.User, error) {
+	if _, err := uuid.Parse(invitationID); err != nil {
+		return nil, nil, errors.NewBadRequestError("Invalid invitation id")
+	}
+	invitation, err := repos.Invitations.LockInvitation(ctx, invitationID)
+	if err != nil {
+		return nil, nil, errors.NewDatabaseError("get invitation", err)
+	}
+	if invitation == nil {
+		return nil, nil, errors.NewNotFoundError("invitation")
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil || invitation.UserID == nil {
+		return nil, nil, invitationClosedError()
+	}
+	user, err := repos.Users.LockUser(ctx, invitation.UserID.String())
+	if err != nil {
+		return nil, nil, errors.NewDatabaseError("lock user", err)
+	}
+	if user == nil || user.Status != models.UserStatusInvited {
+		return nil, nil, invitationClosedError()
+	}
+	return invitation, user, nil
+}
+
+// issueToken sets a new token hash and expiry on the invitation and returns the plain token
+func (s *InvitationService) issueToken(invitation *models.Invitation) (string, error) {
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return "", errors.NewInternalError("Failed to generate token", err)
+	}
+	invitation.TokenHash = utils.HashToken(token)
+	invitation.ExpiresAt = time.Now().Add(invitationTTL)
+	invitation.SentCount++
+	return token, nil
+}
+
+// publishInvitation emails the invitation and publishes it with user.invited
+func (s *InvitationService) publishInvitation(ctx context.Context, repos *repositories.Repositories, user *models.User, invitation *models.Invitation, token string) error {
+	if err := s.notifier.SendInvitation(ctx, repos, user, token, invitation.ExpiresAt); err != nil {
+		return err
+	}
+	return publishEvent(ctx, repos.Outbox, user.ID.String(), events.UserInvited, events.UserInvitedData{
+		UserID:       user.ID.String(),
+		InvitationID: invitation.ID.String(),
+		Email:        user.Email,
+		Name:         user.Name,
+		Role:         user.Role,
+		ExpiresAt:    invitation.ExpiresAt,
+	})
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	config "scs-user/config"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/mail"
+	"strings"
+	"time"
+)
+
+// emailTimeFormat formats the times in emails, in the time zone of the recipient
+const emailTimeFormat = "2006-01-02 15:04 MST"
+
+// Paths of the web app pages the links in emails open
+const (
+	verifyAccountPath    = "/verify-account"
+	acceptInvitationPath = "/accept-invitation"
+	resetPasswordPath    = "/reset-password"
+	forgotPasswordPath   = "/forgot-password"
+	confirmEmailPath     = "/confirm-email"
+)
+
+// emailData is the data the email templates are rendered with
+type emailData struct {
+	Name        string
+	Role        string
+	Email       string
+	Link        string
+	ExpiresAt   string
+	LockedUntil string
+}
+
+// Notifier queues transactional emails to users, rendered in the locale and time zone of their profile
+type Notifier struct {
+	cfg       *config.Config
+	templates *mail.Templates
+}
+
+func NewNotifier(cfg *config.Config, templates *mail.Templates) *Notifier {
+	return &Notifier{cfg: cfg, templates: templates}
+}
+
+// SendVerification queues the email with the link that verifies the account of a new user
+func (n *Notifier) SendVerification(ctx context.Context, repos *repositories.Repositories, user *models.User, token string, expiresAt time.Time) error {
+	return n.queue(ctx, repos, user, user.Email, mail.TemplateVerification, func(location *time.Location) emailData {
+		return emailData{Name: user.Name, Link: n.link(verifyAccountPath, token), ExpiresAt: expiresAt.In(location).Format(emailTimeFormat)}
+	})
+}
+
+// SendInvitation queues the email with the link with which the invitee chooses a password
+func (n *Notifier) SendInvitation(ctx context.Context, repos *repositories.Repositories, user *models.User, token string, expiresAt time.Time) error {
+	return n.queue(ctx, repos, user, user.Email, mail.TemplateInvitation, func(location *time.Location) emailData {
+		return emailData{Name: user.Name, Role: user.Role, Link: n.link(acceptInvitationPath, token), ExpiresAt: expiresAt.In(location).Format(emailTimeFormat)}
+	})
+}
+
+// SendPasswordReset queues the email with the link that sets a new password
+func (n *Notifier) SendPasswordReset(ctx context.Context, repos *repositories.Repositories, user *models.User, token string, expiresAt time.Time) error {
+	return n.queue(ctx, repos, user, user.Email, mail.TemplatePasswordReset, func(location *time.Location) emailData {
+		return emailData{Name: user.Name, Link: n.link(resetPasswordPath, token), ExpiresAt: expiresAt.In(location).Format(emailTimeFormat)}
+	})
+}
+
+// SendLockout queues the email telling the user that the account is locked after too many failed logins
+func (n *Notifier) SendLockout(ctx context.Context, repos *repositories.Repositories, user *models.User, lockedUntil time.Time) error {
+	return n.queue(ctx, repos, user, user.Email, mail.TemplateLockout, func(location *time.Location) emailData {
+		return emailData{Name: user.Name, Link: n.link(forgotPasswordPath, ""), LockedUntil: lockedUntil.In(location).Format(emailTimeFormat)}
+	})
+}
+
+// SendEmailChange queues the email to the new address with the link that confirms it
+func (n *Notifier) SendEmailChange(ctx context.Context, repos *repositories.Repositories, user *models.User, newEmail string, token string, expiresAt time.Time) error {
+	return n.queue(ctx, repos, user, newEmail, mail.TemplateEmailChange, func(location *time.Location) emailData {
+		return emailData{Name: user.Name, Link: n.link(confirmEmailPath, token), ExpiresAt: expiresAt.In(location).Format(emailTimeFormat)}
+	})
+}
+
+// SendEmailChanged queues the email telling the old address that the email of the account has been changed
+func (n *Notifier) SendEmailChanged(ctx context.Context, repos *repositories.Repositories, user *models.User, oldEmail string) error {
+	return n.queue(ctx, repos, user, oldEmail, mail.TemplateEmailChanged, func(location *time.Location) emailData {
+		return emailData{Name: user.Name, Email: user.Email}
+	})
+}
+
+// queue renders the template for the user and adds the email to address to the queue of the unit of work
+func (n *Notifier) queue(ctx context.Context, repos *repositories.Repositories, user *models.User, to string, template string, data func(location *time.Location) emailData) error {
+	profile, err := repos.UserProfiles.GetByUserID(ctx, user.ID.String())
+	if err != nil {
+		return errors.NewDatabaseError("get user profile", err)
+	}
+	locale := n.cfg.Mail.DefaultLocale
+	location := time.UTC
+	if profile != nil {
+		if profile.Locale != "" {
+			locale = profile.Locale
+		}
+		if profile.TimeZone != "" {
+			if loc, err := time.LoadLocation(profile.TimeZone); err == nil {
+				location = loc
+			}
+		}
+	}
+
+	content, err := n.templates.Render(template, locale, data(location))
+	if err != nil {
+		return errors.NewInternalError("Failed to render email", err)
+	}
+	email := &models.Email{
+		UserID:   &user.ID,
+		Template: template,
+		To:       to,
+		Subject:  content.Subject,
+		Text:     content.Text,
+		HTML:     content.HTML,
+		Status:   models.EmailStatusPending,
+	}
+	if err := repos.Emails.AddEmail(ctx, email); err != nil {
+		return errors.NewDatabaseError("add email", err)
+	}
+	return nil
+}
+
+// link returns the address of the web app page, with the token as query parameter if set
+func (n *Notifier) link(path string, token string) string {
+	link := strings.TrimRight(n.cfg.Mail.AppBaseURL, "/") + path
+	if token != "" {
+		link += "?" + url.Values{"token": {token}}.Encode()
+	}
+	return link
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	config "scs-user/config"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	kafka_client "scs-user/pkg/kafka"
+	"scs-user/pkg/logger"
+	"scs-user/pkg/utils"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	outboxPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_events_published_total",
+		Help: "Number of outbox events published to Kafka.",
+	})
+	outboxPublishFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_publish_failures_total",
+		Help: "Number of failed attempts to publish an outbox event.",
+	})
+	outboxAbandoned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_events_abandoned_total",
+		Help: "Number of outbox events set aside as failed after their last attempt.",
+	})
+	outboxFailed = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_failed_events",
+		Help: "Number of outbox events set aside as failed and not published.",
+	})
+	outboxPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_events",
+		Help: "Number of outbox events not published yet.",
+	})
+	outboxOldestPendingAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_oldest_pending_age_seconds",
+		Help: "Age of the oldest unpublished outbox event, 0 if there is none.",
+	})
+	outboxPublishLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "outbox_publish_lag_seconds",
+		Help:    "Time between an outbox event being written and published.",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600},
+	})
+)
+
+// OutboxRelay publishes the events of the outbox to Kafka. Events of the same key are published
+// in order: an event is held back while an earlier event of its key is waiting for a retry. An
+// event that still fails after the configured number of attempts is set aside as failed, counted
+// in outbox_failed_events, and no longer holds back its key.
+// Published events are also queued for delivery to the webhooks subscribed to them.
+type OutboxRelay struct {
+	cfg        *config.Config
+	outboxRepo repositories.OutboxRepository
+	uow        repositories.UnitOfWork
+	producer   kafka_client.Publisher
+	encoder    *EventEncoder
+	logger     logger.Logger
+}
+
+func NewOutboxRelay(cfg *config.Config, outboxRepo repositories.OutboxRepository, uow repositories.UnitOfWork, producer kafka_client.Publisher, encoder *EventEncoder, logger logger.Logger) *OutboxRelay {
+	return &OutboxRelay{cfg: cfg, outboxRepo: outboxRepo, uow: uow, producer: producer, encoder: encoder, logger: logger}
+}
+
+// Relay publishes a batch of pending events. It is run periodically by the server; replicas
+// that find another replica relaying skip the run.
+func (r *OutboxRelay) Relay(ctx context.Context) error {
+	err := r.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		locked, err := repos.Outbox.TryLockRelay(ctx)
+		if err != nil || !locked {
+			return err
+		}
+		events, err := repos.Outbox.GetPending(ctx, time.Now(), r.cfg.Outbox.BatchSize)
+		if err != nil {
+			return err
+		}
+		return r.publish(ctx, repos.Outbox, repos.Webhooks, events)
+	})
+	if err != nil {
+		return err
+	}
+	return r.updateBacklogMetrics(ctx)
+}
+
+// Cleanup deletes the published events past the retention period
+func (r *OutboxRelay) Cleanup(ctx context.Context) error {
+	deleted, err := r.outboxRepo.DeletePublished(ctx, time.Now().Add(-r.cfg.Outbox.Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		r.logger.Infof("Deleted %d published outbox events", deleted)
+	}
+	return nil
+}
+
+// publish sends the events in rounds. Every round sends at most one event per key, so a failed
+// event never has a later event of its key published before it.
+func (r *OutboxRelay) publish(ctx context.Context, outbox *repositories.OutboxRepository, webhooks *repositories.WebhookRepository, events []models.OutboxEvent) error {
+	now := time.Now()
+	blocked := make(map[string]bool)
+	queued := make(map[string][]*models.OutboxEvent)
+	var keys []string
+	for i := range events {
+		event := &events[i]
+		if blocked[event.Key] {
+			continue
+		}
+		if event.NextAttemptAt.After(now) {
+			// Waiting for a retry, later events of the key have to wait too
+			blocked[event.Key] = true
+			continue
+		}
+		if _, ok := queued[event.Key]; !ok {
+			keys = append(keys, event.Key)
+		}
+		queued[event.Key] = append(queued[event.Key], event)
+	}
+
+	for len(keys) > 0 {
+		round := make([]*models.OutboxEvent, len(keys))
+		for i, key := range keys {
+			round[i] = queued[key][0]
+			queued[key] = queued[key][1:]
+		}
+
+		failed, err := r.send(ctx, round)
+		if err != nil {
+			return err
+		}
+		publishedAt := time.Now()
+		var published []int64
+		var publishedEvents []*models.OutboxEvent
+		for i, event := range round {
+			if failed[i] != nil {
+				outboxPublishFailures.Inc()
+				queued[event.Key] = nil
+				attempt := event.Attempts + 1
+				if attempt >= r.cfg.Outbox.MaxAttempts {
+					outboxAbandoned.Inc()
+					r.logger.Errorf("Giving up on outbox event %d (%s) after %d attempts: %v", event.ID, event.Type, attempt, failed[i])
+					if err := outbox.MarkAbandoned(ctx, event.ID, publishedAt, failed[i].Error()); err != nil {
+						return err
+					}
+					continue
+				}
+				r.logger.Warnf("Failed to publish outbox event %d (%s), attempt %d: %v", event.ID, event.Type, attempt, failed[i])
+				if err := outbox.MarkFailed(ctx, event.ID, publishedAt.Add(utils.Backoff(attempt, r.cfg.Outbox.RetryBaseDelay, r.cfg.Outbox.RetryMaxDelay)), failed[i].Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			published = append(published, event.ID)
+			publishedEvents = append(publishedEvents, event)
+			outboxPublishLag.Observe(publishedAt.Sub(event.CreatedAt).Seconds())
+		}
+		if err := outbox.MarkPublished(ctx, published, publishedAt); err != nil {
+			return err
+		}
+		outboxPublished.Add(float64(len(published)))
+		if err := enqueueWebhookDeliveries(ctx, webhooks, publishedEvents); err != nil {
+			return err
+		}
+
+		remaining := keys[:0]
+		for _, key := range keys {
+			if len(queued[key]) > 0 {
+				remaining = append(remaining, key)
+			}
+		}
+		keys = remaining
+	}
+	return nil
+}
+
+// outboxMessage returns the Kafka message of the event. The producer routes it to a topic by its
+// ce_type header, consumers deduplicate it by its idempotency-key header.
+func outboxMessage(event *models.OutboxEvent) kafka.Message {
+	return kafka.Message{
+		Key:     []byte(event.Key),
+		Value:   event.Payload,
+		Headers: kafka_client.EventHeaders(event.EventID, event.Type, event.TraceParent),
+	}
+}
+
+// send encodes and writes the events and returns the error of every event that was not written.
+// An event that can not be encoded, e.g. while the schema registry is unavailable, is retried
+// like a failed write.
+func (r *OutboxRelay) send(ctx context.Context, round []*models.OutboxEvent) ([]error, error) {
+	failed := make([]error, len(round))
+	var messages []kafka.Message
+	var written []int
+	for i, event := range round {
+		msg := outboxMessage(event)
+		value, err := r.encoder.Encode(ctx, event)
+		if err != nil {
+			failed[i] = fmt.Errorf("failed to encode event: %w", err)
+			continue
+		}
+		msg.Value = value
+		messages = append(messages, msg)
+		written = append(written, i)
+	}
+	if len(messages) == 0 {
+		return failed, nil
+	}
+	writeFailed, err := r.write(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range written {
+		failed[i] = writeFailed[j]
+	}
+	return failed, nil
+}
+
+// write sends the messages and returns the error of every message that was not written.
+// An error is only returned if the context was cancelled.
+func (r *OutboxRelay) write(ctx context.Context, messages []kafka.Message) ([]error, error) {
+	failed := make([]error, len(messages))
+	err := r.producer.WriteMessages(ctx, messages...)
+	if err == nil {
+		return failed, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if writeErrors, ok := err.(kafka.WriteErrors); ok {
+		copy(failed, writeErrors)
+		return failed, nil
+	}
+	for i := range failed {
+		failed[i] = err
+	}
+	return failed, nil
+}
+
+func (r *OutboxRelay) updateBacklogMetrics(ctx context.Context) error {
+	count, oldest, failed, err := r.outboxRepo.GetBacklog(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox metrics: %w", err)
+	}
+	outboxPending.Set(float64(count))
+	outboxFailed.Set(float64(failed))
+	if oldest == nil {
+		outboxOldestPendingAge.Set(0)
+	} else {
+		outboxOldestPendingAge.Set(time.Since(*oldest).Seconds())
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	config "scs-user/config"
+	"scs-user/internal/models"
+	"scs-user/pkg/events"
+	kafka_client "scs-user/pkg/kafka"
+	"strings"
+	"testing"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/segmentio/kafka-go"
+)
+
+// newTestKafkaConfig returns the default Kafka config
+func newTestKafkaConfig(t *testing.T) config.KafkaConfig {
+	t.Helper()
+	var cfg config.KafkaConfig
+	if err := env.Parse(&cfg); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	return cfg
+}
+
+// newTestProducer returns a producer routing by the default Kafka config, it is never written to
+func newTestProducer(t *testing.T) *kafka_client.Producer {
+	t.Helper()
+	cfg := newTestKafkaConfig(t)
+	return kafka_client.NewProducer(
+		&kafka_client.Config{Brokers: []string{"localhost:9092"}, Topic: cfg.DefaultTopic},
+		&kafka_client.ProducerConfig{Topics: cfg.TopicRoutes},
+	)
+}
+
+func TestOutboxRelayWritesEventHeaders(t *testing.T) {
+	recorder := kafka_client.NewRecorder()
+	relay := &OutboxRelay{producer: recorder}
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	event := &models.OutboxEvent{
+		Key:         "user-1",
+		Type:        events.UserCreated,
+		EventID:     "event-1",
+		TraceParent: traceParent,
+		Payload:     []byte(`{"type":"user.created"}`),
+	}
+
+	failed, err := relay.write(context.Background(), []kafka.Message{outboxMessage(event)})
+	if err != nil || failed[0] != nil {
+		t.Fatalf("write() = %v, %v", failed, err)
+	}
+	msg := recorder.AssertPublished(t, events.UserCreated)
+	if string(msg.Key) != "user-1" || string(msg.Value) != `{"type":"user.created"}` {
+		t.Errorf("Unexpected message %q: %q", msg.Key, msg.Value)
+	}
+	for header, expected := range map[string]string{
+		kafka_client.HeaderEventID:        "event-1",
+		kafka_client.HeaderEventType:      events.UserCreated,
+		kafka_client.HeaderIdempotencyKey: "event-1",
+		kafka_client.HeaderTraceParent:    traceParent,
+	} {
+		if got := kafka_client.HeaderValue(msg, header); got != expected {
+			t.Errorf("Header %s = %q, expected %q", header, got, expected)
+		}
+	}
+	if topic := newTestProducer(t).Topic(kafka_client.HeaderValue(msg, kafka_client.HeaderEventType)); topic != "scs-user.events" {
+		t.Errorf("Expected user.created to be routed to the default topic, got %q", topic)
+	}
+}
+
+func TestOutboxRelayRoutesSnapshotsToStateTopic(t *testing.T) {
+	recorder := kafka_client.NewRecorder()
+	relay := &OutboxRelay{producer: recorder}
+	snapshot := &models.OutboxEvent{Key: "user-1", Type: events.UserSnapshot, EventID: "event-1", Payload: []byte(`{"type":"user.snapshot"}`)}
+	tombstone := &models.OutboxEvent{Key: "user-1", Type: events.UserSnapshot, EventID: "event-2"}
+
+	failed, err := relay.write(context.Background(), []kafka.Message{outboxMessage(snapshot), outboxMessage(tombstone)})
+	if err != nil || failed[0] != nil || failed[1] != nil {
+		t.Fatalf("write() = %v, %v", failed, err)
+	}
+	producer := newTestProducer(t)
+	messages := recorder.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	for _, msg := range messages {
+		if topic := producer.Topic(kafka_client.HeaderValue(msg, kafka_client.HeaderEventType)); topic != "users.state" {
+			t.Errorf("Expected the snapshot %s to be routed to users.state, got %q", kafka_client.HeaderValue(msg, kafka_client.HeaderEventID), topic)
+		}
+	}
+	// The tombstone has no value, so that compaction removes the user
+	if messages[1].Value != nil {
+		t.Errorf("Expected a nil tombstone value, got %q", messages[1].Value)
+	}
+}
+
+func TestOutboxRelayEncodesCataloguedEvents(t *testing.T) {
+	ctx := context.Background()
+	fake := kafka_client.NewFakeSchemaRegistry()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	cfg := newTestKafkaConfig(t)
+	cfg.ValueFormat = ValueFormatJSONSchema
+	cfg.SchemaRegistryURL = server.URL
+	encoder, err := NewEventEncoder(&cfg)
+	if err != nil {
+		t.Fatalf("NewEventEncoder() error = %v", err)
+	}
+	recorder := kafka_client.NewRecorder()
+	relay := &OutboxRelay{producer: recorder, encoder: encoder}
+	round := []*models.OutboxEvent{
+		{Key: "user-1", Type: events.UserCreated, EventID: "event-1", Payload: []byte(`{"type":"user.created"}`)},
+		{Key: "group-1", Type: "group.members_added", EventID: "event-2", Payload: []byte(`{"type":"group.members_added"}`)},
+		{Key: "user-2", Type: events.UserSnapshot, EventID: "event-3"},
+	}
+
+	failed, err := relay.send(ctx, round)
+	if err != nil || failed[0] != nil || failed[1] != nil || failed[2] != nil {
+		t.Fatalf("send() = %v, %v", failed, err)
+	}
+	messages := recorder.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	if value := messages[0].Value; len(value) < 5 || value[0] != 0 || string(value[5:]) != `{"type":"user.created"}` {
+		t.Errorf("Expected user.created in the wire format, got %q", value)
+	}
+	if ids := fake.Subjects()["scs-user.events-user.created"]; len(ids) != 1 {
+		t.Errorf("Expected the user.created schema to be registered for its type, got %v", fake.Subjects())
+	}
+	// Uncatalogued events have no schema, tombstones no value
+	if string(messages[1].Value) != `{"type":"group.members_added"}` || messages[2].Value != nil {
+		t.Errorf("Expected the uncatalogued event and the tombstone as they are, got %q and %q", messages[1].Value, messages[2].Value)
+	}
+
+	// Events are retried while the registry is unavailable
+	server.Close()
+	encoder, _ = NewEventEncoder(&cfg)
+	relay.encoder = encoder
+	failed, err = relay.send(ctx, round)
+	if err != nil || failed[0] == nil || failed[1] != nil {
+		t.Errorf("Expected only the catalogued event to fail, got %v, %v", failed, err)
+	}
+}
+
+func TestNewEventEncoderRejectsUnsupportedFormats(t *testing.T) {
+	cfg := newTestKafkaConfig(t)
+	for format, expected := range map[string]string{"avro": "unsupported KAFKA_VALUE_FORMAT", ValueFormatJSONSchema: "KAFKA_SCHEMA_REGISTRY_URL is required"} {
+		cfg.ValueFormat = format
+		if _, err := NewEventEncoder(&cfg); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("NewEventEncoder(%s) error = %v, expected %q", format, err, expected)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/events"
+	kafka_client "scs-user/pkg/kafka"
+	"scs-user/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// PremiseReplicaService applies the premise events of the premise service to the local premises,
+// which are then used to validate premise IDs and to resolve the premise hierarchy.
+type PremiseReplicaService struct {
+	uow    repositories.UnitOfWork
+	logger logger.Logger
+}
+
+func NewPremiseReplicaService(uow repositories.UnitOfWork, logger logger.Logger) *PremiseReplicaService {
+	return &PremiseReplicaService{uow: uow, logger: logger}
+}
+
+// RegisterHandlers registers the handlers of the premise events
+func (s *PremiseReplicaService) RegisterHandlers(registry *kafka_client.Registry) {
+	registry.Handle(events.PremiseCreated, s.HandlePremiseEvent)
+	registry.Handle(events.PremiseUpdated, s.HandlePremiseEvent)
+	registry.Handle(events.PremiseDeleted, s.HandlePremiseEvent)
+}
+
+// HandlePremiseEvent applies a premise event. Events already consumed and events older than the
+// replicated version of the premise are skipped, so redelivered and reordered events are harmless.
+func (s *PremiseReplicaService) HandlePremiseEvent(ctx context.Context, msg kafka.Message) error {
+	var event events.Event[events.UpstreamPremiseData]
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return kafka_client.Permanent(fmt.Errorf("failed to decode premise event: %w", err))
+	}
+	if event.Source == eventSource {
+		// Premises managed here are already up to date
+		return nil
+	}
+	if event.ID == "" {
+		return kafka_client.Permanent(fmt.Errorf("premise event has no ID"))
+	}
+	premiseID, err := uuid.Parse(event.Data.ID)
+	if err != nil {
+		return kafka_client.Permanent(fmt.Errorf("invalid premise ID %q: %w", event.Data.ID, err))
+	}
+	var parentID *uuid.UUID
+	if event.Data.ParentPremiseID != nil {
+		id, err := uuid.Parse(*event.Data.ParentPremiseID)
+		if err != nil {
+			return kafka_client.Permanent(fmt.Errorf("invalid parent premise ID %q: %w", *event.Data.ParentPremiseID, err))
+		}
+		parentID = &id
+	}
+	if event.Data.Geofence != nil {
+		if err := event.Data.Geofence.Validate(); err != nil {
+			return kafka_client.Permanent(fmt.Errorf("invalid geofence of premise %s: %w", premiseID, err))
+		}
+	}
+
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		isNew, err := repos.ConsumedEvents.MarkConsumed(ctx, event.ID, event.Type)
+		if err != nil || !isNew {
+			return err
+		}
+		version, err := repos.Premises.GetPremiseVersion(ctx, premiseID)
+		if err != nil {
+			return err
+		}
+		if version != nil && version.Version >= event.Data.Version {
+			s.logger.Infof("Skipping %s of premise %s, version %d is not newer than %d", event.Type, premiseID, event.Data.Version, version.Version)
+			return nil
+		}
+
+		deleted := event.Type == events.PremiseDeleted
+		if deleted {
+			err = s.deletePremise(ctx, repos, premiseID)
+		} else {
+			err = s.savePremise(ctx, repos, premiseID, parentID, event.Data)
+		}
+		if err != nil {
+			return err
+		}
+		return repos.Premises.SavePremiseVersion(ctx, &models.PremiseVersion{PremiseID: premiseID, Version: event.Data.Version, Deleted: deleted})
+	})
+}
+
+// savePremise creates the premise or updates it to the state of the event
+func (s *PremiseReplicaService) savePremise(ctx context.Context, repos *repositories.Repositories, id uuid.UUID, parentID *uuid.UUID, data events.UpstreamPremiseData) error {
+	if parentID != nil {
+		exists, err := repos.Premises.PremiseExists(ctx, parentID.String())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			// The parent is an event of another key that may not have been consumed yet, retry
+			return fmt.Errorf("parent premise %s of premise %s is not replicated yet", parentID, id)
+		}
+	}
+
+	premise, err := repos.Premises.GetPremiseByID(ctx, id.String())
+	if err != nil {
+		return err
+	}
+	exists := premise != nil
+	if !exists {
+		premise = &models.Premise{}
+	}
+	premise.ID = id
+	premise.Name = data.Name
+	premise.Address = data.Address
+	premise.ParentPremiseID = parentID
+	premise.Latitude = data.Latitude
+	premise.Longitude = data.Longitude
+	premise.Geofence = data.Geofence
+	if exists {
+		return repos.Premises.UpdatePremise(ctx, premise)
+	}
+	return repos.Premises.CreatePremise(ctx, premise)
+}
+
+// deletePremise deletes the premise together with its user and group assignments and its shifts
+func (s *PremiseReplicaService) deletePremise(ctx context.Context, repos *repositories.Repositories, id uuid.UUID) error {
+	children, err := repos.Premises.CountChildren(ctx, id.String())
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		// The premise service moves or deletes the children first, retry until their events arrive
+		return fmt.Errorf("premise %s still has %d child premises", id, children)
+	}
+	// The assignments are deleted with the premise, the snapshots of their users change
+	userPremises, err := repos.UserPremises.GetByPremise(ctx, id.String(), nil)
+	if err != nil {
+		return err
+	}
+	if err := repos.Premises.DeletePremise(ctx, id.String()); err != nil {
+		return err
+	}
+	for _, userPremise := range userPremises {
+		if err := publishUserSnapshot(ctx, repos, userPremise.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/internal/types"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+	"scs-user/pkg/geo"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultNearbyRadiusMeters is used when a nearby query does not specify a radius
+const defaultNearbyRadiusMeters = 1000
+
+type PremiseService struct {
+	premiseRepo     repositories.PremiseRepository
+	userPremiseRepo repositories.UserPremiseRepository
+	uow             repositories.UnitOfWork
+}
+
+func NewPremiseService(premiseRepo repositories.PremiseRepository, userPremiseRepo repositories.UserPremiseRepository, uow repositories.UnitOfWork) *PremiseService {
+	return &PremiseService{premiseRepo: premiseRepo, userPremiseRepo: userPremiseRepo, uow: uow}
+}
+
+func (s *PremiseService) CreatePremise(ctx context.Context, createPremiseDto *dto.CreatePremiseDto) (*models.Premise, error) {
+	premise := &models.Premise{
+		Name:      createPremiseDto.Name,
+		Address:   createPremiseDto.Address,
+		Latitude:  createPremiseDto.Latitude,
+		Longitude: createPremiseDto.Longitude,
+		Geofence:  createPremiseDto.Geofence,
+	}
+	if createPremiseDto.ParentPremiseID != "" {
+		parentID, err := s.parsePremiseID(ctx, createPremiseDto.ParentPremiseID)
+		if err != nil {
+			return nil, err
+		}
+		premise.ParentPremiseID = &parentID
+	}
+
+	err := s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Premises.CreatePremise(ctx, premise); err != nil {
+			return errors.NewDatabaseError("create premise", err)
+		}
+		return s.publishPremiseEvent(ctx, repos.Outbox, events.PremiseCreated, premise)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return premise, nil
+}
+
+func (s *PremiseService) GetPremises(ctx context.Context, filter dto.PremiseFilter, page int, limit int) (*types.PaginateResponse[models.Premise], error) {
+	premises, err := s.premiseRepo.GetPremises(ctx, filter, page, limit)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premises", err)
+	}
+	total, err := s.premiseRepo.GetPremisesCount(ctx, filter)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premises count", err)
+	}
+	totalPages := int(total) / limit
+	if total%int64(limit) != 0 {
+		totalPages++
+	}
+	return &types.PaginateResponse[models.Premise]{
+		Pagination: types.Pagination{
+			TotalPages: totalPages,
+			Page:       page,
+			Limit:      limit,
+		},
+		Data: premises,
+	}, nil
+}
+
+func (s *PremiseService) GetPremiseByID(ctx context.Context, id string) (*models.Premise, error) {
+	return getPremise(ctx, &s.premiseRepo, id)
+}
+
+func (s *PremiseService) UpdatePremise(ctx context.Context, id string, updatePremiseDto *dto.UpdatePremiseDto) (*models.Premise, error) {
+	premise, err := s.GetPremiseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if updatePremiseDto.Name != nil {
+		premise.Name = *updatePremiseDto.Name
+	}
+	if updatePremiseDto.Address != nil {
+		premise.Address = *updatePremiseDto.Address
+	}
+	if updatePremiseDto.ClearLocation {
+		premise.Latitude, premise.Longitude = nil, nil
+	} else if updatePremiseDto.Latitude != nil {
+		premise.Latitude, premise.Longitude = updatePremiseDto.Latitude, updatePremiseDto.Longitude
+	}
+	if updatePremiseDto.ClearGeofence {
+		premise.Geofence = nil
+	} else if updatePremiseDto.Geofence != nil {
+		premise.Geofence = updatePremiseDto.Geofence
+	}
+
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Premises.UpdatePremise(ctx, premise); err != nil {
+			return errors.NewDatabaseError("update premise", err)
+		}
+		return s.publishPremiseEvent(ctx, repos.Outbox, events.PremiseUpdated, premise)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return premise, nil
+}
+
+// DeletePremise deletes a premise that has neither children nor assigned users
+func (s *PremiseService) DeletePremise(ctx context.Context, id string) error {
+	premise, err := s.GetPremiseByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	children, err := s.premiseRepo.CountChildren(ctx, id)
+	if err != nil {
+		return errors.NewDatabaseError("count child premises", err)
+	}
+	if children > 0 {
+		return errors.NewConflictError("Premise has child premises, move or delete them first")
+	}
+	users, err := s.userPremiseRepo.CountByPremise(ctx, id)
+	if err != nil {
+		return errors.NewDatabaseError("count premise users", err)
+	}
+	if users > 0 {
+		return errors.NewConflictError("Premise has assigned users, unassign them first")
+	}
+
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Premises.DeletePremise(ctx, id); err != nil {
+			return errors.NewDatabaseError("delete premise", err)
+		}
+		return s.publishPremiseEvent(ctx, repos.Outbox, events.PremiseDeleted, premise)
+	})
+}
+
+func (s *PremiseService) GetChildren(ctx context.Context, id string) ([]models.Premise, error) {
+	if _, err := s.GetPremiseByID(ctx, id); err != nil {
+		return nil, err
+	}
+	children, err := s.premiseRepo.GetChildren(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get child premises", err)
+	}
+	return children, nil
+}
+
+func (s *PremiseService) GetAncestors(ctx context.Context, id string) ([]models.Premise, error) {
+	if _, err := s.GetPremiseByID(ctx, id); err != nil {
+		return nil, err
+	}
+	ancestors, err := s.premiseRepo.GetAncestors(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premise ancestors", err)
+	}
+	return ancestors, nil
+}
+
+// GetTree returns the premise with all of its descendants nested
+func (s *PremiseService) GetTree(ctx context.Context, id string) (*dto.PremiseTreeNode, error) {
+	premises, err := s.premiseRepo.GetSubtree(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premise subtree", err)
+	}
+	if len(premises) == 0 {
+		return nil, errors.NewNotFoundError("premise")
+	}
+	return buildPremiseTree(premises), nil
+}
+
+// MovePremise moves the premise with its subtree under a new parent, or to the root when the parent is empty.
+// The premise and the new parent stay locked from the cycle check to the update, so that concurrent
+// moves can not create a cycle together.
+func (s *PremiseService) MovePremise(ctx context.Context, id string, movePremiseDto *dto.MovePremiseDto) (*models.Premise, error) {
+	premiseID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.NewBadRequestError("Invalid premise id")
+	}
+	ids := []uuid.UUID{premiseID}
+	var parentID *uuid.UUID
+	if movePremiseDto.ParentPremiseID != "" {
+		newParentID, err := uuid.Parse(movePremiseDto.ParentPremiseID)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid premise id")
+		}
+		parentID = &newParentID
+		ids = append(ids, newParentID)
+	}
+
+	var premise *models.Premise
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		locked, err := repos.Premises.LockPremises(ctx, ids)
+		if err != nil {
+			return errors.NewDatabaseError("lock premises", err)
+		}
+		parentFound := false
+		for i := range locked {
+			if locked[i].ID == premiseID {
+				premise = &locked[i]
+			} else {
+				parentFound = true
+			}
+		}
+		if premise == nil {
+			return errors.NewNotFoundError("premise")
+		}
+		if parentID != nil {
+			if !parentFound && *parentID != premiseID {
+				return errors.NewNotFoundError("parent premise")
+			}
+			// Moving a premise below itself or one of its descendants would create a cycle
+			cycle, err := repos.Premises.IsInSubtree(ctx, id, parentID.String())
+			if err != nil {
+				return errors.NewDatabaseError("check premise subtree", err)
+			}
+			if cycle {
+				return errors.NewBadRequestError("Premise can not be moved below itself or one of its descendants")
+			}
+		}
+
+		premise.ParentPremiseID = parentID
+		if err := repos.Premises.UpdatePremise(ctx, premise); err != nil {
+			return errors.NewDatabaseError("move premise", err)
+		}
+		return s.publishPremiseEvent(ctx, repos.Outbox, events.PremiseMoved, premise)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return premise, nil
+}
+
+// GetPremisesContaining returns the premises whose geofence contains the point, the
+// smallest first so that the most specific premise (e.g. a building inside a campus) leads
+func (s *PremiseService) GetPremisesContaining(ctx context.Context, point geo.Point) ([]models.Premise, error) {
+	candidates, err := s.premiseRepo.GetPremisesWithGeofenceAt(ctx, point)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premises by geofence", err)
+	}
+	premises := make([]models.Premise, 0, len(candidates))
+	for _, premise := range candidates {
+		if premise.Geofence.Contains(point) {
+			premises = append(premises, premise)
+		}
+	}
+	sort.SliceStable(premises, func(i, j int) bool {
+		return boundingBoxArea(premises[i].Geofence.BoundingBox()) < boundingBoxArea(premises[j].Geofence.BoundingBox())
+	})
+	return premises, nil
+}
+
+// GetNearbyPremises returns the premises located within the radius of the point, nearest first
+func (s *PremiseService) GetNearbyPremises(ctx context.Context, point geo.Point, radiusMeters float64) ([]dto.NearbyPremise, error) {
+	if radiusMeters == 0 {
+		radiusMeters = defaultNearbyRadiusMeters
+	}
+	candidates, err := s.premiseRepo.GetPremisesInBoundingBox(ctx, geo.BoundingBoxAround(point, radiusMeters))
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premises by location", err)
+	}
+	premises := make([]dto.NearbyPremise, 0, len(candidates))
+	for _, premise := range candidates {
+		distance := geo.DistanceMeters(point, *premise.Location())
+		if distance <= radiusMeters {
+			premises = append(premises, dto.NearbyPremise{Premise: premise, DistanceMeters: distance})
+		}
+	}
+	sort.SliceStable(premises, func(i, j int) bool {
+		return premises[i].DistanceMeters < premises[j].DistanceMeters
+	})
+	return premises, nil
+}
+
+// GetNearbyGuards returns the active guards currently assigned to premises within the radius of the point, nearest first
+func (s *PremiseService) GetNearbyGuards(ctx context.Context, point geo.Point, radiusMeters float64) ([]dto.NearbyGuard, error) {
+	premises, err := s.GetNearbyPremises(ctx, point, radiusMeters)
+	if err != nil {
+		return nil, err
+	}
+	nearby := make(map[uuid.UUID]dto.NearbyPremise, len(premises))
+	premiseIDs := make([]uuid.UUID, 0, len(premises))
+	for _, premise := range premises {
+		nearby[premise.ID] = premise
+		premiseIDs = append(premiseIDs, premise.ID)
+	}
+
+	now := time.Now()
+	assignments, err := s.userPremiseRepo.GetByPremises(ctx, premiseIDs, "guard", &now)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premise guards", err)
+	}
+	guards := make(map[uuid.UUID]dto.NearbyGuard, len(assignments))
+	for _, assignment := range assignments {
+		premise := nearby[assignment.PremiseID]
+		if guard, ok := guards[assignment.UserID]; ok && guard.DistanceMeters <= premise.DistanceMeters {
+			continue
+		}
+		guards[assignment.UserID] = dto.NearbyGuard{
+			User:           *assignment.User,
+			PremiseID:      premise.ID.String(),
+			PremiseName:    premise.Name,
+			DistanceMeters: premise.DistanceMeters,
+		}
+	}
+	result := make([]dto.NearbyGuard, 0, len(guards))
+	for _, guard := range guards {
+		result = append(result, guard)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DistanceMeters != result[j].DistanceMeters {
+			return result[i].DistanceMeters < result[j].DistanceMeters
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// getPremise returns the premise with the ID, a not found error if it does not exist
+func getPremise(ctx context.Context, premiseRepo *repositories.PremiseRepository, id string) (*models.Premise, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequestError("Invalid premise id")
+	}
+	premise, err := premiseRepo.GetPremiseByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premise", err)
+	}
+	if premise == nil {
+		return nil, errors.NewNotFoundError("premise")
+	}
+	return premise, nil
+}
+
+// parsePremiseID parses the ID and checks that the premise exists
+func (s *PremiseService) parsePremiseID(ctx context.Context, id string) (uuid.UUID, error) {
+	premiseID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, errors.NewBadRequestError("Invalid premise id")
+	}
+	exists, err := s.premiseRepo.PremiseExists(ctx, id)
+	if err != nil {
+		return uuid.Nil, errors.NewDatabaseError("get premise", err)
+	}
+	if !exists {
+		return uuid.Nil, errors.NewNotFoundError("parent premise")
+	}
+	return premiseID, nil
+}
+
+func (s *PremiseService) publishPremiseEvent(ctx context.Context, outbox *repositories.OutboxRepository, eventType string, premise *models.Premise) error {
+	data := events.PremiseData{
+		ID:        premise.ID.String(),
+		Name:      premise.Name,
+		Address:   premise.Address,
+		Latitude:  premise.Latitude,
+		Longitude: premise.Longitude,
+	}
+	if premise.ParentPremiseID != nil {
+		parentID := premise.ParentPremiseID.String()
+		data.ParentPremiseID = &parentID
+	}
+	if premise.Geofence != nil {
+		data.Geofence = &events.GeoJSONPolygon{Type: "Polygon", Coordinates: make([][][2]float64, len(premise.Geofence.Rings))}
+		for i, ring := range premise.Geofence.Rings {
+			data.Geofence.Coordinates[i] = make([][2]float64, len(ring))
+			for j, point := range ring {
+				data.Geofence.Coordinates[i][j] = [2]float64{point.Lng, point.Lat}
+			}
+		}
+	}
+	return publishEvent(ctx, outbox, premise.ID.String(), eventType, data)
+}
+
+// buildPremiseTree nests a subtree ordered by depth, the first premise being the root
+func buildPremiseTree(premises []models.Premise) *dto.PremiseTreeNode {
+	nodes := make(map[uuid.UUID]*dto.PremiseTreeNode, len(premises))
+	root := &dto.PremiseTreeNode{Premise: premises[0], Children: []*dto.PremiseTreeNode{}}
+	nodes[root.ID] = root
+	for _, premise := range premises[1:] {
+		node := &dto.PremiseTreeNode{Premise: premise, Children: []*dto.PremiseTreeNode{}}
+		nodes[premise.ID] = node
+		if premise.ParentPremiseID == nil {
+			continue
+		}
+		if parent, ok := nodes[*premise.ParentPremiseID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return root
+}
+
+func boundingBoxArea(box geo.BoundingBox) float64 {
+	return (box.MaxLat - box.MinLat) * (box.MaxLng - box.MinLng)
+}
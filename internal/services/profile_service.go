@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	config "scs-user/config"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/storage"
+	"scs-user/pkg/utils"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const avatarKeyPrefix = "avatars"
+
+type ProfileService struct {
+	cfg         *config.Config
+	userRepo    repositories.UserRepository
+	profileRepo repositories.UserProfileRepository
+	blobStore   storage.BlobStore
+}
+
+func NewProfileService(cfg *config.Config, userRepo repositories.UserRepository, profileRepo repositories.UserProfileRepository, blobStore storage.BlobStore) *ProfileService {
+	return &ProfileService{cfg: cfg, userRepo: userRepo, profileRepo: profileRepo, blobStore: blobStore}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	profile, err := s.getOrNewProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setAvatarURLs(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID string, updateProfileDto *dto.UpdateProfileDto) (*models.UserProfile, error) {
+	profile, err := s.getOrNewProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if updateProfileDto.Phone != nil {
+		profile.Phone = *updateProfileDto.Phone
+	}
+	if updateProfileDto.EmployeeNumber != nil {
+		if *updateProfileDto.EmployeeNumber == "" {
+			profile.EmployeeNumber = nil
+		} else {
+			profile.EmployeeNumber = updateProfileDto.EmployeeNumber
+		}
+	}
+	if updateProfileDto.Locale != nil {
+		profile.Locale = *updateProfileDto.Locale
+	}
+	if updateProfileDto.TimeZone != nil {
+		profile.TimeZone = *updateProfileDto.TimeZone
+	}
+	if updateProfileDto.EmergencyContactName != nil {
+		profile.EmergencyContactName = *updateProfileDto.EmergencyContactName
+	}
+	if updateProfileDto.EmergencyContactPhone != nil {
+		profile.EmergencyContactPhone = *updateProfileDto.EmergencyContactPhone
+	}
+	if updateProfileDto.EmergencyContactRelation != nil {
+		profile.EmergencyContactRelation = *updateProfileDto.EmergencyContactRelation
+	}
+
+	if err := s.profileRepo.SaveProfile(ctx, profile); err != nil {
+		if isDuplicateKeyError(err, "employee_number") {
+			return nil, errors.NewConflictError("Employee number is already in use")
+		}
+		return nil, errors.NewDatabaseError("save user profile", err)
+	}
+	if err := s.setAvatarURLs(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// UploadAvatar stores the original image and its thumbnails and replaces the previous avatar
+func (s *ProfileService) UploadAvatar(ctx context.Context, userID string, file *multipart.FileHeader) (*models.UserProfile, error) {
+	if err := utils.ValidateImageFile(file, s.cfg.Upload.MaxAvatarSize); err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+	profile, err := s.getOrNewProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to open avatar", err)
+	}
+	defer src.Close()
+	content, err := io.ReadAll(io.LimitReader(src, s.cfg.Upload.MaxAvatarSize+1))
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to read avatar", err)
+	}
+	img, format, err := utils.DecodeImage(content, s.cfg.Upload.MaxAvatarPixels)
+	if stderrors.Is(err, utils.ErrImageTooLarge) {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("avatar must not have more than %d pixels", s.cfg.Upload.MaxAvatarPixels))
+	}
+	if err != nil {
+		return nil, errors.NewBadRequestError("avatar is not a valid image")
+	}
+
+	// Every upload gets a new prefix so cached URLs of the old avatar never serve the new one
+	prefix := fmt.Sprintf("%s/%s/%s", avatarKeyPrefix, profile.UserID, uuid.New())
+	originalKey := prefix + "/original" + utils.ImageExtension(utils.MimeTypeFromFormat(format))
+	if err := s.blobStore.Put(ctx, originalKey, bytes.NewReader(content), int64(len(content)), utils.MimeTypeFromFormat(format)); err != nil {
+		return nil, errors.NewInternalError("Failed to store avatar", err)
+	}
+	storedKeys := []string{originalKey}
+
+	thumbnailKeys := make(map[string]string, len(s.cfg.Storage.ThumbnailSizes))
+	for _, size := range s.cfg.Storage.ThumbnailSizes {
+		var buf bytes.Buffer
+		contentType, err := utils.EncodeImage(&buf, utils.Thumbnail(img, size), format)
+		if err != nil {
+			s.deleteObjects(ctx, storedKeys)
+			return nil, errors.NewInternalError("Failed to create avatar thumbnail", err)
+		}
+		key := fmt.Sprintf("%s/thumb_%d%s", prefix, size, utils.ImageExtension(contentType))
+		if err := s.blobStore.Put(ctx, key, &buf, int64(buf.Len()), contentType); err != nil {
+			s.deleteObjects(ctx, storedKeys)
+			return nil, errors.NewInternalError("Failed to store avatar thumbnail", err)
+		}
+		storedKeys = append(storedKeys, key)
+		thumbnailKeys[strconv.Itoa(size)] = key
+	}
+
+	oldKeys := avatarKeys(profile)
+	profile.AvatarKey = originalKey
+	profile.AvatarThumbnailKeys = thumbnailKeys
+	if err := s.profileRepo.SaveProfile(ctx, profile); err != nil {
+		s.deleteObjects(ctx, storedKeys)
+		return nil, errors.NewDatabaseError("save user profile", err)
+	}
+	// The old avatar is no longer referenced, a failed delete only leaves an orphaned object
+	s.deleteObjects(ctx, oldKeys)
+
+	if err := s.setAvatarURLs(ctx, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// GetAvatarURL returns a signed download URL of the user's avatar. An empty size returns the original image.
+func (s *ProfileService) GetAvatarURL(ctx context.Context, userID string, size string) (string, error) {
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return "", errors.NewDatabaseError("get user profile", err)
+	}
+	if profile == nil || profile.AvatarKey == "" {
+		return "", errors.NewNotFoundError("avatar")
+	}
+	key := profile.AvatarKey
+	if size != "" {
+		thumbnailKey, ok := profile.AvatarThumbnailKeys[size]
+		if !ok {
+			return "", errors.NewNotFoundError("avatar thumbnail")
+		}
+		key = thumbnailKey
+	}
+	url, err := s.blobStore.SignedURL(ctx, key, s.cfg.Storage.SignedURLTTL)
+	if err != nil {
+		return "", errors.NewInternalError("Failed to sign avatar URL", err)
+	}
+	return url, nil
+}
+
+// getOrNewProfile returns the stored profile or a new, unsaved one for users without a profile
+func (s *ProfileService) getOrNewProfile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user profile", err)
+	}
+	if profile == nil {
+		profile = &models.UserProfile{UserID: user.ID}
+	}
+	return profile, nil
+}
+
+// setAvatarURLs fills the signed avatar URLs of the profile
+func (s *ProfileService) setAvatarURLs(ctx context.Context, profile *models.UserProfile) error {
+	if profile.AvatarKey == "" {
+		return nil
+	}
+	url, err := s.blobStore.SignedURL(ctx, profile.AvatarKey, s.cfg.Storage.SignedURLTTL)
+	if err != nil {
+		return errors.NewInternalError("Failed to sign avatar URL", err)
+	}
+	profile.AvatarURL = url
+	profile.AvatarThumbnailURLs = make(map[string]string, len(profile.AvatarThumbnailKeys))
+	for size, key := range profile.AvatarThumbnailKeys {
+		url, err := s.blobStore.SignedURL(ctx, key, s.cfg.Storage.SignedURLTTL)
+		if err != nil {
+			return errors.NewInternalError("Failed to sign avatar URL", err)
+		}
+		profile.AvatarThumbnailURLs[size] = url
+	}
+	return nil
+}
+
+func (s *ProfileService) deleteObjects(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		s.blobStore.Delete(ctx, key)
+	}
+}
+
+// avatarKeys returns the keys of all stored avatar objects of the profile
+func avatarKeys(profile *models.UserProfile) []string {
+	if profile.AvatarKey == "" {
+		return nil
+	}
+	keys := []string{profile.AvatarKey}
+	for _, key := range profile.AvatarThumbnailKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	config "scs-user/config"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/recurrence"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxShiftDuration is the longest a single shift occurrence may last
+	maxShiftDuration = 24 * time.Hour
+	// defaultShiftWindow and maxShiftWindow bound the listing of shift occurrences
+	defaultShiftWindow = 7 * 24 * time.Hour
+	maxShiftWindow     = 31 * 24 * time.Hour
+)
+
+// ShiftService schedules shifts of users at their premises and tracks who is on duty
+type ShiftService struct {
+	cfg               *config.Config
+	userRepo          repositories.UserRepository
+	premiseRepo       repositories.PremiseRepository
+	userPremiseRepo   repositories.UserPremiseRepository
+	groupRepo         repositories.GroupRepository
+	shiftRepo         repositories.ShiftRepository
+	certificationRepo repositories.CertificationRepository
+	uow               repositories.UnitOfWork
+}
+
+func NewShiftService(cfg *config.Config, userRepo repositories.UserRepository, premiseRepo repositories.PremiseRepository, userPremiseRepo repositories.UserPremiseRepository, groupRepo repositories.GroupRepository, shiftRepo repositories.ShiftRepository, certificationRepo repositories.CertificationRepository, uow repositories.UnitOfWork) *ShiftService {
+	return &ShiftService{
+		cfg:               cfg,
+		userRepo:          userRepo,
+		premiseRepo:       premiseRepo,
+		userPremiseRepo:   userPremiseRepo,
+		groupRepo:         groupRepo,
+		shiftRepo:         shiftRepo,
+		certificationRepo: certificationRepo,
+		uow:               uow,
+	}
+}
+
+// CreateShift schedules a shift of a user at a premise the user is assigned to. Shifts of the
+// same user must not overlap.
+func (s *ShiftService) CreateShift(ctx context.Context, createdByID string, createShiftDto *dto.CreateShiftDto) (*models.Shift, error) {
+	user, err := s.userRepo.GetUserByID(ctx, createShiftDto.UserID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	premise, err := getPremise(ctx, &s.premiseRepo, createShiftDto.PremiseID)
+	if err != nil {
+		return nil, err
+	}
+	if createShiftDto.EndsAt.Sub(createShiftDto.StartsAt) > maxShiftDuration {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("A shift can last at most %s", maxShiftDuration))
+	}
+
+	shift := &models.Shift{
+		UserID:    user.ID,
+		PremiseID: premise.ID,
+		StartsAt:  createShiftDto.StartsAt.UTC(),
+		EndsAt:    createShiftDto.EndsAt.UTC(),
+		Notes:     createShiftDto.Notes,
+	}
+	if createShiftDto.Recurrence != "" {
+		rule, err := recurrence.Parse(createShiftDto.Recurrence)
+		if err != nil {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("Invalid recurrence: %s", err))
+		}
+		shift.Recurrence = rule.String()
+	}
+	if err := shift.SetScheduleEnd(); err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("Invalid recurrence: %s", err))
+	}
+	if createdBy, err := uuid.Parse(createdByID); err == nil {
+		shift.CreatedByID = &createdBy
+	}
+
+	assigned, err := isAssignedToPremise(ctx, s.userPremiseRepo, s.groupRepo, user.ID.String(), premise.ID.String(), shift.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	if !assigned {
+		return nil, errors.NewBadRequestError("User is not assigned to this premise at the start of the shift")
+	}
+
+	// The user stays locked from the overlap check to the insert, so that concurrent requests can
+	// not schedule overlapping shifts
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		locked, err := repos.Users.LockUser(ctx, user.ID.String())
+		if err != nil {
+			return errors.NewDatabaseError("lock user", err)
+		}
+		if locked == nil {
+			return errors.NewNotFoundError("user")
+		}
+		if err := s.checkOverlap(ctx, repos.Shifts, shift); err != nil {
+			return err
+		}
+		if err := repos.Shifts.CreateShift(ctx, shift); err != nil {
+			return errors.NewDatabaseError("create shift", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+func (s *ShiftService) GetShift(ctx context.Context, id string) (*models.Shift, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequestError("Invalid shift id")
+	}
+	shift, err := s.shiftRepo.GetShiftByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get shift", err)
+	}
+	if shift == nil {
+		return nil, errors.NewNotFoundError("shift")
+	}
+	return shift, nil
+}
+
+// DeleteShift deletes a shift nobody is currently clocked in to
+func (s *ShiftService) DeleteShift(ctx context.Context, id string) error {
+	if _, err := s.GetShift(ctx, id); err != nil {
+		return err
+	}
+	open, err := s.shiftRepo.CountOpenAttendances(ctx, id)
+	if err != nil {
+		return errors.NewDatabaseError("count shift attendances", err)
+	}
+	if open > 0 {
+		return errors.NewConflictError("A user is clocked in to this shift, clock out first")
+	}
+	if err := s.shiftRepo.DeleteShift(ctx, id); err != nil {
+		return errors.NewDatabaseError("delete shift", err)
+	}
+	return nil
+}
+
+// GetShiftOccurrences expands the matching shifts into their occurrences within the filter window
+func (s *ShiftService) GetShiftOccurrences(ctx context.Context, filter dto.ShiftFilter) ([]models.ShiftOccurrence, error) {
+	from := time.Now()
+	if filter.From != nil {
+		from = *filter.From
+	}
+	to := from.Add(defaultShiftWindow)
+	if filter.To != nil {
+		to = *filter.To
+	}
+	if !to.After(from) {
+		return nil, errors.NewBadRequestError("to must be after from")
+	}
+	if to.Sub(from) > maxShiftWindow {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("The time window can span at most %s", maxShiftWindow))
+	}
+
+	shifts, err := s.shiftRepo.GetShiftsInWindow(ctx, filter.UserID, filter.PremiseID, from, to)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get shifts", err)
+	}
+	occurrences := []models.ShiftOccurrence{}
+	for i := range shifts {
+		occurrences = append(occurrences, shifts[i].Occurrences(from, to)...)
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].StartsAt.Before(occurrences[j].StartsAt)
+	})
+	return occurrences, nil
+}
+
+// ClockIn starts the current occurrence of the user's shift. Users can clock in from
+// the configured window before the start until the end of the occurrence, and only
+// with valid certifications of the types required for clock-in.
+func (s *ShiftService) ClockIn(ctx context.Context, userID string, shiftID string) (*models.ShiftAttendance, error) {
+	shift, err := s.getUserShift(ctx, userID, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	if !user.IsActive {
+		return nil, errors.NewForbiddenError("User is not active")
+	}
+
+	now := time.Now()
+	occurrences := shift.Occurrences(now, now.Add(s.cfg.Shift.ClockInWindow))
+	if len(occurrences) == 0 {
+		return nil, errors.NewBadRequestError("The shift is not scheduled now")
+	}
+	occurrence := occurrences[0]
+	if err := checkRequiredCertifications(ctx, s.certificationRepo, s.cfg.Certification.RequiredForClockIn, userID, now); err != nil {
+		return nil, err
+	}
+
+	assigned, err := isAssignedToPremise(ctx, s.userPremiseRepo, s.groupRepo, userID, shift.PremiseID.String(), now)
+	if err != nil {
+		return nil, err
+	}
+	if !assigned {
+		return nil, errors.NewForbiddenError("User is no longer assigned to the premise of this shift")
+	}
+
+	open, err := s.shiftRepo.GetOpenAttendance(ctx, userID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get shift attendance", err)
+	}
+	if open != nil {
+		return nil, errors.NewConflictError("User is already clocked in")
+	}
+
+	attendance := &models.ShiftAttendance{
+		ShiftID:        shift.ID,
+		ScheduledStart: occurrence.StartsAt,
+		ScheduledEnd:   occurrence.EndsAt,
+		UserID:         shift.UserID,
+		PremiseID:      shift.PremiseID,
+		ClockInAt:      now,
+	}
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.Shifts.CreateAttendance(ctx, attendance); err != nil {
+			if isDuplicateKeyError(err, "idx_shift_attendances") {
+				return errors.NewConflictError("User is already clocked in or this shift has already been worked")
+			}
+			return errors.NewDatabaseError("create shift attendance", err)
+		}
+		return s.publishShiftEvent(ctx, repos.Outbox, "shift.started", attendance)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// ClockOut ends the user's attendance of the shift
+func (s *ShiftService) ClockOut(ctx context.Context, userID string, shiftID string) (*models.ShiftAttendance, error) {
+	if _, err := s.getUserShift(ctx, userID, shiftID); err != nil {
+		return nil, err
+	}
+	attendance, err := s.shiftRepo.GetOpenAttendance(ctx, userID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get shift attendance", err)
+	}
+	if attendance == nil || attendance.ShiftID.String() != shiftID {
+		return nil, errors.NewConflictError("User is not clocked in to this shift")
+	}
+
+	now := time.Now()
+	attendance.ClockOutAt = &now
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		// Only the request that closes the attendance publishes its end
+		closed, err := repos.Shifts.CloseAttendance(ctx, attendance.ID.String(), now)
+		if err != nil {
+			return errors.NewDatabaseError("update shift attendance", err)
+		}
+		if !closed {
+			return errors.NewConflictError("User is not clocked in to this shift")
+		}
+		return s.publishShiftEvent(ctx, repos.Outbox, "shift.ended", attendance)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// GetOnDuty returns the attendances of the active users currently clocked in at the premise. Users who
+// have not clocked out within the configured grace period after the end of their shift are not on duty.
+func (s *ShiftService) GetOnDuty(ctx context.Context, premiseID string) ([]models.ShiftAttendance, error) {
+	if _, err := getPremise(ctx, &s.premiseRepo, premiseID); err != nil {
+		return nil, err
+	}
+	attendances, err := s.shiftRepo.GetOnDutyAtPremise(ctx, premiseID, time.Now().Add(-s.cfg.Shift.ClockOutGrace))
+	if err != nil {
+		return nil, errors.NewDatabaseError("get on-duty users", err)
+	}
+	return attendances, nil
+}
+
+// getUserShift returns the shift if it belongs to the user
+func (s *ShiftService) getUserShift(ctx context.Context, userID string, shiftID string) (*models.Shift, error) {
+	shift, err := s.GetShift(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.UserID.String() != userID {
+		return nil, errors.NewNotFoundError("shift")
+	}
+	return shift, nil
+}
+
+// checkOverlap rejects the shift if any of its occurrences overlaps another shift of the same user.
+// Open-ended schedules are only compared up to the configured horizon. The caller holds the lock of the user.
+func (s *ShiftService) checkOverlap(ctx context.Context, shifts *repositories.ShiftRepository, shift *models.Shift) error {
+	from := shift.StartsAt
+	to := from.Add(s.cfg.Shift.OverlapHorizon)
+	if shift.ScheduleEndsAt != nil && shift.ScheduleEndsAt.Before(to) {
+		to = *shift.ScheduleEndsAt
+	}
+
+	existing, err := shifts.GetShiftsInWindow(ctx, shift.UserID.String(), "", from, to)
+	if err != nil {
+		return errors.NewDatabaseError("get shifts", err)
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+	occurrences := shift.Occurrences(from, to)
+	for i := range existing {
+		for _, other := range existing[i].Occurrences(from, to) {
+			for _, occurrence := range occurrences {
+				if occurrence.Overlaps(other) {
+					return errors.NewConflictError(fmt.Sprintf("Shift overlaps shift %s from %s to %s",
+						other.ShiftID, other.StartsAt.Format(time.RFC3339), other.EndsAt.Format(time.RFC3339)))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *ShiftService) publishShiftEvent(ctx context.Context, outbox *repositories.OutboxRepository, eventType string, attendance *models.ShiftAttendance) error {
+	payload := map[string]interface{}{
+		"shift_id":        attendance.ShiftID.String(),
+		"attendance_id":   attendance.ID.String(),
+		"user_id":         attendance.UserID.String(),
+		"premise_id":      attendance.PremiseID.String(),
+		"scheduled_start": attendance.ScheduledStart,
+		"scheduled_end":   attendance.ScheduledEnd,
+		"clock_in_at":     attendance.ClockInAt,
+		"clock_out_at":    attendance.ClockOutAt,
+	}
+	return publishEvent(ctx, outbox, attendance.UserID.String(), eventType, payload)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	config "scs-user/config"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/utils"
+	"time"
+)
+
+// SigningKeyService rotates the keys that sign access tokens and loads them into the token signer
+type SigningKeyService struct {
+	cfg     *config.Config
+	keyRepo repositories.SigningKeyRepository
+	uow     repositories.UnitOfWork
+}
+
+func NewSigningKeyService(cfg *config.Config, keyRepo repositories.SigningKeyRepository, uow repositories.UnitOfWork) *SigningKeyService {
+	return &SigningKeyService{cfg: cfg, keyRepo: keyRepo, uow: uow}
+}
+
+// Rotate creates a new signing key and retires the previous ones. Replicas sign with the new key
+// once it is older than the refresh interval, tokens signed with the retired keys stay valid until
+// they expire.
+func (s *SigningKeyService) Rotate(ctx context.Context) (*models.SigningKey, error) {
+	secret, err := utils.GenerateRandomToken()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate signing key", err)
+	}
+	key := &models.SigningKey{Secret: secret}
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.SigningKeys.CreateKey(ctx, key); err != nil {
+			return errors.NewDatabaseError("create signing key", err)
+		}
+		now := time.Now()
+		if _, err := repos.SigningKeys.RetireKeys(ctx, key.ID.String(), now); err != nil {
+			return errors.NewDatabaseError("retire signing keys", err)
+		}
+		if _, err := repos.SigningKeys.DeleteRetired(ctx, now.Add(-s.retention())); err != nil {
+			return errors.NewDatabaseError("delete retired signing keys", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Load sets the keys tokens are signed and verified with. It is run periodically by the server
+// to pick up rotations; until the first key is old enough the built-in secret signs tokens.
+func (s *SigningKeyService) Load(ctx context.Context) error {
+	now := time.Now()
+	keys, err := s.keyRepo.GetKeys(ctx, now.Add(-s.retention()))
+	if err != nil {
+		return err
+	}
+	activeBefore := now.Add(-s.ActivationDelay())
+	var current *utils.SigningKey
+	others := make([]utils.SigningKey, 0, len(keys))
+	// The keys are ordered newest first, the first key old enough is the current one
+	for _, key := range keys {
+		signingKey := utils.SigningKey{ID: key.ID.String(), Secret: []byte(key.Secret)}
+		if current == nil && !key.CreatedAt.After(activeBefore) {
+			current = &signingKey
+			continue
+		}
+		others = append(others, signingKey)
+	}
+	utils.SetSigningKeys(current, others)
+	return nil
+}
+
+// retention is how long a retired key is kept. It may still sign tokens for one refresh interval
+// after it was retired, and those tokens stay valid for their lifetime.
+func (s *SigningKeyService) retention() time.Duration {
+	return utils.TokenTTL + s.cfg.Auth.SigningKeyRefreshInterval
+}
+
+// ActivationDelay is how long after its creation a new key starts signing tokens
+func (s *SigningKeyService) ActivationDelay() time.Duration {
+	return s.cfg.Auth.SigningKeyRefreshInterval
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"io"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/export"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const exportBatchSize = 500
+
+// userExportColumns are the columns of the user export. The password hash is never exported.
+var userExportColumns = []string{"id", "name", "email", "role", "status", "premises", "created_at", "updated_at"}
+
+// ExportUsers streams all users matching the filter to w in the given format
+func (s *UserService) ExportUsers(ctx context.Context, filter dto.UserFilter, format export.Format, w io.Writer) error {
+	writer, err := export.NewWriter(format, w, userExportColumns)
+	if err != nil {
+		return errors.NewInternalError("Failed to create export writer", err)
+	}
+
+	err = s.userRepo.StreamUsers(ctx, filter, exportBatchSize, func(users []models.User) error {
+		userIDs := make([]uuid.UUID, len(users))
+		for i, user := range users {
+			userIDs[i] = user.ID
+		}
+		premiseNames, err := s.userPremiseRepo.GetPremiseNamesByUserIDs(ctx, userIDs)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			premises := premiseNames[user.ID]
+			if premises == nil {
+				premises = []string{}
+			}
+			record := []interface{}{
+				user.ID.String(),
+				user.Name,
+				user.Email,
+				user.Role,
+				user.Status,
+				premises,
+				user.CreatedAt.UTC().Format(time.RFC3339),
+				user.UpdatedAt.UTC().Format(time.RFC3339),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.NewDatabaseError("export users", err)
+	}
+	if err := writer.Close(); err != nil {
+		return errors.NewInternalError("Failed to finalize export", err)
+	}
+	return nil
+}
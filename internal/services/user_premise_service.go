@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserPremiseService manages the assignments of users to premises
+type UserPremiseService struct {
+	userRepo        repositories.UserRepository
+	premiseRepo     repositories.PremiseRepository
+	userPremiseRepo repositories.UserPremiseRepository
+	uow             repositories.UnitOfWork
+}
+
+func NewUserPremiseService(userRepo repositories.UserRepository, premiseRepo repositories.PremiseRepository, userPremiseRepo repositories.UserPremiseRepository, uow repositories.UnitOfWork) *UserPremiseService {
+	return &UserPremiseService{userRepo: userRepo, premiseRepo: premiseRepo, userPremiseRepo: userPremiseRepo, uow: uow}
+}
+
+// GetUserPremises returns the premises of the user, only the currently valid ones if activeOnly is set
+func (s *UserPremiseService) GetUserPremises(ctx context.Context, userID string, activeOnly bool) ([]models.UserPremise, error) {
+	if _, err := s.userRepo.GetUserByID(ctx, userID); err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	userPremises, err := s.userPremiseRepo.GetByUser(ctx, userID, activeAt(activeOnly))
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user premises", err)
+	}
+	return userPremises, nil
+}
+
+// GetPremiseUsers returns the users of the premise, only the currently valid ones if activeOnly is set
+func (s *UserPremiseService) GetPremiseUsers(ctx context.Context, premiseID string, activeOnly bool) ([]models.UserPremise, error) {
+	if _, err := getPremise(ctx, &s.premiseRepo, premiseID); err != nil {
+		return nil, err
+	}
+	userPremises, err := s.userPremiseRepo.GetByPremise(ctx, premiseID, activeAt(activeOnly))
+	if err != nil {
+		return nil, errors.NewDatabaseError("get premise users", err)
+	}
+	return userPremises, nil
+}
+
+func (s *UserPremiseService) AssignPremise(ctx context.Context, userID string, assignPremiseDto *dto.AssignPremiseDto) (*models.UserPremise, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user")
+	}
+	premise, err := getPremise(ctx, &s.premiseRepo, assignPremiseDto.PremiseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateValidityPeriod(assignPremiseDto.StartsAt, assignPremiseDto.EndsAt); err != nil {
+		return nil, err
+	}
+
+	userPremise := &models.UserPremise{
+		UserID:    user.ID,
+		PremiseID: premise.ID,
+		Role:      assignPremiseDto.Role,
+		StartsAt:  assignPremiseDto.StartsAt,
+		EndsAt:    assignPremiseDto.EndsAt,
+	}
+	if userPremise.Role == "" {
+		userPremise.Role = models.PremiseRoleGuard
+	}
+	exists, err := s.userPremiseRepo.CheckExist(ctx, userPremise)
+	if err != nil {
+		return nil, errors.NewDatabaseError("check user premise", err)
+	}
+	if exists {
+		return nil, errors.NewConflictError("User is already assigned to this premise")
+	}
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.UserPremises.AssignPremises(ctx, userPremise); err != nil {
+			if isDuplicateKeyError(err, "idx_user_premises_user_premise") {
+				return errors.NewConflictError("User is already assigned to this premise")
+			}
+			return errors.NewDatabaseError("add user to premise", err)
+		}
+		if err := s.publishAssignmentEvent(ctx, repos.Outbox, events.UserPremiseAssigned, userPremise); err != nil {
+			return err
+		}
+		return publishUserSnapshot(ctx, repos, userPremise.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	userPremise.Premise = premise
+	return userPremise, nil
+}
+
+func (s *UserPremiseService) UpdateAssignment(ctx context.Context, userID string, premiseID string, updateDto *dto.UpdatePremiseAssignmentDto) (*models.UserPremise, error) {
+	userPremise, err := s.getAssignment(ctx, userID, premiseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateValidityPeriod(updateDto.StartsAt, updateDto.EndsAt); err != nil {
+		return nil, err
+	}
+	if updateDto.Role != "" {
+		userPremise.Role = updateDto.Role
+	}
+	userPremise.StartsAt = updateDto.StartsAt
+	userPremise.EndsAt = updateDto.EndsAt
+
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		// The assignment may have been removed since it was read, it must not be brought back
+		updated, err := repos.UserPremises.UpdateUserPremise(ctx, userPremise)
+		if err != nil {
+			return errors.NewDatabaseError("update user premise", err)
+		}
+		if !updated {
+			return errors.NewNotFoundError("premise assignment")
+		}
+		if err := s.publishAssignmentEvent(ctx, repos.Outbox, events.UserPremiseAssigned, userPremise); err != nil {
+			return err
+		}
+		return publishUserSnapshot(ctx, repos, userPremise.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userPremise, nil
+}
+
+func (s *UserPremiseService) UnassignPremise(ctx context.Context, userID string, premiseID string) error {
+	userPremise, err := s.getAssignment(ctx, userID, premiseID)
+	if err != nil {
+		return err
+	}
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := repos.UserPremises.RemoveUserPremise(ctx, userID, premiseID); err != nil {
+			return errors.NewDatabaseError("remove user premise", err)
+		}
+		if err := s.publishAssignmentEvent(ctx, repos.Outbox, events.UserPremiseUnassigned, userPremise); err != nil {
+			return err
+		}
+		return publishUserSnapshot(ctx, repos, userPremise.UserID)
+	})
+}
+
+func (s *UserPremiseService) getAssignment(ctx context.Context, userID string, premiseID string) (*models.UserPremise, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.NewBadRequestError("Invalid user id")
+	}
+	if _, err := uuid.Parse(premiseID); err != nil {
+		return nil, errors.NewBadRequestError("Invalid premise id")
+	}
+	userPremise, err := s.userPremiseRepo.GetUserPremise(ctx, userID, premiseID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get user premise", err)
+	}
+	if userPremise == nil {
+		return nil, errors.NewNotFoundError("premise assignment")
+	}
+	return userPremise, nil
+}
+
+func (s *UserPremiseService) publishAssignmentEvent(ctx context.Context, outbox *repositories.OutboxRepository, eventType string, userPremise *models.UserPremise) error {
+	return publishEvent(ctx, outbox, userPremise.UserID.String(), eventType, events.UserPremiseData{
+		UserID:    userPremise.UserID.String(),
+		PremiseID: userPremise.PremiseID.String(),
+		Role:      userPremise.Role,
+		StartsAt:  userPremise.StartsAt,
+		EndsAt:    userPremise.EndsAt,
+	})
+}
+
+// validateValidityPeriod checks that the end of an assignment is after its start
+func validateValidityPeriod(startsAt *time.Time, endsAt *time.Time) error {
+	if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+		return errors.NewBadRequestError("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// activeAt returns the current time when only active assignments are requested
+func activeAt(activeOnly bool) *time.Time {
+	if !activeOnly {
+		return nil
+	}
+	now := time.Now()
+	return &now
+}
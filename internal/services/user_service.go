@@ -2,30 +2,43 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	dto "scs-user/internal/dto"
 	"scs-user/internal/models"
 	repositories "scs-user/internal/repositories"
 	"scs-user/internal/types"
 	"scs-user/pkg/errors"
-	kafka_client "scs-user/pkg/kafka"
+	"scs-user/pkg/events"
 	"scs-user/pkg/utils"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/segmentio/kafka-go"
 )
 
+const verificationTokenTTL = 72 * time.Hour
+
 type UserService struct {
 	userRepo        repositories.UserRepository
 	userPremiseRepo repositories.UserPremiseRepository
-	producer        kafka_client.Producer
+	tokenRepo       repositories.UserTokenRepository
+	uow             repositories.UnitOfWork
+	notifier        *Notifier
 }
 
-func NewUserService(userRepo repositories.UserRepository, userPremiseRepo repositories.UserPremiseRepository, producer kafka_client.Producer) *UserService {
-	return &UserService{userRepo: userRepo, userPremiseRepo: userPremiseRepo, producer: producer}
+func NewUserService(userRepo repositories.UserRepository, userPremiseRepo repositories.UserPremiseRepository, tokenRepo repositories.UserTokenRepository, uow repositories.UnitOfWork, notifier *Notifier) *UserService {
+	return &UserService{userRepo: userRepo, userPremiseRepo: userPremiseRepo, tokenRepo: tokenRepo, uow: uow, notifier: notifier}
 }
 
+// CreateUser creates the user together with its premise assignments in one transaction
 func (s *UserService) CreateUser(ctx context.Context, createUserDto *dto.CreateUserDto) (*models.User, error) {
+	premiseIDs, err := parsePremiseIDs(createUserDto.AllPremiseIDs())
+	if err != nil {
+		return nil, err
+	}
+	// Only admins can be city-wide, everybody else works at specific premises
+	if len(premiseIDs) == 0 && createUserDto.Role != "admin" {
+		return nil, errors.NewBadRequestError("At least one premise is required for this role")
+	}
+
 	// Hash the password before saving
 	hashedPassword, err := utils.HashPassword(createUserDto.Password)
 	if err != nil {
@@ -37,71 +50,65 @@ func (s *UserService) CreateUser(ctx context.Context, createUserDto *dto.CreateU
 		Email:    createUserDto.Email,
 		Password: hashedPassword,
 		Role:     createUserDto.Role,
-		IsActive: false,
 	}
+	user.SetStatus(models.UserStatusPending)
 
-	createdUser, err := s.userRepo.CreateUser(ctx, user)
-	if err != nil {
-		// Check if it's a duplicate email error
-		if isDuplicateEmailError(err) {
-			return nil, errors.NewConflictError("User with this email already exists")
+	var createdUser *models.User
+	err = s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		if err := ensurePremisesExist(ctx, repos.Premises, premiseIDs); err != nil {
+			return err
 		}
-		return nil, errors.NewDatabaseError("create user", err)
-	}
-	if createUserDto.PremiseID != "" {
-		// Assign the user to the premise
-		premiseId, err := uuid.Parse(createUserDto.PremiseID)
+		createdUser, err = repos.Users.CreateUser(ctx, user)
 		if err != nil {
-			return nil, errors.NewBadRequestError("Invalid premise id")
+			// Check if it's a duplicate email error
+			if isDuplicateEmailError(err) {
+				return errors.NewConflictError("User with this email already exists")
+			}
+			return errors.NewDatabaseError("create user", err)
+		}
+		// Assign the user to the premises
+		for _, premiseID := range premiseIDs {
+			userPremise := &models.UserPremise{
+				UserID:    createdUser.ID,
+				PremiseID: premiseID,
+			}
+			if err := repos.UserPremises.AssignPremises(ctx, userPremise); err != nil {
+				return errors.NewDatabaseError("add user to premise", err)
+			}
 		}
-		userPremise := &models.UserPremise{
-			UserID:    createdUser.ID,
-			PremiseID: premiseId,
+
+		ids := make([]string, len(premiseIDs))
+		for i, premiseID := range premiseIDs {
+			ids[i] = premiseID.String()
 		}
-		err = s.userPremiseRepo.AssignPremises(ctx, userPremise)
+		err = publishEvent(ctx, repos.Outbox, createdUser.ID.String(), events.UserCreated, events.UserCreatedData{
+			UserID:     createdUser.ID.String(),
+			Email:      createdUser.Email,
+			Name:       createdUser.Name,
+			Role:       createdUser.Role,
+			Status:     createdUser.Status,
+			PremiseIDs: ids,
+		})
 		if err != nil {
-			return nil, errors.NewDatabaseError("add user to premise", err)
+			return err
 		}
-	}
-	// Generate JWT token
-	token, err := utils.GenerateToken(createdUser.ID.String(), createdUser.Role)
-	if err != nil {
-		return nil, errors.NewInternalError("Failed to generate token", err)
-	}
-	//send to kafka
-	message := types.Message[map[string]interface{}]{
-		Type:    "user.created",
-		Payload: map[string]interface{}{"token": token, "email": createdUser.Email},
-	}
-	messageBytes, err := json.Marshal(message)
-
-	// Send Kafka message after successful alarm creation
-
-	if err != nil {
-		// Log error but don't fail the operation since alarm was created successfully
-		// You might want to add proper logging here
-		return nil, errors.NewInternalError("Failed to marshal message", err)
-	}
-
-	producerMessage := kafka.Message{
-		Key:   []byte(createdUser.ID.String()),
-		Value: messageBytes,
-	}
-	err = s.producer.WriteMessages(ctx, producerMessage)
+		if err := publishUserSnapshot(ctx, repos, createdUser.ID); err != nil {
+			return err
+		}
+		return requestVerification(ctx, repos, s.notifier, createdUser)
+	})
 	if err != nil {
-		// Log error but don't fail the operation since alarm was created successfully
-		// You might want to add proper logging here
-		return nil, errors.NewInternalError("Failed to send message", err)
+		return nil, err
 	}
 	return createdUser, nil
 }
 
-func (s *UserService) GetUsers(ctx context.Context, page int, limit int) (*types.PaginateResponse[models.User], error) {
-	users, err := s.userRepo.GetUsers(ctx, page, limit)
+func (s *UserService) GetUsers(ctx context.Context, filter dto.UserFilter, page int, limit int) (*types.PaginateResponse[models.User], error) {
+	users, err := s.userRepo.GetUsers(ctx, filter, page, limit)
 	if err != nil {
 		return nil, errors.NewDatabaseError("get users", err)
 	}
-	total, err := s.userRepo.GetUsersCount(ctx)
+	total, err := s.userRepo.GetUsersCount(ctx, filter)
 	totalPages := int(total) / limit
 	if total%int64(limit) != 0 {
 		totalPages++
@@ -127,28 +134,110 @@ func (s *UserService) GetUserByID(ctx context.Context, id string) (*models.User,
 	}
 	return user, nil
 }
+
+// VerifyAccount activates the user with the single-use token sent in user.verification_requested
 func (s *UserService) VerifyAccount(ctx context.Context, token string) error {
-	claims, err := utils.ParseToken(token)
+	userToken, err := s.tokenRepo.GetValidToken(ctx, models.TokenPurposeVerification, utils.HashToken(token))
 	if err != nil {
+		return errors.NewDatabaseError("get token", err)
+	}
+	if userToken == nil {
 		return errors.NewBadRequestError("Invalid token")
 	}
-	user, err := s.userRepo.GetUserByID(ctx, claims.UserID)
-
+	user, err := s.userRepo.GetUserByID(ctx, userToken.UserID.String())
 	if err != nil {
 		return errors.NewDatabaseError("can not get user by id", err)
 	}
-	user.IsActive = true
-	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
-		return errors.NewDatabaseError("can not update user", err)
+	// Invited users activate by accepting the invitation, suspended users must not reactivate themselves
+	if user.Status == models.UserStatusInvited || user.Status == models.UserStatusSuspended {
+		return errors.NewBadRequestError("Account can not be verified")
+	}
+
+	return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		consumed, err := repos.UserTokens.ConsumeToken(ctx, userToken.ID.String())
+		if err != nil {
+			return errors.NewDatabaseError("consume token", err)
+		}
+		if !consumed {
+			return errors.NewBadRequestError("Invalid token")
+		}
+		user.SetStatus(models.UserStatusActive)
+		if err := repos.Users.UpdateUser(ctx, user); err != nil {
+			return errors.NewDatabaseError("can not update user", err)
+		}
+		return publishUserSnapshot(ctx, repos, user.ID)
+	})
+}
+
+// requestVerification issues a single-use verification token for the new user, emails it and
+// publishes it with user.verification_requested
+func requestVerification(ctx context.Context, repos *repositories.Repositories, notifier *Notifier, user *models.User) error {
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return errors.NewInternalError("Failed to generate token", err)
+	}
+	userToken := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeVerification,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(verificationTokenTTL),
+	}
+	if err := repos.UserTokens.CreateToken(ctx, userToken); err != nil {
+		return errors.NewDatabaseError("create token", err)
+	}
+	if err := notifier.SendVerification(ctx, repos, user, token, userToken.ExpiresAt); err != nil {
+		return err
+	}
+	return publishEvent(ctx, repos.Outbox, user.ID.String(), events.UserVerificationRequested, events.UserVerificationRequestedData{
+		UserID:    user.ID.String(),
+		Email:     user.Email,
+		Name:      user.Name,
+		ExpiresAt: userToken.ExpiresAt,
+	})
+}
+
+// parsePremiseIDs parses and de-duplicates premise IDs
+func parsePremiseIDs(ids []string) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	premiseIDs := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		premiseID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, errors.NewBadRequestError("Invalid premise id")
+		}
+		if !seen[premiseID] {
+			seen[premiseID] = true
+			premiseIDs = append(premiseIDs, premiseID)
+		}
+	}
+	return premiseIDs, nil
+}
+
+// ensurePremisesExist returns a bad request error if any of the premises does not exist
+func ensurePremisesExist(ctx context.Context, premiseRepo *repositories.PremiseRepository, premiseIDs []uuid.UUID) error {
+	if len(premiseIDs) == 0 {
+		return nil
+	}
+	count, err := premiseRepo.CountExistingPremises(ctx, premiseIDs)
+	if err != nil {
+		return errors.NewDatabaseError("get premises", err)
+	}
+	if count != int64(len(premiseIDs)) {
+		return errors.NewBadRequestError("One or more premises do not exist")
 	}
 	return nil
 }
 
 // isDuplicateEmailError checks if the error is due to duplicate email constraint
 func isDuplicateEmailError(err error) bool {
+	return isDuplicateKeyError(err, "email")
+}
+
+// isDuplicateKeyError checks if the error is due to a unique constraint on the given column
+func isDuplicateKeyError(err error, column string) bool {
 	errStr := err.Error()
 	return contains(errStr, "duplicate key value violates unique constraint") &&
-		contains(errStr, "email")
+		contains(errStr, column)
 }
 
 // contains checks if a string contains a substring
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+
+	"github.com/google/uuid"
+)
+
+// snapshotBatchSize is the number of users re-published per transaction
+const snapshotBatchSize = 500
+
+// publishUserSnapshot publishes the current state of the user, or a tombstone if it no longer exists
+func publishUserSnapshot(ctx context.Context, repos *repositories.Repositories, userID uuid.UUID) error {
+	return publishUserSnapshots(ctx, repos, []uuid.UUID{userID})
+}
+
+// publishUserSnapshots publishes the current state of the users, and a tombstone for every user
+// that no longer exists. The user rows are locked first, in ID order, so snapshots of concurrent
+// changes are recorded in the order the changes are committed.
+func publishUserSnapshots(ctx context.Context, repos *repositories.Repositories, userIDs []uuid.UUID) error {
+	locked, err := repos.Users.LockUsers(ctx, userIDs)
+	if err != nil {
+		return errors.NewDatabaseError("lock users", err)
+	}
+	userPremises, err := repos.UserPremises.GetByUsers(ctx, userIDs)
+	if err != nil {
+		return errors.NewDatabaseError("get user premises", err)
+	}
+	premisesByUser := make(map[uuid.UUID][]models.UserPremise, len(locked))
+	for _, userPremise := range userPremises {
+		premisesByUser[userPremise.UserID] = append(premisesByUser[userPremise.UserID], userPremise)
+	}
+	inherited, err := repos.Groups.GetInheritedPremisesByUsers(ctx, userIDs)
+	if err != nil {
+		return errors.NewDatabaseError("get inherited premises", err)
+	}
+
+	found := make(map[uuid.UUID]bool, len(locked))
+	for i := range locked {
+		user := &locked[i]
+		found[user.ID] = true
+		if err := publishEvent(ctx, repos.Outbox, user.ID.String(), events.UserSnapshot, userSnapshot(user, premisesByUser[user.ID], inherited[user.ID])); err != nil {
+			return err
+		}
+	}
+	for _, userID := range userIDs {
+		if found[userID] {
+			continue
+		}
+		found[userID] = true
+		if err := publishTombstone(ctx, repos.Outbox, userID.String(), events.UserSnapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RepublishSnapshots publishes a snapshot of every user, so that consumers can rebuild their
+// view of the users from the compacted snapshot topic. It returns the number of users published.
+func (s *UserService) RepublishSnapshots(ctx context.Context) (int, error) {
+	published := 0
+	err := s.userRepo.StreamUsers(ctx, dto.UserFilter{}, snapshotBatchSize, func(users []models.User) error {
+		ids := make([]uuid.UUID, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		return s.uow.Do(ctx, func(repos *repositories.Repositories) error {
+			// The users are reloaded locked, a user changed since the batch was read has a newer state
+			if err := publishUserSnapshots(ctx, repos, ids); err != nil {
+				return err
+			}
+			published += len(ids)
+			return nil
+		})
+	})
+	if err != nil {
+		return published, errors.NewDatabaseError("republish user snapshots", err)
+	}
+	return published, nil
+}
+
+func userSnapshot(user *models.User, userPremises []models.UserPremise, groupPremises []models.GroupPremise) events.UserSnapshotData {
+	premises := make([]events.UserSnapshotPremise, len(userPremises))
+	for i, userPremise := range userPremises {
+		premises[i] = events.UserSnapshotPremise{
+			PremiseID: userPremise.PremiseID.String(),
+			Role:      userPremise.Role,
+			StartsAt:  userPremise.StartsAt,
+			EndsAt:    userPremise.EndsAt,
+		}
+	}
+	var inherited []events.UserSnapshotGroupPremise
+	for _, groupPremise := range groupPremises {
+		inherited = append(inherited, events.UserSnapshotGroupPremise{
+			PremiseID: groupPremise.PremiseID.String(),
+			GroupID:   groupPremise.GroupID.String(),
+			Role:      groupPremise.Role,
+		})
+	}
+	return events.UserSnapshotData{
+		UserID:            user.ID.String(),
+		Email:             user.Email,
+		Name:              user.Name,
+		Role:              user.Role,
+		Status:            user.Status,
+		IsActive:          user.IsActive,
+		Premises:          premises,
+		CreatedAt:         user.CreatedAt,
+		UpdatedAt:         user.UpdatedAt,
+		InheritedPremises: inherited,
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	config "scs-user/config"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/pkg/logger"
+	"scs-user/pkg/utils"
+	"scs-user/pkg/webhook"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// maxWebhookErrorLength bounds the error stored in the delivery log
+const maxWebhookErrorLength = 1000
+
+var (
+	webhookDeliveryAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_delivery_attempts_total",
+		Help: "Number of webhook delivery attempts by result.",
+	}, []string{"result"})
+	webhookDeliveryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "webhook_delivery_duration_seconds",
+		Help:    "Time webhook endpoints took to respond.",
+		Buckets: prometheus.DefBuckets,
+	})
+	webhookSubscriptionsDisabled = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "webhook_subscriptions_disabled_total",
+		Help: "Number of webhook subscriptions disabled after failing repeatedly.",
+	})
+)
+
+// WebhookDispatcher delivers the queued webhook deliveries. Failed attempts are retried with
+// exponential backoff, and a subscription is disabled once too many attempts in a row failed.
+type WebhookDispatcher struct {
+	cfg         *config.Config
+	webhookRepo repositories.WebhookRepository
+	uow         repositories.UnitOfWork
+	client      *webhook.Client
+	logger      logger.Logger
+}
+
+func NewWebhookDispatcher(cfg *config.Config, webhookRepo repositories.WebhookRepository, uow repositories.UnitOfWork, logger logger.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{cfg: cfg, webhookRepo: webhookRepo, uow: uow, client: webhook.NewClient(cfg.Webhook.Timeout, cfg.Webhook.AllowedNetworks), logger: logger}
+}
+
+// Deliver attempts a batch of due deliveries. It is run periodically by the server; the claimed
+// deliveries stay locked until their outcome is recorded, so replicas never send one twice at once.
+func (d *WebhookDispatcher) Deliver(ctx context.Context) error {
+	return d.uow.Do(ctx, func(repos *repositories.Repositories) error {
+		deliveries, err := repos.Webhooks.ClaimDue(ctx, time.Now(), d.cfg.Webhook.BatchSize)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		results := d.send(ctx, deliveries)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		for i := range deliveries {
+			if err := d.record(ctx, repos.Webhooks, &deliveries[i], results[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Cleanup deletes the finished deliveries past the retention period
+func (d *WebhookDispatcher) Cleanup(ctx context.Context) error {
+	deleted, err := d.webhookRepo.DeleteFinished(ctx, time.Now().Add(-d.cfg.Webhook.Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		d.logger.Infof("Deleted %d finished webhook deliveries", deleted)
+	}
+	return nil
+}
+
+// send posts the deliveries concurrently and returns their results in the same order
+func (d *WebhookDispatcher) send(ctx context.Context, deliveries []models.WebhookDelivery) []webhook.Result {
+	results := make([]webhook.Result, len(deliveries))
+	concurrency := make(chan struct{}, max(d.cfg.Webhook.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		wg.Add(1)
+		concurrency <- struct{}{}
+		go func() {
+			defer func() {
+				<-concurrency
+				wg.Done()
+			}()
+			results[i] = d.client.Send(ctx, webhook.Delivery{
+				ID:        delivery.ID.String(),
+				EventType: delivery.EventType,
+				URL:       delivery.Subscription.URL,
+				Secret:    delivery.Subscription.Secret,
+				Body:      delivery.Payload,
+			})
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// record stores the outcome of an attempt, schedules the retry of a failed attempt and disables
+// the subscription once it failed too often in a row
+func (d *WebhookDispatcher) record(ctx context.Context, webhooks *repositories.WebhookRepository, delivery *models.WebhookDelivery, result webhook.Result) error {
+	now := time.Now()
+	recordWebhookAttempt(delivery, result, now)
+	webhookDeliveryDuration.Observe(result.Duration.Seconds())
+	subscriptionID := delivery.SubscriptionID.String()
+
+	if result.OK() {
+		webhookDeliveryAttempts.WithLabelValues("succeeded").Inc()
+		if err := webhooks.SaveAttempt(ctx, delivery); err != nil {
+			return err
+		}
+		return webhooks.ResetFailures(ctx, subscriptionID)
+	}
+
+	webhookDeliveryAttempts.WithLabelValues("failed").Inc()
+	d.logger.Warnf("Webhook delivery %s of %s to subscription %s failed, attempt %d: %v", delivery.ID, delivery.EventType, subscriptionID, delivery.Attempts, result.Err)
+	if delivery.Attempts >= d.cfg.Webhook.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryStatusFailed
+	} else {
+		delivery.NextAttemptAt = now.Add(utils.Backoff(delivery.Attempts, d.cfg.Webhook.RetryBaseDelay, d.cfg.Webhook.RetryMaxDelay))
+	}
+	if err := webhooks.SaveAttempt(ctx, delivery); err != nil {
+		return err
+	}
+
+	failures, err := webhooks.RecordFailure(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if failures < d.cfg.Webhook.DisableAfterFailures {
+		return nil
+	}
+	reason := fmt.Sprintf("Disabled after %d consecutive failed deliveries, last error: %s", failures, delivery.LastError)
+	disabled, err := webhooks.DisableSubscription(ctx, subscriptionID, now, truncate(reason, maxWebhookErrorLength))
+	if err != nil {
+		return err
+	}
+	if disabled {
+		webhookSubscriptionsDisabled.Inc()
+		d.logger.Warnf("Disabled webhook subscription %s (%s) after %d consecutive failed deliveries", subscriptionID, delivery.Subscription.URL, failures)
+	}
+	return nil
+}
+
+// recordWebhookAttempt copies the result of an attempt to the delivery log
+func recordWebhookAttempt(delivery *models.WebhookDelivery, result webhook.Result, at time.Time) {
+	delivery.Attempts++
+	durationMs := result.Duration.Milliseconds()
+	delivery.LastDurationMs = &durationMs
+	delivery.LastStatusCode = nil
+	if result.StatusCode != 0 {
+		statusCode := result.StatusCode
+		delivery.LastStatusCode = &statusCode
+	}
+	delivery.LastResponse = result.Response
+	delivery.LastError = ""
+	if result.Err != nil {
+		delivery.LastError = truncate(result.Err.Error(), maxWebhookErrorLength)
+	}
+	if result.OK() {
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		delivery.DeliveredAt = &at
+	}
+}
+
+// enqueueWebhookDeliveries queues a delivery of each event for every enabled subscription of its
+// type. It is called by the outbox relay with the events it published, in the same transaction.
+func enqueueWebhookDeliveries(ctx context.Context, webhooks *repositories.WebhookRepository, events []*models.OutboxEvent) error {
+	subscriptionsByType := make(map[string][]models.WebhookSubscription)
+	var deliveries []models.WebhookDelivery
+	now := time.Now()
+	for _, event := range events {
+		// Tombstones have no payload to deliver
+		if event.Payload == nil || webhookExcludedEventTypes[event.Type] {
+			continue
+		}
+		subscriptions, ok := subscriptionsByType[event.Type]
+		if !ok {
+			var err error
+			if subscriptions, err = webhooks.GetSubscriptionsForEvent(ctx, event.Type); err != nil {
+				return err
+			}
+			subscriptionsByType[event.Type] = subscriptions
+		}
+		for _, subscription := range subscriptions {
+			deliveries = append(deliveries, models.WebhookDelivery{
+				SubscriptionID: subscription.ID,
+				EventID:        event.EventID,
+				EventType:      event.Type,
+				Payload:        event.Payload,
+				Status:         models.WebhookDeliveryStatusPending,
+				NextAttemptAt:  now,
+			})
+		}
+	}
+	return webhooks.CreateDeliveries(ctx, deliveries)
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length]
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	config "scs-user/config"
+	dto "scs-user/internal/dto"
+	"scs-user/internal/models"
+	repositories "scs-user/internal/repositories"
+	"scs-user/internal/types"
+	"scs-user/pkg/errors"
+	"scs-user/pkg/events"
+	"scs-user/pkg/utils"
+	"scs-user/pkg/webhook"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookTestEventType is the type of the events sent by test deliveries
+const webhookTestEventType = "webhook.test"
+
+// webhookExcludedEventTypes are never delivered to webhooks: they announce emails with single-use
+// tokens, which version 1 of their payloads carried, or are the state of a compacted topic
+var webhookExcludedEventTypes = map[string]bool{
+	events.UserVerificationRequested: true,
+	events.UserEmailChangeRequested:  true,
+	events.UserInvited:               true,
+	events.UserSnapshot:              true,
+}
+
+// webhookEventTypePattern matches the event types a webhook can subscribe to, like user.created
+var webhookEventTypePattern = regexp.MustCompile(`^[a-z_]+(\.[a-z_]+)+$`)
+
+// WebhookService manages the webhook subscriptions of partners that can not consume Kafka
+type WebhookService struct {
+	cfg         *config.Config
+	webhookRepo repositories.WebhookRepository
+	client      *webhook.Client
+}
+
+func NewWebhookService(cfg *config.Config, webhookRepo repositories.WebhookRepository) *WebhookService {
+	return &WebhookService{cfg: cfg, webhookRepo: webhookRepo, client: webhook.NewClient(cfg.Webhook.Timeout, cfg.Webhook.AllowedNetworks)}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, createdByID string, createWebhookDto *dto.CreateWebhookDto) (*dto.WebhookWithSecret, error) {
+	createdBy, err := uuid.Parse(createdByID)
+	if err != nil {
+		return nil, errors.NewBadRequestError("Invalid user id")
+	}
+	if err := s.validateWebhookURL(createWebhookDto.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(createWebhookDto.EventTypes); err != nil {
+		return nil, err
+	}
+	secret := createWebhookDto.Secret
+	if secret == "" {
+		if secret, err = utils.GenerateRandomToken(); err != nil {
+			return nil, errors.NewInternalError("Failed to generate webhook secret", err)
+		}
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:         createWebhookDto.URL,
+		Description: createWebhookDto.Description,
+		EventTypes:  createWebhookDto.EventTypes,
+		Secret:      secret,
+		Enabled:     true,
+		CreatedByID: createdBy,
+	}
+	if err := s.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, errors.NewDatabaseError("create webhook subscription", err)
+	}
+	return &dto.WebhookWithSecret{WebhookSubscription: *subscription, Secret: secret}, nil
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, page int, limit int) (*types.PaginateResponse[models.WebhookSubscription], error) {
+	subscriptions, err := s.webhookRepo.GetSubscriptions(ctx, page, limit)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get webhook subscriptions", err)
+	}
+	total, err := s.webhookRepo.GetSubscriptionsCount(ctx)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get webhook subscriptions count", err)
+	}
+	totalPages := int(total) / limit
+	if total%int64(limit) != 0 {
+		totalPages++
+	}
+	return &types.PaginateResponse[models.WebhookSubscription]{
+		Pagination: types.Pagination{
+			TotalPages: totalPages,
+			Page:       page,
+			Limit:      limit,
+		},
+		Data: subscriptions,
+	}, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequestError("Invalid webhook id")
+	}
+	subscription, err := s.webhookRepo.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get webhook subscription", err)
+	}
+	if subscription == nil {
+		return nil, errors.NewNotFoundError("webhook")
+	}
+	return subscription, nil
+}
+
+// UpdateWebhook changes the subscription. Enabling it again clears its failures so that its
+// pending deliveries are retried.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id string, updateWebhookDto *dto.UpdateWebhookDto) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if updateWebhookDto.URL != nil {
+		if err := s.validateWebhookURL(*updateWebhookDto.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *updateWebhookDto.URL
+	}
+	if updateWebhookDto.Description != nil {
+		subscription.Description = *updateWebhookDto.Description
+	}
+	if updateWebhookDto.EventTypes != nil {
+		if err := validateWebhookEventTypes(*updateWebhookDto.EventTypes); err != nil {
+			return nil, err
+		}
+		subscription.EventTypes = *updateWebhookDto.EventTypes
+	}
+	if updateWebhookDto.Secret != nil {
+		subscription.Secret = *updateWebhookDto.Secret
+	}
+	if updateWebhookDto.Enabled != nil {
+		if *updateWebhookDto.Enabled && !subscription.Enabled {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+			subscription.DisabledReason = ""
+		}
+		if !*updateWebhookDto.Enabled && subscription.Enabled {
+			now := time.Now()
+			subscription.DisabledAt = &now
+			subscription.DisabledReason = "Disabled by an admin"
+		}
+		subscription.Enabled = *updateWebhookDto.Enabled
+	}
+
+	if err := s.webhookRepo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, errors.NewDatabaseError("update webhook subscription", err)
+	}
+	return subscription, nil
+}
+
+// DeleteWebhook deletes the subscription, its pending deliveries are dropped
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.DeleteSubscription(ctx, id); err != nil {
+		return errors.NewDatabaseError("delete webhook subscription", err)
+	}
+	return nil
+}
+
+// TestWebhook sends a webhook.test event to the endpoint right away, also if the subscription is
+// disabled, and returns the logged delivery. Test deliveries are not retried and do not count
+// towards disabling the subscription.
+func (s *WebhookService) TestWebhook(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	subscription, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	envelope := events.New(eventSource, webhookTestEventType, subscription.ID.String(), map[string]string{
+		"subscription_id": subscription.ID.String(),
+		"message":         "This is a test delivery from scs-user",
+	})
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to marshal message", err)
+	}
+
+	delivery := &models.WebhookDelivery{
+		Base:           models.Base{ID: uuid.New()},
+		SubscriptionID: subscription.ID,
+		EventID:        envelope.ID,
+		EventType:      webhookTestEventType,
+		Payload:        payload,
+	}
+	result := s.client.Send(ctx, webhook.Delivery{
+		ID:        delivery.ID.String(),
+		EventType: delivery.EventType,
+		URL:       subscription.URL,
+		Secret:    subscription.Secret,
+		Body:      payload,
+	})
+	now := time.Now()
+	recordWebhookAttempt(delivery, result, now)
+	delivery.NextAttemptAt = now
+	if !result.OK() {
+		delivery.Status = models.WebhookDeliveryStatusFailed
+	}
+
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		return nil, errors.NewDatabaseError("create webhook delivery", err)
+	}
+	return delivery, nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, id string, query dto.WebhookDeliveriesQuery, page int, limit int) (*types.PaginateResponse[models.WebhookDelivery], error) {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.webhookRepo.GetDeliveries(ctx, id, query.Status, page, limit)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get webhook deliveries", err)
+	}
+	total, err := s.webhookRepo.GetDeliveriesCount(ctx, id, query.Status)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get webhook deliveries count", err)
+	}
+	totalPages := int(total) / limit
+	if total%int64(limit) != 0 {
+		totalPages++
+	}
+	return &types.PaginateResponse[models.WebhookDelivery]{
+		Pagination: types.Pagination{
+			TotalPages: totalPages,
+			Page:       page,
+			Limit:      limit,
+		},
+		Data: deliveries,
+	}, nil
+}
+
+func (s *WebhookService) GetDelivery(ctx context.Context, id string, deliveryID string) (*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		return nil, errors.NewBadRequestError("Invalid delivery id")
+	}
+	delivery, err := s.webhookRepo.GetDeliveryByID(ctx, id, deliveryID)
+	if err != nil {
+		return nil, errors.NewDatabaseError("get webhook delivery", err)
+	}
+	if delivery == nil {
+		return nil, errors.NewNotFoundError("webhook delivery")
+	}
+	return delivery, nil
+}
+
+// validateWebhookURL rejects endpoints on internal addresses up front, the client checks the
+// resolved address of every delivery again
+func (s *WebhookService) validateWebhookURL(rawURL string) error {
+	if err := webhook.CheckURL(rawURL, s.cfg.Webhook.AllowedNetworks); err != nil {
+		return errors.NewBadRequestError("Webhook URL must not point to a loopback, link-local or private address")
+	}
+	return nil
+}
+
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if eventType == models.WebhookEventTypeAll {
+			continue
+		}
+		if !webhookEventTypePattern.MatchString(eventType) {
+			return errors.NewBadRequestError("Invalid event type " + eventType)
+		}
+		if webhookExcludedEventTypes[eventType] {
+			return errors.NewBadRequestError("Event type " + eventType + " can not be delivered to webhooks")
+		}
+	}
+	return nil
+}
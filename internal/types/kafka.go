@@ -1,6 +0,0 @@
-package types
-
-type Message[T any] struct {
-	Type    string `json:"type"`
-	Payload T      `json:"payload"`
-}
@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS "emails";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_subscriptions";
+DROP TABLE IF EXISTS "premise_versions";
+DROP TABLE IF EXISTS "consumed_events";
+DROP TABLE IF EXISTS "outbox_events";
+DROP TABLE IF EXISTS "group_premises";
+DROP TABLE IF EXISTS "group_members";
+DROP TABLE IF EXISTS "groups";
+DROP TABLE IF EXISTS "certifications";
+DROP TABLE IF EXISTS "shift_attendances";
+DROP TABLE IF EXISTS "shifts";
+DROP TABLE IF EXISTS "assignment_steps";
+DROP TABLE IF EXISTS "assignments";
+DROP TABLE IF EXISTS "invitations";
+DROP TABLE IF EXISTS "user_tokens";
+DROP TABLE IF EXISTS "sessions";
+DROP TABLE IF EXISTS "user_profiles";
+DROP TABLE IF EXISTS "user_premises";
+DROP TABLE IF EXISTS "premises";
+DROP TABLE IF EXISTS "users";
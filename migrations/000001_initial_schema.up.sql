@@ -0,0 +1,379 @@
+-- The schema as created by AutoMigrate before migrations were introduced. Tables and indexes
+-- that already exist are kept, so databases set up by AutoMigrate adopt the migrations. The
+-- tables of the first release, users and the premises tables created by hand, get the columns
+-- added since.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "name" text NOT NULL,
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    "role" text NOT NULL,
+    "status" text NOT NULL,
+    "is_active" boolean,
+    "pending_email" text,
+    "failed_logins" bigint NOT NULL DEFAULT 0,
+    "locked_until" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+-- Deactivated users of the first release are suspended. A status column added with an 'active'
+-- default marked them active, so rows contradicting is_active are corrected too.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "status" text;
+UPDATE "users" SET "status" = CASE WHEN "is_active" THEN 'active' ELSE 'suspended' END
+    WHERE "status" IS NULL OR ("status" = 'active' AND "is_active" IS NOT TRUE);
+ALTER TABLE "users" ALTER COLUMN "status" SET NOT NULL;
+ALTER TABLE "users" ALTER COLUMN "status" DROP DEFAULT;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "pending_email" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "failed_logins" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "locked_until" timestamptz;
+
+CREATE TABLE IF NOT EXISTS "premises" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "name" text NOT NULL,
+    "address" text,
+    "parent_premise_id" uuid,
+    "latitude" decimal,
+    "longitude" decimal,
+    "geofence" jsonb,
+    "geofence_min_lat" decimal,
+    "geofence_min_lng" decimal,
+    "geofence_max_lat" decimal,
+    "geofence_max_lng" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_premises_parent_premise" FOREIGN KEY ("parent_premise_id") REFERENCES "premises"("id") ON DELETE RESTRICT
+);
+ALTER TABLE "premises" ADD COLUMN IF NOT EXISTS "latitude" decimal;
+ALTER TABLE "premises" ADD COLUMN IF NOT EXISTS "longitude" decimal;
+ALTER TABLE "premises" ADD COLUMN IF NOT EXISTS "geofence" jsonb;
+ALTER TABLE "premises" ADD COLUMN IF NOT EXISTS "geofence_min_lat" decimal;
+ALTER TABLE "premises" ADD COLUMN IF NOT EXISTS "geofence_min_lng" decimal;
+ALTER TABLE "premises" ADD COLUMN IF NOT EXISTS "geofence_max_lat" decimal;
+ALTER TABLE "premises" ADD COLUMN IF NOT EXISTS "geofence_max_lng" decimal;
+CREATE INDEX IF NOT EXISTS "idx_premises_location" ON "premises" ("latitude","longitude");
+CREATE INDEX IF NOT EXISTS "idx_premises_parent_premise_id" ON "premises" ("parent_premise_id");
+
+CREATE TABLE IF NOT EXISTS "user_premises" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL,
+    "premise_id" uuid NOT NULL,
+    "role" text NOT NULL DEFAULT 'guard',
+    "starts_at" timestamptz,
+    "ends_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_premises_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_user_premises_premise" FOREIGN KEY ("premise_id") REFERENCES "premises"("id") ON DELETE CASCADE
+);
+ALTER TABLE "user_premises" ADD COLUMN IF NOT EXISTS "role" text NOT NULL DEFAULT 'guard';
+ALTER TABLE "user_premises" ADD COLUMN IF NOT EXISTS "starts_at" timestamptz;
+ALTER TABLE "user_premises" ADD COLUMN IF NOT EXISTS "ends_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_user_premises_premise_id" ON "user_premises" ("premise_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_premises_user_premise" ON "user_premises" ("user_id","premise_id");
+
+CREATE TABLE IF NOT EXISTS "user_profiles" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL,
+    "phone" text,
+    "employee_number" text,
+    "locale" text,
+    "time_zone" text,
+    "emergency_contact_name" text,
+    "emergency_contact_phone" text,
+    "emergency_contact_relation" text,
+    "avatar_key" text,
+    "avatar_thumbnail_keys" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_profiles_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_profiles_employee_number" ON "user_profiles" ("employee_number");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_profiles_user_id" ON "user_profiles" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "sessions" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL,
+    "user_agent" text,
+    "ip_address" text,
+    "expires_at" timestamptz NOT NULL,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sessions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_sessions_user_id" ON "sessions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_tokens" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL,
+    "purpose" text NOT NULL,
+    "token_hash" text NOT NULL,
+    "data" text,
+    "expires_at" timestamptz NOT NULL,
+    "consumed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_tokens_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_tokens_token_hash" ON "user_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_user_tokens_user_id" ON "user_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "invitations" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid,
+    "email" text NOT NULL,
+    "invited_by_id" uuid,
+    "token_hash" text NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "sent_count" bigint,
+    "accepted_at" timestamptz,
+    "revoked_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_invitations_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE SET NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invitations_token_hash" ON "invitations" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_invitations_user_id" ON "invitations" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "assignments" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL,
+    "premise_id" uuid,
+    "type" text NOT NULL,
+    "title" text NOT NULL,
+    "description" text,
+    "status" text NOT NULL DEFAULT 'pending',
+    "sequential" boolean,
+    "due_at" timestamptz,
+    "started_at" timestamptz,
+    "completed_at" timestamptz,
+    "created_by_id" uuid,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_assignments_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_assignments_premise" FOREIGN KEY ("premise_id") REFERENCES "premises"("id") ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_assignments_status" ON "assignments" ("status");
+CREATE INDEX IF NOT EXISTS "idx_assignments_premise_id" ON "assignments" ("premise_id");
+CREATE INDEX IF NOT EXISTS "idx_assignments_user_id" ON "assignments" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "assignment_steps" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "assignment_id" uuid NOT NULL,
+    "position" bigint NOT NULL,
+    "title" text NOT NULL,
+    "description" text,
+    "requires_evidence" boolean,
+    "completed_at" timestamptz,
+    "completed_by_id" uuid,
+    "note" text,
+    "evidence_key" text,
+    "evidence_content_type" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_assignments_steps" FOREIGN KEY ("assignment_id") REFERENCES "assignments"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_assignment_steps_position" ON "assignment_steps" ("assignment_id","position");
+
+CREATE TABLE IF NOT EXISTS "shifts" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL,
+    "premise_id" uuid NOT NULL,
+    "starts_at" timestamptz NOT NULL,
+    "ends_at" timestamptz NOT NULL,
+    "recurrence" text,
+    "schedule_ends_at" timestamptz,
+    "notes" text,
+    "created_by_id" uuid,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_shifts_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_shifts_premise" FOREIGN KEY ("premise_id") REFERENCES "premises"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_shifts_schedule_ends_at" ON "shifts" ("schedule_ends_at");
+CREATE INDEX IF NOT EXISTS "idx_shifts_premise_id" ON "shifts" ("premise_id");
+CREATE INDEX IF NOT EXISTS "idx_shifts_user_id" ON "shifts" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "shift_attendances" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "shift_id" uuid NOT NULL,
+    "scheduled_start" timestamptz NOT NULL,
+    "scheduled_end" timestamptz NOT NULL,
+    "user_id" uuid NOT NULL,
+    "premise_id" uuid NOT NULL,
+    "clock_in_at" timestamptz NOT NULL,
+    "clock_out_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_shift_attendances_shift" FOREIGN KEY ("shift_id") REFERENCES "shifts"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_shift_attendances_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_shift_attendances_premise_id" ON "shift_attendances" ("premise_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_shift_attendances_open" ON "shift_attendances" ("user_id") WHERE clock_out_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_shift_attendances_occurrence" ON "shift_attendances" ("shift_id","scheduled_start");
+
+CREATE TABLE IF NOT EXISTS "certifications" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL,
+    "type" text NOT NULL,
+    "number" text NOT NULL,
+    "issuer" text NOT NULL,
+    "issued_at" timestamptz NOT NULL,
+    "expires_at" timestamptz,
+    "status" text NOT NULL DEFAULT 'pending',
+    "document_key" text,
+    "document_content_type" text,
+    "verified_by_id" uuid,
+    "verified_at" timestamptz,
+    "rejection_reason" text,
+    "expiry_notified_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_certifications_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_certifications_expires_at" ON "certifications" ("expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_certifications_user_type_number" ON "certifications" ("user_id","type","number");
+
+CREATE TABLE IF NOT EXISTS "groups" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "name" text NOT NULL,
+    "description" text,
+    "parent_group_id" uuid,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_groups_parent_group" FOREIGN KEY ("parent_group_id") REFERENCES "groups"("id") ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS "idx_groups_parent_group_id" ON "groups" ("parent_group_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_groups_name" ON "groups" ("name");
+
+CREATE TABLE IF NOT EXISTS "group_members" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "group_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_group_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_group_members_group" FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_group_members_user_id" ON "group_members" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_group_members_group_user" ON "group_members" ("group_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "group_premises" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "group_id" uuid NOT NULL,
+    "premise_id" uuid NOT NULL,
+    "role" text NOT NULL DEFAULT 'guard',
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_group_premises_group" FOREIGN KEY ("group_id") REFERENCES "groups"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_group_premises_premise" FOREIGN KEY ("premise_id") REFERENCES "premises"("id") ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_group_premises_group_premise" ON "group_premises" ("group_id","premise_id");
+CREATE INDEX IF NOT EXISTS "idx_group_premises_premise_id" ON "group_premises" ("premise_id");
+
+CREATE TABLE IF NOT EXISTS "outbox_events" (
+    "id" bigserial,
+    "key" text NOT NULL,
+    "type" text NOT NULL,
+    "payload" jsonb,
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_error" text,
+    "created_at" timestamptz,
+    "published_at" timestamptz,
+    "event_id" text NOT NULL DEFAULT '',
+    "trace_parent" text NOT NULL DEFAULT '',
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_pending" ON "outbox_events" ("published_at") WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_key" ON "outbox_events" ("key");
+
+CREATE TABLE IF NOT EXISTS "consumed_events" (
+    "id" text,
+    "type" text NOT NULL,
+    "consumed_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "premise_versions" (
+    "premise_id" uuid,
+    "version" bigint NOT NULL,
+    "deleted" boolean NOT NULL DEFAULT false,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("premise_id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "url" text NOT NULL,
+    "description" text,
+    "event_types" jsonb NOT NULL,
+    "secret" text NOT NULL,
+    "enabled" boolean NOT NULL DEFAULT true,
+    "consecutive_failures" bigint NOT NULL DEFAULT 0,
+    "disabled_at" timestamptz,
+    "disabled_reason" text,
+    "created_by_id" uuid NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "subscription_id" uuid NOT NULL,
+    "event_id" text NOT NULL,
+    "event_type" text NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_status_code" bigint,
+    "last_response" text,
+    "last_error" text,
+    "last_duration_ms" bigint,
+    "delivered_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_webhook_deliveries_subscription" FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_due" ON "webhook_deliveries" ("status","next_attempt_at") WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_subscription_id" ON "webhook_deliveries" ("subscription_id");
+
+CREATE TABLE IF NOT EXISTS "emails" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid,
+    "template" text NOT NULL,
+    "to" text NOT NULL,
+    "subject" text NOT NULL,
+    "text" text NOT NULL,
+    "html" text NOT NULL,
+    "status" text NOT NULL DEFAULT 'pending',
+    "attempts" bigint NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamptz NOT NULL,
+    "last_error" text,
+    "sent_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_emails_due" ON "emails" ("status","next_attempt_at") WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS "idx_emails_user_id" ON "emails" ("user_id");
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "signing_keys" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "secret" text NOT NULL,
+    "retired_at" timestamptz,
+    PRIMARY KEY ("id")
+);
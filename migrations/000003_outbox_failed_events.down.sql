@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_outbox_events_failed";
+ALTER TABLE "outbox_events" DROP COLUMN IF EXISTS "failed_at";
@@ -0,0 +1,2 @@
+ALTER TABLE "outbox_events" ADD COLUMN IF NOT EXISTS "failed_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_failed" ON "outbox_events" ("failed_at") WHERE failed_at IS NOT NULL;
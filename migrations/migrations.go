@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned SQL migrations of the database schema. Every migration
+// is a pair of files VERSION_NAME.up.sql and VERSION_NAME.down.sql, e.g. 000002_add_foo.up.sql.
+// Applied migrations must not be edited, their checksums are verified before migrating.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -118,6 +118,11 @@ func NewUnauthorizedError(message string) *AppError {
 	return NewAppError(ErrorTypeUnauthorized, message, nil)
 }
 
+// NewForbiddenError creates a forbidden error
+func NewForbiddenError(message string) *AppError {
+	return NewAppError(ErrorTypeForbidden, message, nil)
+}
+
 // IsAppError checks if an error is an AppError
 func IsAppError(err error) (*AppError, bool) {
 	if appErr, ok := err.(*AppError); ok {
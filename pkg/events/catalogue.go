@@ -0,0 +1,87 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+)
+
+// schemaURIPrefix prefixes the data schema URIs of catalogued events
+const schemaURIPrefix = "urn:scs-user:events:"
+
+// Definition describes the payload of an event type. An incompatible change of the payload
+// requires a new version.
+type Definition struct {
+	Type    string
+	Version int
+	// Data is the zero value of the payload struct the schema is generated from
+	Data any
+}
+
+// SchemaURI identifies the schema in the dataschema attribute of events
+func (d Definition) SchemaURI() string {
+	return fmt.Sprintf("%s%s:v%d", schemaURIPrefix, d.Type, d.Version)
+}
+
+// SchemaFile is the name of the published schema in the schemas directory
+func (d Definition) SchemaFile() string {
+	return fmt.Sprintf("%s.v%d.json", d.Type, d.Version)
+}
+
+// Schema generates the JSON Schema of the payload
+func (d Definition) Schema() *Schema {
+	schema := Generate(d.Data)
+	schema.Draft = draft
+	schema.ID = d.SchemaURI()
+	schema.Title = d.Type
+	return schema
+}
+
+// EnvelopeSchema generates the JSON Schema of the CloudEvents envelope carrying the payload, the
+// schema of the published message values
+func (d Definition) EnvelopeSchema() *Schema {
+	schema := Generate(Event[struct{}]{})
+	schema.Properties["data"] = Generate(d.Data)
+	schema.Draft = draft
+	schema.ID = d.SchemaURI() + ":envelope"
+	schema.Title = d.Type
+	return schema
+}
+
+var catalogue = []Definition{
+	{Type: UserCreated, Version: 1, Data: UserCreatedData{}},
+	{Type: UserVerificationRequested, Version: 2, Data: UserVerificationRequestedData{}},
+	{Type: UserEmailChangeRequested, Version: 2, Data: UserEmailChangeRequestedData{}},
+	{Type: UserEmailChanged, Version: 1, Data: UserEmailChangedData{}},
+	{Type: UserInvited, Version: 2, Data: UserInvitedData{}},
+	{Type: UserInvitationRevoked, Version: 1, Data: UserInvitationRevokedData{}},
+	{Type: UserPremiseAssigned, Version: 1, Data: UserPremiseData{}},
+	{Type: UserPremiseUnassigned, Version: 1, Data: UserPremiseData{}},
+	{Type: PremiseCreated, Version: 1, Data: PremiseData{}},
+	{Type: PremiseUpdated, Version: 1, Data: PremiseData{}},
+	{Type: PremiseDeleted, Version: 1, Data: PremiseData{}},
+	{Type: PremiseMoved, Version: 1, Data: PremiseData{}},
+	{Type: UserSnapshot, Version: 1, Data: UserSnapshotData{}},
+}
+
+// Catalogue returns the definitions of all catalogued events
+func Catalogue() []Definition {
+	return append([]Definition(nil), catalogue...)
+}
+
+// Lookup returns the current definition of the event type
+func Lookup(eventType string) (Definition, bool) {
+	for _, definition := range catalogue {
+		if definition.Type == eventType {
+			return definition, true
+		}
+	}
+	return Definition{}, false
+}
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// PublishedSchema returns the published JSON Schema of the event type version
+func PublishedSchema(eventType string, version int) ([]byte, error) {
+	return schemaFiles.ReadFile("schemas/" + Definition{Type: eventType, Version: version}.SchemaFile())
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CheckCompatible returns the changes from the published to the current schema that break
+// consumers, none if the schemas are compatible. Changes are compatible in both directions:
+// events of either schema have to validate against the other one, so only optional properties
+// can be added or removed and allowed values can not change.
+func CheckCompatible(published *Schema, current *Schema) []string {
+	var problems []string
+	checkCompatible("", published, current, &problems)
+	return problems
+}
+
+func checkCompatible(path string, published *Schema, current *Schema, problems *[]string) {
+	report := func(format string, args ...any) {
+		location := path
+		if location == "" {
+			location = "(root)"
+		}
+		*problems = append(*problems, location+": "+fmt.Sprintf(format, args...))
+	}
+
+	if !sameSet(published.Type, current.Type) {
+		report("type changed from %v to %v", []string(published.Type), []string(current.Type))
+		return
+	}
+	if published.Format != current.Format {
+		report("format changed from %q to %q", published.Format, current.Format)
+	}
+	if !sameSet(published.Enum, current.Enum) {
+		report("allowed values changed from %v to %v", published.Enum, current.Enum)
+	}
+	if !sameLength(published.MinItems, current.MinItems) || !sameLength(published.MaxItems, current.MaxItems) {
+		report("number of items changed")
+	}
+
+	if published.Items != nil && current.Items != nil {
+		checkCompatible(path+"[]", published.Items, current.Items, problems)
+	}
+
+	publishedRequired := toSet(published.Required)
+	currentRequired := toSet(current.Required)
+	for _, name := range sortedKeys(published.Properties) {
+		property := join(path, name)
+		currentProperty, ok := current.Properties[name]
+		if !ok {
+			if publishedRequired[name] {
+				report("required property %s was removed", property)
+			}
+			continue
+		}
+		if publishedRequired[name] != currentRequired[name] {
+			report("property %s changed from required=%t to required=%t", property, publishedRequired[name], currentRequired[name])
+		}
+		checkCompatible(property, published.Properties[name], currentProperty, problems)
+	}
+	for _, name := range sortedKeys(current.Properties) {
+		if _, ok := published.Properties[name]; !ok && currentRequired[name] {
+			report("required property %s was added", join(path, name))
+		}
+	}
+}
+
+func join(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sameSet(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := toSet(a)
+	for _, value := range b {
+		if !set[value] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameLength(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func sortedKeys(properties map[string]*Schema) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,49 @@
+// Package events defines the envelope and the typed payloads of the events published by scs-user,
+// together with their JSON Schemas.
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SpecVersion is the CloudEvents version the envelope follows
+	SpecVersion = "1.0"
+	// ContentTypeJSON is the content type of the event data
+	ContentTypeJSON = "application/json"
+)
+
+// Event is a CloudEvents 1.0 envelope in structured JSON mode
+type Event[T any] struct {
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	SpecVersion     string    `json:"specversion"`
+	Type            string    `json:"type"`
+	Time            time.Time `json:"time"`
+	Subject         string    `json:"subject,omitempty"`
+	DataContentType string    `json:"datacontenttype"`
+	// DataSchema identifies the schema and version of the data, empty for uncatalogued events
+	DataSchema string `json:"dataschema,omitempty"`
+	Data       T      `json:"data"`
+}
+
+// New returns an event with a new ID, the current time and the schema of the event type
+// from the catalogue
+func New[T any](source string, eventType string, subject string, data T) Event[T] {
+	event := Event[T]{
+		ID:              uuid.New().String(),
+		Source:          source,
+		SpecVersion:     SpecVersion,
+		Type:            eventType,
+		Time:            time.Now().UTC(),
+		Subject:         subject,
+		DataContentType: ContentTypeJSON,
+		Data:            data,
+	}
+	if definition, ok := Lookup(eventType); ok {
+		event.DataSchema = definition.SchemaURI()
+	}
+	return event
+}
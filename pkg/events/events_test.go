@@ -0,0 +1,211 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the generated schemas of new or compatibly changed events")
+
+// TestSchemasCompatible fails when a payload changed incompatibly with its published schema,
+// which requires a new version, or when a published schema is out of date.
+// Run go test ./pkg/events -update to publish new and compatibly changed schemas.
+func TestSchemasCompatible(t *testing.T) {
+	for _, definition := range Catalogue() {
+		current := definition.Schema()
+		generated, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			t.Fatalf("%s: failed to marshal schema: %v", definition.Type, err)
+		}
+		generated = append(generated, '\n')
+		path := filepath.Join("schemas", definition.SchemaFile())
+
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			if *update {
+				writeSchema(t, path, generated)
+				continue
+			}
+			t.Errorf("%s v%d has no published schema, run go test ./pkg/events -update", definition.Type, definition.Version)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		var published Schema
+		if err := json.Unmarshal(data, &published); err != nil {
+			t.Fatalf("%s: invalid schema: %v", path, err)
+		}
+		if problems := CheckCompatible(&published, current); len(problems) > 0 {
+			t.Errorf("%s v%d changed incompatibly, add version %d instead:\n%s", definition.Type, definition.Version, definition.Version+1, strings.Join(problems, "\n"))
+			continue
+		}
+		if !bytes.Equal(data, generated) {
+			if *update {
+				writeSchema(t, path, generated)
+				continue
+			}
+			t.Errorf("%s is out of date, run go test ./pkg/events -update", path)
+		}
+	}
+}
+
+func writeSchema(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	type v1 struct {
+		ID       string  `json:"id" format:"uuid"`
+		Name     string  `json:"name"`
+		Nickname string  `json:"nickname,omitempty"`
+		Role     string  `json:"role" enum:"admin,guard"`
+		Score    float64 `json:"score"`
+	}
+	published := Generate(v1{})
+
+	tests := []struct {
+		name       string
+		current    any
+		compatible bool
+	}{
+		{"unchanged", v1{}, true},
+		{"optional property added", struct {
+			ID       string  `json:"id" format:"uuid"`
+			Name     string  `json:"name"`
+			Nickname string  `json:"nickname,omitempty"`
+			Role     string  `json:"role" enum:"admin,guard"`
+			Score    float64 `json:"score"`
+			Phone    string  `json:"phone,omitempty"`
+		}{}, true},
+		{"optional property removed", struct {
+			ID    string  `json:"id" format:"uuid"`
+			Name  string  `json:"name"`
+			Role  string  `json:"role" enum:"admin,guard"`
+			Score float64 `json:"score"`
+		}{}, true},
+		{"required property added", struct {
+			ID       string  `json:"id" format:"uuid"`
+			Name     string  `json:"name"`
+			Nickname string  `json:"nickname,omitempty"`
+			Role     string  `json:"role" enum:"admin,guard"`
+			Score    float64 `json:"score"`
+			Phone    string  `json:"phone"`
+		}{}, false},
+		{"required property removed", struct {
+			ID       string  `json:"id" format:"uuid"`
+			Nickname string  `json:"nickname,omitempty"`
+			Role     string  `json:"role" enum:"admin,guard"`
+			Score    float64 `json:"score"`
+		}{}, false},
+		{"type changed", struct {
+			ID       string `json:"id" format:"uuid"`
+			Name     string `json:"name"`
+			Nickname string `json:"nickname,omitempty"`
+			Role     string `json:"role" enum:"admin,guard"`
+			Score    int    `json:"score"`
+		}{}, false},
+		{"made nullable", struct {
+			ID       string  `json:"id" format:"uuid"`
+			Name     *string `json:"name"`
+			Nickname string  `json:"nickname,omitempty"`
+			Role     string  `json:"role" enum:"admin,guard"`
+			Score    float64 `json:"score"`
+		}{}, false},
+		{"allowed value added", struct {
+			ID       string  `json:"id" format:"uuid"`
+			Name     string  `json:"name"`
+			Nickname string  `json:"nickname,omitempty"`
+			Role     string  `json:"role" enum:"admin,guard,operator"`
+			Score    float64 `json:"score"`
+		}{}, false},
+		{"format changed", struct {
+			ID       string  `json:"id"`
+			Name     string  `json:"name"`
+			Nickname string  `json:"nickname,omitempty"`
+			Role     string  `json:"role" enum:"admin,guard"`
+			Score    float64 `json:"score"`
+		}{}, false},
+	}
+	for _, tt := range tests {
+		problems := CheckCompatible(published, Generate(tt.current))
+		if compatible := len(problems) == 0; compatible != tt.compatible {
+			t.Errorf("%s: compatible = %t, want %t (problems: %v)", tt.name, compatible, tt.compatible, problems)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Generate(PremiseData{})
+	if got := schema.Properties["parent_premise_id"]; !got.Type.Nullable() || got.Format != "uuid" {
+		t.Errorf("parent_premise_id = %+v, want nullable uuid string", got)
+	}
+	coordinates := schema.Properties["geofence"].Properties["coordinates"]
+	position := coordinates.Items.Items
+	if position.Type[0] != "array" || *position.MinItems != 2 || *position.MaxItems != 2 || position.Items.Type[0] != "number" {
+		t.Errorf("geofence position = %+v, want array of two numbers", position)
+	}
+	if len(schema.Required) != len(schema.Properties) {
+		t.Errorf("required = %v, want all properties", schema.Required)
+	}
+}
+
+func TestNew(t *testing.T) {
+	event := New("/scs-user", UserEmailChanged, "user-1", UserEmailChangedData{UserID: "user-1"})
+	if event.ID == "" || event.SpecVersion != SpecVersion || event.DataContentType != ContentTypeJSON {
+		t.Errorf("event = %+v, want id, spec version and content type set", event)
+	}
+	if event.DataSchema != "urn:scs-user:events:user.email_changed:v1" {
+		t.Errorf("dataschema = %q", event.DataSchema)
+	}
+	if uncatalogued := New("/scs-user", "shift.started", "user-1", map[string]any{}); uncatalogued.DataSchema != "" {
+		t.Errorf("dataschema of uncatalogued event = %q, want empty", uncatalogued.DataSchema)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var envelope map[string]any
+	json.Unmarshal(data, &envelope)
+	for _, attribute := range []string{"id", "source", "specversion", "type", "time", "subject", "datacontenttype", "dataschema", "data"} {
+		if _, ok := envelope[attribute]; !ok {
+			t.Errorf("envelope is missing %s", attribute)
+		}
+	}
+}
+
+func TestPublishedSchema(t *testing.T) {
+	for _, definition := range Catalogue() {
+		if _, err := PublishedSchema(definition.Type, definition.Version); err != nil {
+			t.Errorf("PublishedSchema(%s, %d) error = %v", definition.Type, definition.Version, err)
+		}
+	}
+}
+
+func TestEnvelopeSchema(t *testing.T) {
+	definition, _ := Lookup(UserCreated)
+	schema := definition.EnvelopeSchema()
+	if schema.ID != "urn:scs-user:events:user.created:v1:envelope" {
+		t.Errorf("$id = %q", schema.ID)
+	}
+	if data := schema.Properties["data"]; data.Properties["user_id"] == nil {
+		t.Errorf("data = %+v, want the user.created payload", data)
+	}
+	// The optional attributes are not required
+	for attribute, required := range map[string]bool{"id": true, "type": true, "data": true, "subject": false, "dataschema": false} {
+		if got := slices.Contains(schema.Required, attribute); got != required {
+			t.Errorf("%s required = %t, want %t", attribute, got, required)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package events
+
+import "time"
+
+// Event types of the catalogued events
+const (
+	UserCreated               = "user.created"
+	UserVerificationRequested = "user.verification_requested"
+	UserEmailChangeRequested  = "user.email_change_requested"
+	UserEmailChanged          = "user.email_changed"
+	UserInvited               = "user.invited"
+	UserInvitationRevoked     = "user.invitation_revoked"
+	UserPremiseAssigned       = "user.premise_assigned"
+	UserPremiseUnassigned     = "user.premise_unassigned"
+	PremiseCreated            = "premise.created"
+	PremiseUpdated            = "premise.updated"
+	PremiseDeleted            = "premise.deleted"
+	PremiseMoved              = "premise.moved"
+	UserSnapshot              = "user.snapshot"
+)
+
+// UserCreatedData is published when a user is created by an admin
+type UserCreatedData struct {
+	UserID     string   `json:"user_id" format:"uuid"`
+	Email      string   `json:"email" format:"email"`
+	Name       string   `json:"name"`
+	Role       string   `json:"role" enum:"admin,guard,operator"`
+	Status     string   `json:"status"`
+	PremiseIDs []string `json:"premise_ids" format:"uuid"`
+}
+
+// UserVerificationRequestedData announces the verification email sent to a new user. The token is
+// only sent in the email, version 1 carried it.
+type UserVerificationRequestedData struct {
+	UserID    string    `json:"user_id" format:"uuid"`
+	Email     string    `json:"email" format:"email"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UserEmailChangeRequestedData announces the confirmation email sent to a new email address. The
+// token is only sent in the email, version 1 carried it.
+type UserEmailChangeRequestedData struct {
+	UserID    string    `json:"user_id" format:"uuid"`
+	Email     string    `json:"email" format:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type UserEmailChangedData struct {
+	UserID   string `json:"user_id" format:"uuid"`
+	OldEmail string `json:"old_email" format:"email"`
+	NewEmail string `json:"new_email" format:"email"`
+}
+
+// UserInvitedData announces the invitation email sent to an invitee. The token is only sent in
+// the email, version 1 carried it.
+type UserInvitedData struct {
+	UserID       string    `json:"user_id" format:"uuid"`
+	InvitationID string    `json:"invitation_id" format:"uuid"`
+	Email        string    `json:"email" format:"email"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role" enum:"admin,guard,operator"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type UserInvitationRevokedData struct {
+	UserID string `json:"user_id" format:"uuid"`
+	Email  string `json:"email" format:"email"`
+}
+
+// UserPremiseData is published when a user is assigned to a premise, the assignment is changed
+// or the user is unassigned
+type UserPremiseData struct {
+	UserID    string     `json:"user_id" format:"uuid"`
+	PremiseID string     `json:"premise_id" format:"uuid"`
+	Role      string     `json:"role"`
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+}
+
+// PremiseData is the state of a premise after it was created, updated, moved or before it was deleted
+type PremiseData struct {
+	ID              string          `json:"id" format:"uuid"`
+	Name            string          `json:"name"`
+	Address         string          `json:"address"`
+	ParentPremiseID *string         `json:"parent_premise_id" format:"uuid"`
+	Latitude        *float64        `json:"latitude"`
+	Longitude       *float64        `json:"longitude"`
+	Geofence        *GeoJSONPolygon `json:"geofence"`
+}
+
+// GeoJSONPolygon is a GeoJSON Polygon geometry, positions are [longitude, latitude]
+type GeoJSONPolygon struct {
+	Type        string         `json:"type" enum:"Polygon"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// UserSnapshotData is the full state of a user, published after every change of the user to a
+// compacted topic keyed by user ID. A deleted user is published as a tombstone without data.
+type UserSnapshotData struct {
+	UserID    string                `json:"user_id" format:"uuid"`
+	Email     string                `json:"email" format:"email"`
+	Name      string                `json:"name"`
+	Role      string                `json:"role" enum:"admin,guard,operator"`
+	Status    string                `json:"status" enum:"invited,pending,active,suspended"`
+	IsActive  bool                  `json:"is_active"`
+	Premises  []UserSnapshotPremise `json:"premises"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	// InheritedPremises are the premises assigned to the groups of the user, directly or through
+	// a subgroup. The user has access to these premises as well as to Premises.
+	InheritedPremises []UserSnapshotGroupPremise `json:"inherited_premises,omitempty"`
+}
+
+// UserSnapshotPremise is a premise assignment of a user snapshot
+type UserSnapshotPremise struct {
+	PremiseID string     `json:"premise_id" format:"uuid"`
+	Role      string     `json:"role"`
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+}
+
+// UserSnapshotGroupPremise is a premise assignment a user snapshot inherits from a group
+type UserSnapshotGroupPremise struct {
+	PremiseID string `json:"premise_id" format:"uuid"`
+	GroupID   string `json:"group_id" format:"uuid"`
+	Role      string `json:"role"`
+}
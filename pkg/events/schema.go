@@ -0,0 +1,131 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// draft is the JSON Schema dialect of the generated schemas
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema the payload schemas are made of
+type Schema struct {
+	Draft      string             `json:"$schema,omitempty"`
+	ID         string             `json:"$id,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       Types              `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	MinItems   *int               `json:"minItems,omitempty"`
+	MaxItems   *int               `json:"maxItems,omitempty"`
+}
+
+// Types is the type keyword, a single type or a list of types
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Nullable reports whether null is an allowed type
+func (t Types) Nullable() bool {
+	for _, name := range t {
+		if name == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generate returns the JSON Schema of the value's type. Struct fields are named by their json
+// tag and are required unless tagged omitempty; pointers are nullable. The format and enum
+// tags add the format and the comma separated allowed values of a string field.
+func Generate(v any) *Schema {
+	return generate(reflect.TypeOf(v), "", "")
+}
+
+func generate(t reflect.Type, format string, enum string) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := generate(t.Elem(), format, enum)
+		schema.Type = append(schema.Type, "null")
+		return schema
+	}
+	if t == timeType {
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		schema := &Schema{Type: Types{"string"}, Format: format}
+		if enum != "" {
+			schema.Enum = strings.Split(enum, ",")
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.Slice:
+		return &Schema{Type: Types{"array"}, Items: generate(t.Elem(), format, enum)}
+	case reflect.Array:
+		length := t.Len()
+		return &Schema{Type: Types{"array"}, Items: generate(t.Elem(), format, enum), MinItems: &length, MaxItems: &length}
+	case reflect.Map:
+		return &Schema{Type: Types{"object"}}
+	case reflect.Struct:
+		return generateObject(t)
+	default:
+		panic("events: unsupported payload field type " + t.String())
+	}
+}
+
+func generateObject(t reflect.Type) *Schema {
+	schema := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = generate(field.Type, field.Tag.Get("format"), field.Tag.Get("enum"))
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
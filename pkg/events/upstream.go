@@ -0,0 +1,17 @@
+package events
+
+import "scs-user/pkg/geo"
+
+// UpstreamPremiseData is the payload of the premise.created, premise.updated and premise.deleted
+// events consumed from the premise service. Version increases with every change of the premise,
+// so an event older than the replicated state can be told apart and ignored.
+type UpstreamPremiseData struct {
+	ID              string       `json:"id"`
+	Version         int64        `json:"version"`
+	Name            string       `json:"name"`
+	Address         string       `json:"address"`
+	ParentPremiseID *string      `json:"parent_premise_id"`
+	Latitude        *float64     `json:"latitude"`
+	Longitude       *float64     `json:"longitude"`
+	Geofence        *geo.Polygon `json:"geofence"`
+}
@@ -0,0 +1,36 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatCell(v)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	// Flush every record so the data is streamed to the client
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
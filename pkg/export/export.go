@@ -0,0 +1,101 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format represents a supported export format
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatXLSX  Format = "xlsx"
+)
+
+// Writer writes tabular records one at a time to an underlying stream.
+// Implementations must not buffer the whole data set in memory.
+type Writer interface {
+	// Write writes a single record. Values are in the same order as the columns.
+	Write(values []interface{}) error
+	// Close flushes any pending data and finalizes the output.
+	Close() error
+}
+
+// ParseFormat parses a format name, defaulting to CSV when empty
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatJSONL, "ndjson":
+		return FormatJSONL, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", s)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// NewWriter creates a writer for the given format
+func NewWriter(format Format, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatJSONL:
+		return newJSONLWriter(w, columns), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// formatCell converts a value to the text of a spreadsheet cell. Text starting with a character
+// that spreadsheet applications read as a formula is prefixed with a quote, so that exported
+// user input is never evaluated. Numbers and booleans are written as they are.
+func formatCell(v interface{}) string {
+	text := formatValue(v)
+	switch v.(type) {
+	case bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return text
+	}
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// formatValue converts a value to its textual representation
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []string:
+		return strings.Join(val, ";")
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return fmt.Sprint(val)
+	}
+}
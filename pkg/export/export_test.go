@@ -0,0 +1,151 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Format
+		wantErr  bool
+	}{
+		{"", FormatCSV, false},
+		{"CSV", FormatCSV, false},
+		{"jsonl", FormatJSONL, false},
+		{"ndjson", FormatJSONL, false},
+		{"xlsx", FormatXLSX, false},
+		{"pdf", "", true},
+	}
+
+	for _, tt := range tests {
+		format, err := ParseFormat(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if format != tt.expected {
+			t.Errorf("ParseFormat(%q) = %s, expected %s", tt.input, format, tt.expected)
+		}
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, []string{"name", "premises"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := w.Write([]interface{}{"Jane, Doe", []string{"HQ", "Depot"}}); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	expected := "name,premises\n\"Jane, Doe\",HQ;Depot\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, []string{"name", "premises", "count"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	values := [][]interface{}{
+		{"=HYPERLINK(\"http://evil.example\")", []string{"+1", "HQ"}, -1},
+		{"@SUM(A1)", "-2", 3},
+		{"\tTab", "\rReturn", 0},
+		{"Jane", "a=b", 1},
+	}
+	for _, record := range values {
+		if err := w.Write(record); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+	w.Close()
+
+	expected := "name,premises,count\n" +
+		"\"'=HYPERLINK(\"\"http://evil.example\"\")\",'+1;HQ,-1\n" +
+		"'@SUM(A1),'-2,3\n" +
+		"'\tTab,\"'\rReturn\",0\n" +
+		"Jane,a=b,1\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(FormatJSONL, &buf, []string{"name", "is_active"})
+	w.Write([]interface{}{"Jane", true})
+	w.Write([]interface{}{"John", false})
+	w.Close()
+
+	expected := "{\"name\":\"Jane\",\"is_active\":true}\n{\"name\":\"John\",\"is_active\":false}\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+
+	if err := w.Write([]interface{}{"missing column"}); err == nil {
+		t.Error("Expected error when value count does not match columns")
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf, []string{"name", "is_active"})
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	if err := w.Write([]interface{}{"<Jane & co>", true}); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if err := w.Write([]interface{}{"=1+1", false}); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Output is not a valid zip archive: %v", err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(content)
+		}
+	}
+	if sheet == "" {
+		t.Fatal("Worksheet not found in archive")
+	}
+	if !strings.Contains(sheet, "&lt;Jane &amp; co&gt;") {
+		t.Errorf("Expected escaped string cell, got %s", sheet)
+	}
+	if !strings.Contains(sheet, `<c r="B2" t="b"><v>1</v></c>`) {
+		t.Errorf("Expected boolean cell B2, got %s", sheet)
+	}
+	if !strings.Contains(sheet, `<t xml:space="preserve">&#39;=1+1</t>`) {
+		t.Errorf("Expected the formula in A3 to be escaped, got %s", sheet)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, expected := range tests {
+		if got := columnName(index); got != expected {
+			t.Errorf("columnName(%d) = %s, expected %s", index, got, expected)
+		}
+	}
+}
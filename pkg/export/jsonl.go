@@ -0,0 +1,49 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type jsonlWriter struct {
+	w       io.Writer
+	columns []string
+}
+
+func newJSONLWriter(w io.Writer, columns []string) *jsonlWriter {
+	return &jsonlWriter{w: w, columns: columns}
+}
+
+// Write encodes the record as a JSON object keeping the column order
+func (j *jsonlWriter) Write(values []interface{}) error {
+	if len(values) != len(j.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(j.columns), len(values))
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range j.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteString("}\n")
+	_, err := j.w.Write(buf.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
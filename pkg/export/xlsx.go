@@ -0,0 +1,122 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// xlsxWriter writes a minimal single-sheet SpreadsheetML workbook.
+// Rows are written straight into the zip entry so memory usage stays flat
+// regardless of the number of records.
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+func newXLSXWriter(w io.Writer, columns []string) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	staticParts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range staticParts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+
+	// The sheet must be the last entry since it is still open while rows are written
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worksheet: %w", err)
+	}
+	x := &xlsxWriter{zw: zw, sheet: bufio.NewWriter(sheet)}
+	if _, err := x.sheet.WriteString(xlsxSheetHeader); err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	if err := x.Write(header); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(values []interface{}) error {
+	x.row++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.row)
+	for i, v := range values {
+		ref := columnName(i) + strconv.Itoa(x.row)
+		switch val := v.(type) {
+		case nil:
+			continue
+		case bool:
+			b := "0"
+			if val {
+				b = "1"
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="b"><v>%s</v></c>`, ref, b)
+		case int, int32, int64, uint, uint32, uint64, float32, float64:
+			fmt.Fprintf(x.sheet, `<c r="%s"><v>%v</v></c>`, ref, val)
+		default:
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(x.sheet, []byte(formatCell(val))); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// columnName converts a zero-based column index to a spreadsheet column name (A, B, ..., AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"math"
+)
+
+const earthRadiusMeters = 6371008.8
+
+// Point is a WGS84 position
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// DistanceMeters returns the great-circle distance between two points using the haversine formula
+func DistanceMeters(a Point, b Point) float64 {
+	lat1 := toRadians(a.Lat)
+	lat2 := toRadians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := toRadians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox is an axis-aligned latitude/longitude rectangle
+type BoundingBox struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// BoundingBoxAround returns a box that contains every point within radiusMeters of the center
+func BoundingBoxAround(center Point, radiusMeters float64) BoundingBox {
+	dLat := radiusMeters / earthRadiusMeters * 180 / math.Pi
+	// Longitude degrees shrink towards the poles
+	cosLat := math.Cos(toRadians(center.Lat))
+	dLng := 180.0
+	if cosLat > 1e-9 {
+		dLng = math.Min(180, dLat/cosLat)
+	}
+	return BoundingBox{
+		MinLat: math.Max(-90, center.Lat-dLat),
+		MaxLat: math.Min(90, center.Lat+dLat),
+		MinLng: center.Lng - dLng,
+		MaxLng: center.Lng + dLng,
+	}
+}
+
+// Contains reports whether the point lies inside the box
+func (b BoundingBox) Contains(p Point) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
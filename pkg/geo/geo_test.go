@@ -0,0 +1,103 @@
+package geo
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// square returns a closed ring around the center with the given half size in degrees
+func square(lat, lng, half float64) []Point {
+	return []Point{
+		{Lat: lat - half, Lng: lng - half},
+		{Lat: lat - half, Lng: lng + half},
+		{Lat: lat + half, Lng: lng + half},
+		{Lat: lat + half, Lng: lng - half},
+		{Lat: lat - half, Lng: lng - half},
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	polygon := Polygon{Rings: [][]Point{square(1.3, 103.8, 0.01), square(1.3, 103.8, 0.002)}}
+
+	tests := []struct {
+		name     string
+		point    Point
+		expected bool
+	}{
+		{"inside outer ring", Point{Lat: 1.305, Lng: 103.805}, true},
+		{"inside hole", Point{Lat: 1.3, Lng: 103.8}, false},
+		{"outside", Point{Lat: 1.32, Lng: 103.8}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := polygon.Contains(tt.point); got != tt.expected {
+				t.Errorf("Contains(%v) = %v, expected %v", tt.point, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPolygonJSON(t *testing.T) {
+	input := `{"type":"Polygon","coordinates":[[[103.79,1.29],[103.81,1.29],[103.81,1.31],[103.79,1.31],[103.79,1.29]]]}`
+	var polygon Polygon
+	if err := json.Unmarshal([]byte(input), &polygon); err != nil {
+		t.Fatalf("Failed to unmarshal polygon: %v", err)
+	}
+	if polygon.Rings[0][1] != (Point{Lat: 1.29, Lng: 103.81}) {
+		t.Errorf("Expected [lng, lat] order, got %v", polygon.Rings[0][1])
+	}
+
+	output, err := json.Marshal(polygon)
+	if err != nil {
+		t.Fatalf("Failed to marshal polygon: %v", err)
+	}
+	if string(output) != input {
+		t.Errorf("Expected %s, got %s", input, output)
+	}
+
+	box := polygon.BoundingBox()
+	if box.MinLat != 1.29 || box.MaxLat != 1.31 || box.MinLng != 103.79 || box.MaxLng != 103.81 {
+		t.Errorf("Unexpected bounding box %+v", box)
+	}
+}
+
+func TestPolygonRejectsInvalidGeoJSON(t *testing.T) {
+	inputs := []string{
+		`{"type":"Point","coordinates":[103.8,1.3]}`,
+		`{"type":"Polygon","coordinates":[[[103.79,1.29],[103.81,1.29],[103.81,1.31],[103.79,1.31]]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,91],[0,0]]]}`,
+		`{"type":"Polygon","coordinates":[]}`,
+	}
+	for _, input := range inputs {
+		var polygon Polygon
+		if err := json.Unmarshal([]byte(input), &polygon); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	// One degree of latitude is about 111.2 km
+	d := DistanceMeters(Point{Lat: 0, Lng: 0}, Point{Lat: 1, Lng: 0})
+	if math.Abs(d-111195) > 100 {
+		t.Errorf("Expected about 111195m, got %f", d)
+	}
+	if DistanceMeters(Point{Lat: 1.3, Lng: 103.8}, Point{Lat: 1.3, Lng: 103.8}) != 0 {
+		t.Error("Expected zero distance for the same point")
+	}
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	center := Point{Lat: 1.3, Lng: 103.8}
+	box := BoundingBoxAround(center, 1000)
+	for _, p := range []Point{{Lat: 1.3089, Lng: 103.8}, {Lat: 1.3, Lng: 103.8089}} {
+		if DistanceMeters(center, p) < 1000 && !box.Contains(p) {
+			t.Errorf("Point %v within radius is outside the box %+v", p, box)
+		}
+	}
+	if box.Contains(Point{Lat: 1.32, Lng: 103.8}) {
+		t.Error("Point far outside the radius should be outside the box")
+	}
+}
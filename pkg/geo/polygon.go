@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Polygon is a GeoJSON polygon. The first ring is the outer boundary, any further rings are holes.
+// Rings are closed, i.e. the first and last positions are equal.
+type Polygon struct {
+	Rings [][]Point
+}
+
+type geoJSONPolygon struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// Validate checks that the polygon is a well-formed GeoJSON polygon
+func (p *Polygon) Validate() error {
+	if len(p.Rings) == 0 {
+		return fmt.Errorf("polygon must have at least one ring")
+	}
+	for i, ring := range p.Rings {
+		if len(ring) < 4 {
+			return fmt.Errorf("ring %d must have at least 4 positions", i)
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("ring %d must be closed", i)
+		}
+		for _, pt := range ring {
+			if pt.Lat < -90 || pt.Lat > 90 || pt.Lng < -180 || pt.Lng > 180 ||
+				math.IsNaN(pt.Lat) || math.IsNaN(pt.Lng) {
+				return fmt.Errorf("ring %d has an invalid position", i)
+			}
+		}
+	}
+	return nil
+}
+
+// Contains reports whether the point lies inside the outer ring and outside every hole
+func (p *Polygon) Contains(pt Point) bool {
+	if len(p.Rings) == 0 || !ringContains(p.Rings[0], pt) {
+		return false
+	}
+	for _, hole := range p.Rings[1:] {
+		if ringContains(hole, pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// BoundingBox returns the bounding box of the outer ring
+func (p *Polygon) BoundingBox() BoundingBox {
+	box := BoundingBox{MinLat: 90, MinLng: 180, MaxLat: -90, MaxLng: -180}
+	if len(p.Rings) == 0 {
+		return box
+	}
+	for _, pt := range p.Rings[0] {
+		box.MinLat = math.Min(box.MinLat, pt.Lat)
+		box.MaxLat = math.Max(box.MaxLat, pt.Lat)
+		box.MinLng = math.Min(box.MinLng, pt.Lng)
+		box.MaxLng = math.Max(box.MaxLng, pt.Lng)
+	}
+	return box
+}
+
+// ringContains uses ray casting to test whether the point is inside the ring
+func ringContains(ring []Point, pt Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lng < (b.Lng-a.Lng)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// MarshalJSON encodes the polygon as a GeoJSON geometry with [lng, lat] positions
+func (p Polygon) MarshalJSON() ([]byte, error) {
+	g := geoJSONPolygon{Type: "Polygon", Coordinates: make([][][2]float64, len(p.Rings))}
+	for i, ring := range p.Rings {
+		g.Coordinates[i] = make([][2]float64, len(ring))
+		for j, pt := range ring {
+			g.Coordinates[i][j] = [2]float64{pt.Lng, pt.Lat}
+		}
+	}
+	return json.Marshal(g)
+}
+
+// UnmarshalJSON decodes and validates a GeoJSON polygon geometry
+func (p *Polygon) UnmarshalJSON(data []byte) error {
+	var g geoJSONPolygon
+	if err := json.Unmarshal(data, &g); err != nil {
+		return fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if g.Type != "Polygon" {
+		return fmt.Errorf("GeoJSON type must be Polygon, got %q", g.Type)
+	}
+	rings := make([][]Point, len(g.Coordinates))
+	for i, ring := range g.Coordinates {
+		rings[i] = make([]Point, len(ring))
+		for j, position := range ring {
+			rings[i][j] = Point{Lng: position[0], Lat: position[1]}
+		}
+	}
+	polygon := Polygon{Rings: rings}
+	if err := polygon.Validate(); err != nil {
+		return err
+	}
+	*p = polygon
+	return nil
+}
+
+// Value stores the polygon as GeoJSON
+func (p Polygon) Value() (driver.Value, error) {
+	data, err := p.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads a polygon stored as GeoJSON
+func (p *Polygon) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return p.UnmarshalJSON(v)
+	case string:
+		return p.UnmarshalJSON([]byte(v))
+	default:
+		return fmt.Errorf("unsupported polygon value type %T", value)
+	}
+}
@@ -2,7 +2,7 @@ package kafka_client
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
@@ -11,23 +11,39 @@ type Consumer struct {
 	Reader *kafka.Reader
 }
 
+// NewConsumer creates a consumer of the topics of cCfg, or of the topic of cfg if none are set.
+// A consumer with a group ID commits offsets to the group, one without reads a single partition.
 func NewConsumer(cfg *Config, cCfg *ConsumerConfig) *Consumer {
-	return &Consumer{
-		Reader: kafka.NewReader(kafka.ReaderConfig{
-			Brokers: cfg.Brokers,
-			Topic:   cfg.Topic,
-			GroupID: cCfg.GroupID,
-			// MinBytes:    cCfg.MinBytes,
-			// MaxBytes:    cCfg.MaxBytes,
-			// StartOffset: cCfg.StartOffset,
-			// MaxWait:     time.Duration(cCfg.CommitInterval) * time.Millisecond,
-			// Partition:   cCfg.Partition,
-		}),
+	readerCfg := kafka.ReaderConfig{
+		Brokers:        cfg.Brokers,
+		GroupID:        cCfg.GroupID,
+		MinBytes:       cCfg.MinBytes,
+		MaxBytes:       cCfg.MaxBytes,
+		CommitInterval: time.Duration(cCfg.CommitInterval) * time.Millisecond,
+		StartOffset:    cCfg.StartOffset,
 	}
+	switch {
+	case cCfg.GroupID != "" && len(cCfg.Topics) > 0:
+		readerCfg.GroupTopics = cCfg.Topics
+	case len(cCfg.Topics) > 0:
+		readerCfg.Topic = cCfg.Topics[0]
+	default:
+		readerCfg.Topic = cfg.Topic
+	}
+	if cCfg.GroupID == "" {
+		readerCfg.Partition = cCfg.Partition
+	}
+	return &Consumer{Reader: kafka.NewReader(readerCfg)}
 }
-func (c *Consumer) ReadMessage(ctx context.Context) (kafka.Message, error) {
-	fmt.Println("Reading message")
-	return c.Reader.ReadMessage(ctx)
+
+// FetchMessage returns the next message without committing its offset
+func (c *Consumer) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	return c.Reader.FetchMessage(ctx)
+}
+
+// CommitMessages commits the offsets of the messages to the consumer group
+func (c *Consumer) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	return c.Reader.CommitMessages(ctx, msgs...)
 }
 
 func (c *Consumer) Close() error {
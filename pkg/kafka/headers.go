@@ -0,0 +1,38 @@
+package kafka_client
+
+import "github.com/segmentio/kafka-go"
+
+// Headers describing the event carried by a message, following the CloudEvents Kafka binding
+// for the ce_ attributes and W3C trace context for traceparent
+const (
+	HeaderEventID        = "ce_id"
+	HeaderEventType      = "ce_type"
+	HeaderIdempotencyKey = "idempotency-key"
+	HeaderTraceParent    = "traceparent"
+)
+
+// EventHeaders returns the headers of an event message. The event ID doubles as the idempotency
+// key, consumers use it to skip redelivered events. Empty values are left out.
+func EventHeaders(eventID string, eventType string, traceParent string) []kafka.Header {
+	var headers []kafka.Header
+	add := func(key string, value string) {
+		if value != "" {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+	}
+	add(HeaderEventID, eventID)
+	add(HeaderEventType, eventType)
+	add(HeaderIdempotencyKey, eventID)
+	add(HeaderTraceParent, traceParent)
+	return headers
+}
+
+// HeaderValue returns the value of the first header of the message with the key, or "" if it has none
+func HeaderValue(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
@@ -2,28 +2,76 @@ package kafka_client
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
 type Producer struct {
 	Writer *kafka.Writer
+	// defaultTopic and topics route the messages that do not name their topic
+	defaultTopic string
+	topics       map[string]string
 }
 
+// NewProducer creates a producer that writes every message to its own topic, to the topic
+// configured for its event type, or to the topic of config.
 func NewProducer(config *Config, pCfg *ProducerConfig) *Producer {
 	return &Producer{
-		Writer: kafka.NewWriter(kafka.WriterConfig{
-			Brokers:   config.Brokers,
-			Topic:     config.Topic,
-			BatchSize: pCfg.BatchSize,
-		}),
+		Writer: &kafka.Writer{
+			Addr:         kafka.TCP(config.Brokers...),
+			BatchSize:    pCfg.BatchSize,
+			BatchTimeout: time.Duration(pCfg.BatchTimeout) * time.Millisecond,
+			Async:        pCfg.Async,
+			RequiredAcks: requiredAcks(pCfg.RequiredAcks),
+			Completion:   pCfg.OnDelivery,
+			// Messages with the same key go to the same partition so their order is kept
+			Balancer: &kafka.Hash{},
+		},
+		defaultTopic: config.Topic,
+		topics:       pCfg.Topics,
 	}
 }
+
+// WriteMessages routes the messages to their topic and writes them. In async mode it returns
+// before the messages are delivered, their errors are passed to the OnDelivery callback.
 func (p *Producer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
-	return p.Writer.WriteMessages(ctx, msgs...)
+	routed := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		if msg.Topic == "" {
+			msg.Topic = p.Topic(HeaderValue(msg, HeaderEventType))
+			if msg.Topic == "" {
+				return fmt.Errorf("no topic configured for event type %q", HeaderValue(msg, HeaderEventType))
+			}
+		}
+		routed[i] = msg
+	}
+	return p.Writer.WriteMessages(ctx, routed...)
+}
+
+// Topic returns the topic of the event type
+func (p *Producer) Topic(eventType string) string {
+	return RouteTopic(p.topics, p.defaultTopic, eventType)
+}
+
+// RouteTopic returns the topic routes maps the event type to, or defaultTopic
+func RouteTopic(routes map[string]string, defaultTopic string, eventType string) string {
+	if topic, ok := routes[eventType]; ok {
+		return topic
+	}
+	return defaultTopic
 }
 
-// Close closes the producer writer.
+// Close flushes the pending messages and closes the producer writer.
 func (p *Producer) Close() error {
 	return p.Writer.Close()
 }
+
+// requiredAcks maps the configured acks, unset keeps the safe default of waiting for all replicas
+func requiredAcks(acks *int) kafka.RequiredAcks {
+	if acks == nil {
+		return kafka.RequireAll
+	}
+	return kafka.RequiredAcks(*acks)
+}
@@ -0,0 +1,56 @@
+package kafka_client
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestProducerTopic(t *testing.T) {
+	producer := NewProducer(&Config{Brokers: []string{"localhost:9092"}, Topic: "user.events"}, &ProducerConfig{
+		Topics: map[string]string{"premise.created": "premise.events"},
+	})
+	defer producer.Close()
+
+	if got := producer.Topic("premise.created"); got != "premise.events" {
+		t.Errorf("Expected premise.events, got %s", got)
+	}
+	if got := producer.Topic("user.created"); got != "user.events" {
+		t.Errorf("Expected the default topic, got %s", got)
+	}
+	if producer.Writer.RequiredAcks != kafka.RequireAll {
+		t.Errorf("Expected acks from all replicas by default, got %v", producer.Writer.RequiredAcks)
+	}
+}
+
+func TestProducerRequiredAcks(t *testing.T) {
+	for acks, expected := range map[int]kafka.RequiredAcks{-1: kafka.RequireAll, 0: kafka.RequireNone, 1: kafka.RequireOne} {
+		producer := NewProducer(&Config{Brokers: []string{"localhost:9092"}}, &ProducerConfig{RequiredAcks: &acks})
+		if producer.Writer.RequiredAcks != expected {
+			t.Errorf("Expected %d acks to map to %v, got %v", acks, expected, producer.Writer.RequiredAcks)
+		}
+		producer.Close()
+	}
+}
+
+func TestEventHeaders(t *testing.T) {
+	msg := kafka.Message{Headers: EventHeaders("3f0c", "user.created", "")}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{HeaderEventID, "3f0c"},
+		{HeaderEventType, "user.created"},
+		{HeaderIdempotencyKey, "3f0c"},
+		{HeaderTraceParent, ""},
+	}
+	for _, tt := range tests {
+		if got := HeaderValue(msg, tt.key); got != tt.expected {
+			t.Errorf("Expected %s to be %q, got %q", tt.key, tt.expected, got)
+		}
+	}
+	if len(msg.Headers) != 3 {
+		t.Errorf("Expected the empty traceparent to be left out, got %d headers", len(msg.Headers))
+	}
+}
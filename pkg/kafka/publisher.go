@@ -0,0 +1,35 @@
+package kafka_client
+
+import (
+	"context"
+	"scs-user/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Publisher publishes messages, a Producer writes them to Kafka
+type Publisher interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// LogPublisher logs the messages instead of publishing them, for local development without Kafka
+type LogPublisher struct {
+	logger logger.Logger
+}
+
+func NewLogPublisher(logger logger.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (p *LogPublisher) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		eventType, _ := messageType(msg)
+		p.logger.Infof("Not publishing %s message with key %s to %q: %s", eventType, msg.Key, msg.Topic, msg.Value)
+	}
+	return nil
+}
+
+func (p *LogPublisher) Close() error {
+	return nil
+}
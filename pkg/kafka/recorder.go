@@ -0,0 +1,95 @@
+package kafka_client
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Recorder is an in-memory Publisher that records the messages, for testing code that publishes
+type Recorder struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	failures []error
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// WriteMessages records the messages, or fails with the next queued error without recording them
+func (r *Recorder) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.failures) > 0 {
+		err := r.failures[0]
+		r.failures = r.failures[1:]
+		return err
+	}
+	r.messages = append(r.messages, msgs...)
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	return nil
+}
+
+// FailNext makes the next writes fail with the errors, one write per error
+func (r *Recorder) FailNext(errs ...error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, errs...)
+}
+
+// Messages returns the recorded messages in the order they were written
+func (r *Recorder) Messages() []kafka.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]kafka.Message(nil), r.messages...)
+}
+
+// MessagesOfType returns the recorded messages of the event type
+func (r *Recorder) MessagesOfType(eventType string) []kafka.Message {
+	var messages []kafka.Message
+	for _, msg := range r.Messages() {
+		if t, _ := messageType(msg); t == eventType {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Reset forgets the recorded messages and the queued failures
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = nil
+	r.failures = nil
+}
+
+// AssertPublished fails the test unless exactly one message of the event type was recorded,
+// and returns it
+func (r *Recorder) AssertPublished(t testing.TB, eventType string) kafka.Message {
+	t.Helper()
+	messages := r.MessagesOfType(eventType)
+	if len(messages) != 1 {
+		t.Fatalf("Expected one %s message, got %d", eventType, len(messages))
+	}
+	return messages[0]
+}
+
+// AssertCount fails the test unless count messages of the event type were recorded
+func (r *Recorder) AssertCount(t testing.TB, eventType string, count int) {
+	t.Helper()
+	if got := len(r.MessagesOfType(eventType)); got != count {
+		t.Errorf("Expected %d %s messages, got %d", count, eventType, got)
+	}
+}
+
+// AssertNotPublished fails the test if a message of the event type was recorded
+func (r *Recorder) AssertNotPublished(t testing.TB, eventType string) {
+	t.Helper()
+	r.AssertCount(t, eventType, 0)
+}
@@ -0,0 +1,42 @@
+package kafka_client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var _ Publisher = (*Producer)(nil)
+var _ Publisher = (*LogPublisher)(nil)
+var _ Publisher = (*Recorder)(nil)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	recorder := NewRecorder()
+	recorder.FailNext(errors.New("broker unavailable"))
+
+	created := kafka.Message{Key: []byte("1"), Headers: EventHeaders("a", "user.created", "")}
+	if err := recorder.WriteMessages(ctx, created); err == nil {
+		t.Fatal("Expected the queued failure")
+	}
+	recorder.AssertNotPublished(t, "user.created")
+
+	snapshot := kafka.Message{Key: []byte("1"), Value: []byte(`{"type":"user.snapshot"}`)}
+	if err := recorder.WriteMessages(ctx, created, snapshot, snapshot); err != nil {
+		t.Fatalf("WriteMessages returned %v", err)
+	}
+	if msg := recorder.AssertPublished(t, "user.created"); HeaderValue(msg, HeaderEventID) != "a" {
+		t.Errorf("Expected the user.created message, got %v", msg)
+	}
+	recorder.AssertCount(t, "user.snapshot", 2)
+	if got := len(recorder.Messages()); got != 3 {
+		t.Errorf("Expected 3 messages, got %d", got)
+	}
+
+	recorder.Reset()
+	if got := len(recorder.Messages()); got != 0 {
+		t.Errorf("Expected no messages after Reset, got %d", got)
+	}
+}
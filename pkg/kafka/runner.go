@@ -0,0 +1,261 @@
+package kafka_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"scs-user/pkg/logger"
+	"scs-user/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers set on the messages routed to a dead-letter topic
+const (
+	HeaderDLQError             = "dlq-error"
+	HeaderDLQOriginalTopic     = "dlq-original-topic"
+	HeaderDLQOriginalPartition = "dlq-original-partition"
+	HeaderDLQOriginalOffset    = "dlq-original-offset"
+	HeaderDLQAttempts          = "dlq-attempts"
+	HeaderDLQFailedAt          = "dlq-failed-at"
+)
+
+// MessageReader fetches messages and commits their offsets once processed
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// MessageWriter writes messages, each message naming its topic
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// HandlerFunc processes a message. Returned errors are retried unless wrapped with Permanent.
+type HandlerFunc func(ctx context.Context, msg kafka.Message) error
+
+// Registry maps event types to their handlers
+type Registry struct {
+	handlers map[string]HandlerFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle registers the handler of an event type. It panics if the type already has a handler.
+func (r *Registry) Handle(eventType string, handler HandlerFunc) {
+	if _, ok := r.handlers[eventType]; ok {
+		panic(fmt.Sprintf("kafka: handler already registered for %s", eventType))
+	}
+	r.handlers[eventType] = handler
+}
+
+// Len returns the number of registered handlers
+func (r *Registry) Len() int {
+	return len(r.handlers)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix, the message goes to the dead-letter topic right away
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type RunnerConfig struct {
+	// MaxRetries is the number of retries after the first attempt before a message is dead-lettered
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// DLQSuffix is appended to the topic of a message to get its dead-letter topic
+	DLQSuffix string
+}
+
+// Runner consumes messages and dispatches them by event type to the handlers of a registry.
+// The offset of a message is committed only once it was handled or written to its dead-letter
+// topic, so a message is processed at least once.
+type Runner struct {
+	reader   MessageReader
+	dlq      MessageWriter
+	registry *Registry
+	cfg      RunnerConfig
+	logger   logger.Logger
+}
+
+func NewRunner(reader MessageReader, dlq MessageWriter, registry *Registry, cfg RunnerConfig, logger logger.Logger) *Runner {
+	return &Runner{reader: reader, dlq: dlq, registry: registry, cfg: cfg, logger: logger}
+}
+
+// Run processes messages until ctx is cancelled or the reader is closed. A message being handled
+// when ctx is cancelled is finished first; a message waiting for a retry is left uncommitted and
+// redelivered later. Failed fetches and commits are retried with backoff, so the consumer keeps
+// running while the brokers are unavailable.
+func (r *Runner) Run(ctx context.Context) error {
+	for {
+		msg, err := r.fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := r.process(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := r.commit(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// fetch returns the next message, retrying until it succeeds, ctx is cancelled or the reader is closed
+func (r *Runner) fetch(ctx context.Context) (kafka.Message, error) {
+	for attempt := 1; ; attempt++ {
+		msg, err := r.reader.FetchMessage(ctx)
+		if err == nil || ctx.Err() != nil || errors.Is(err, io.EOF) {
+			return msg, err
+		}
+		r.logger.Errorf("Failed to fetch message, attempt %d: %v", attempt, err)
+		if err := sleep(ctx, r.backoff(attempt)); err != nil {
+			return kafka.Message{}, err
+		}
+	}
+}
+
+// commit commits the offset of the message, retrying until it succeeds or ctx is cancelled. An
+// uncommitted message is redelivered after a restart.
+func (r *Runner) commit(ctx context.Context, msg kafka.Message) error {
+	for attempt := 1; ; attempt++ {
+		// Commit even once ctx is cancelled, the message is done
+		err := r.reader.CommitMessages(context.WithoutCancel(ctx), msg)
+		if err == nil {
+			return nil
+		}
+		r.logger.Errorf("Failed to commit %s[%d]@%d, attempt %d: %v", msg.Topic, msg.Partition, msg.Offset, attempt, err)
+		if err := sleep(ctx, r.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// process handles the message or routes it to its dead-letter topic. It returns an error only
+// if ctx was cancelled before either succeeded.
+func (r *Runner) process(ctx context.Context, msg kafka.Message) error {
+	eventType, err := messageType(msg)
+	if err != nil {
+		return r.deadLetter(ctx, msg, Permanent(err), 1)
+	}
+	handler, ok := r.registry.handlers[eventType]
+	if !ok {
+		return nil
+	}
+
+	attempt := 1
+	for {
+		// Handlers are not interrupted by shutdown, a message is either handled or not
+		err := handler(context.WithoutCancel(ctx), msg)
+		if err == nil {
+			return nil
+		}
+		if IsPermanent(err) || attempt > r.cfg.MaxRetries {
+			return r.deadLetter(ctx, msg, err, attempt)
+		}
+		r.logger.Warnf("Handler for %s failed on %s[%d]@%d, attempt %d: %v", eventType, msg.Topic, msg.Partition, msg.Offset, attempt, err)
+		if err := sleep(ctx, r.backoff(attempt)); err != nil {
+			return err
+		}
+		attempt++
+	}
+}
+
+// deadLetter writes the message to its dead-letter topic, retrying until it succeeds or ctx is cancelled
+func (r *Runner) deadLetter(ctx context.Context, msg kafka.Message, cause error, attempts int) error {
+	r.logger.Errorf("Routing %s[%d]@%d to dead-letter topic after %d attempts: %v", msg.Topic, msg.Partition, msg.Offset, attempts, cause)
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDLQOriginalTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderDLQFailedAt, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+	dead := kafka.Message{
+		Topic:   msg.Topic + r.cfg.DLQSuffix,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.dlq.WriteMessages(ctx, dead)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.logger.Errorf("Failed to write to dead-letter topic %s, attempt %d: %v", dead.Topic, attempt, err)
+		if err := sleep(ctx, r.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff returns the exponential delay after the given attempt
+func (r *Runner) backoff(attempt int) time.Duration {
+	return utils.Backoff(attempt, r.cfg.RetryBackoff, r.cfg.MaxRetryBackoff)
+}
+
+// messageType reads the event type of the message from its header, or else from its envelope
+func messageType(msg kafka.Message) (string, error) {
+	if eventType := HeaderValue(msg, HeaderEventType); eventType != "" {
+		return eventType, nil
+	}
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+		return "", fmt.Errorf("failed to decode message envelope: %w", err)
+	}
+	if envelope.Type == "" {
+		return "", errors.New("message envelope has no type")
+	}
+	return envelope.Type, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
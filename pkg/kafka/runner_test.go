@@ -0,0 +1,256 @@
+package kafka_client
+
+import (
+	"context"
+	"errors"
+	"scs-user/config"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) InitLogger(cfg *config.Config)                {}
+func (nopLogger) Debug(args ...interface{})                    {}
+func (nopLogger) Debugf(template string, args ...interface{})  {}
+func (nopLogger) Info(args ...interface{})                     {}
+func (nopLogger) Infof(template string, args ...interface{})   {}
+func (nopLogger) Warn(args ...interface{})                     {}
+func (nopLogger) Warnf(template string, args ...interface{})   {}
+func (nopLogger) Error(args ...interface{})                    {}
+func (nopLogger) Errorf(template string, args ...interface{})  {}
+func (nopLogger) DPanic(args ...interface{})                   {}
+func (nopLogger) DPanicf(template string, args ...interface{}) {}
+func (nopLogger) Fatal(args ...interface{})                    {}
+func (nopLogger) Fatalf(template string, args ...interface{})  {}
+func (nopLogger) IsInitialized() bool                          { return true }
+
+// fakeReader returns its messages in order and then cancels the run. The first fetchFailures
+// fetches and commitFailures commits fail.
+type fakeReader struct {
+	messages       []kafka.Message
+	committed      []int64
+	cancel         context.CancelFunc
+	fetchFailures  int
+	commitFailures int
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if r.fetchFailures > 0 {
+		r.fetchFailures--
+		return kafka.Message{}, errors.New("broker unavailable")
+	}
+	if len(r.messages) == 0 {
+		r.cancel()
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+	return msg, nil
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if r.commitFailures > 0 {
+		r.commitFailures--
+		return errors.New("group coordinator unavailable")
+	}
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	return nil
+}
+
+type fakeWriter struct {
+	mu       sync.Mutex
+	failures int
+	written  []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("broker unavailable")
+	}
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func message(offset int64, value string) kafka.Message {
+	return kafka.Message{Topic: "premise.events", Partition: 2, Offset: offset, Value: []byte(value)}
+}
+
+func runMessages(t *testing.T, registry *Registry, dlq *fakeWriter, messages ...kafka.Message) *fakeReader {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader := &fakeReader{messages: messages, cancel: cancel}
+	cfg := RunnerConfig{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: 4 * time.Millisecond, DLQSuffix: ".dlq"}
+	if err := NewRunner(reader, dlq, registry, cfg, nopLogger{}).Run(ctx); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	return reader
+}
+
+func TestRunnerDispatch(t *testing.T) {
+	var handled []int64
+	registry := NewRegistry()
+	registry.Handle("premise.created", func(ctx context.Context, msg kafka.Message) error {
+		handled = append(handled, msg.Offset)
+		return nil
+	})
+	dlq := &fakeWriter{}
+
+	reader := runMessages(t, registry, dlq,
+		message(1, `{"type":"premise.created","data":{}}`),
+		message(2, `{"type":"premise.unknown","data":{}}`),
+		message(3, `{"type":"premise.created","data":{}}`),
+	)
+
+	if len(handled) != 2 || handled[0] != 1 || handled[1] != 3 {
+		t.Errorf("Expected offsets 1 and 3 to be handled, got %v", handled)
+	}
+	if len(reader.committed) != 3 {
+		t.Errorf("Expected all 3 messages to be committed, got %v", reader.committed)
+	}
+	if len(dlq.written) != 0 {
+		t.Errorf("Expected no dead-lettered messages, got %d", len(dlq.written))
+	}
+}
+
+func TestRunnerRetries(t *testing.T) {
+	attempts := 0
+	registry := NewRegistry()
+	registry.Handle("premise.updated", func(ctx context.Context, msg kafka.Message) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+	dlq := &fakeWriter{}
+
+	reader := runMessages(t, registry, dlq, message(7, `{"type":"premise.updated"}`))
+
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	if len(reader.committed) != 1 || len(dlq.written) != 0 {
+		t.Errorf("Expected the message to be committed after the retries, committed %v, dead-lettered %d", reader.committed, len(dlq.written))
+	}
+}
+
+func TestRunnerDeadLetter(t *testing.T) {
+	attempts := 0
+	registry := NewRegistry()
+	registry.Handle("premise.updated", func(ctx context.Context, msg kafka.Message) error {
+		attempts++
+		return errors.New("database unavailable")
+	})
+	registry.Handle("premise.deleted", func(ctx context.Context, msg kafka.Message) error {
+		return Permanent(errors.New("invalid premise ID"))
+	})
+	dlq := &fakeWriter{failures: 1}
+
+	reader := runMessages(t, registry, dlq,
+		message(10, `{"type":"premise.updated"}`),
+		message(11, `{"type":"premise.deleted"}`),
+		message(12, `not json`),
+	)
+
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts before dead-lettering, got %d", attempts)
+	}
+	if len(reader.committed) != 3 {
+		t.Errorf("Expected all 3 messages to be committed, got %v", reader.committed)
+	}
+	if len(dlq.written) != 3 {
+		t.Fatalf("Expected 3 dead-lettered messages, got %d", len(dlq.written))
+	}
+	tests := []struct {
+		offset   string
+		attempts string
+		err      string
+	}{
+		{"10", "3", "database unavailable"},
+		{"11", "1", "invalid premise ID"},
+		{"12", "1", "failed to decode message envelope: invalid character 'o' in literal null (expecting 'u')"},
+	}
+	for i, tt := range tests {
+		msg := dlq.written[i]
+		if msg.Topic != "premise.events.dlq" {
+			t.Errorf("Expected topic premise.events.dlq, got %s", msg.Topic)
+		}
+		if got := HeaderValue(msg, HeaderDLQOriginalOffset); got != tt.offset {
+			t.Errorf("Expected original offset %s, got %s", tt.offset, got)
+		}
+		if got := HeaderValue(msg, HeaderDLQOriginalPartition); got != "2" {
+			t.Errorf("Expected original partition 2, got %s", got)
+		}
+		if got := HeaderValue(msg, HeaderDLQAttempts); got != tt.attempts {
+			t.Errorf("Expected %s attempts for offset %s, got %s", tt.attempts, tt.offset, got)
+		}
+		if got := HeaderValue(msg, HeaderDLQError); got != tt.err {
+			t.Errorf("Expected error %q, got %q", tt.err, got)
+		}
+	}
+}
+
+func TestRunnerShutdownDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	registry := NewRegistry()
+	registry.Handle("premise.updated", func(ctx context.Context, msg kafka.Message) error {
+		cancel()
+		return errors.New("database unavailable")
+	})
+	reader := &fakeReader{messages: []kafka.Message{message(1, `{"type":"premise.updated"}`)}, cancel: cancel}
+	cfg := RunnerConfig{MaxRetries: 5, RetryBackoff: time.Minute, MaxRetryBackoff: time.Minute, DLQSuffix: ".dlq"}
+
+	if err := NewRunner(reader, &fakeWriter{}, registry, cfg, nopLogger{}).Run(ctx); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	if len(reader.committed) != 0 {
+		t.Errorf("Expected the message to be left uncommitted, got %v", reader.committed)
+	}
+}
+
+func TestRunnerRetriesFetchAndCommit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var handled []int64
+	registry := NewRegistry()
+	registry.Handle("premise.created", func(ctx context.Context, msg kafka.Message) error {
+		handled = append(handled, msg.Offset)
+		return nil
+	})
+	reader := &fakeReader{
+		messages:       []kafka.Message{message(1, `{"type":"premise.created"}`), message(2, `{"type":"premise.created"}`)},
+		cancel:         cancel,
+		fetchFailures:  2,
+		commitFailures: 2,
+	}
+	cfg := RunnerConfig{MaxRetries: 2, RetryBackoff: time.Millisecond, MaxRetryBackoff: 4 * time.Millisecond, DLQSuffix: ".dlq"}
+
+	if err := NewRunner(reader, &fakeWriter{}, registry, cfg, nopLogger{}).Run(ctx); err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+	if len(handled) != 2 || len(reader.committed) != 2 {
+		t.Errorf("Expected both messages to be handled once and committed, handled %v, committed %v", handled, reader.committed)
+	}
+}
+
+func TestRegistryDuplicateHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.Handle("premise.created", func(ctx context.Context, msg kafka.Message) error { return nil })
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic on a duplicate handler")
+		}
+	}()
+	registry.Handle("premise.created", func(ctx context.Context, msg kafka.Message) error { return nil })
+}
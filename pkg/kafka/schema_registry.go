@@ -0,0 +1,151 @@
+package kafka_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// SchemaType is the type of a schema in the schema registry
+type SchemaType string
+
+const (
+	SchemaTypeAvro     SchemaType = "AVRO"
+	SchemaTypeProtobuf SchemaType = "PROTOBUF"
+	SchemaTypeJSON     SchemaType = "JSON"
+)
+
+// schemaRegistryContentType is the content type of the schema registry API
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// Schema is a schema stored in the schema registry
+type Schema struct {
+	Type       SchemaType
+	Definition string
+}
+
+// SchemaRegistryClient is a client of the Confluent schema registry API. Registered schema IDs
+// and fetched schemas are cached, they never change once assigned.
+type SchemaRegistryClient struct {
+	url        string
+	httpClient *http.Client
+
+	mu      sync.RWMutex
+	ids     map[string]int
+	schemas map[int]Schema
+}
+
+func NewSchemaRegistryClient(url string, httpClient *http.Client) *SchemaRegistryClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &SchemaRegistryClient{
+		url:        strings.TrimRight(url, "/"),
+		httpClient: httpClient,
+		ids:        make(map[string]int),
+		schemas:    make(map[int]Schema),
+	}
+}
+
+// schemaPayload is the schema in the requests and responses of the registry. The schema type
+// is left out for Avro, the default type.
+type schemaPayload struct {
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType,omitempty"`
+	ID         int        `json:"id,omitempty"`
+}
+
+// registryError is the error body of the registry
+type registryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// Register registers the schema under the subject, unless it already is, and returns its ID
+func (c *SchemaRegistryClient) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	cacheKey := subject + "\x00" + string(schema.Type) + "\x00" + schema.Definition
+	c.mu.RLock()
+	id, ok := c.ids[cacheKey]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	request := schemaPayload{Schema: schema.Definition}
+	if schema.Type != SchemaTypeAvro {
+		request.SchemaType = schema.Type
+	}
+	var response schemaPayload
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", request, &response); err != nil {
+		return 0, fmt.Errorf("failed to register schema for %s: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	c.ids[cacheKey] = response.ID
+	c.schemas[response.ID] = schema
+	c.mu.Unlock()
+	return response.ID, nil
+}
+
+// GetSchema returns the schema with the ID
+func (c *SchemaRegistryClient) GetSchema(ctx context.Context, id int) (Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var response schemaPayload
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &response); err != nil {
+		return Schema{}, fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+	schema = Schema{Type: response.SchemaType, Definition: response.Schema}
+	if schema.Type == "" {
+		schema.Type = SchemaTypeAvro
+	}
+
+	c.mu.Lock()
+	c.schemas[id] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+
+func (c *SchemaRegistryClient) do(ctx context.Context, method string, path string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		var registryErr registryError
+		if err := json.NewDecoder(res.Body).Decode(&registryErr); err != nil || registryErr.Message == "" {
+			return fmt.Errorf("schema registry returned status %d", res.StatusCode)
+		}
+		return fmt.Errorf("schema registry error %d: %s", registryErr.ErrorCode, registryErr.Message)
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
@@ -0,0 +1,110 @@
+package kafka_client
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FakeSchemaRegistry is an in-memory schema registry serving the parts of the Confluent API used
+// by SchemaRegistryClient, for tests. Serve it with httptest.NewServer. Like the real registry, it
+// gives the same schema the same ID in every subject.
+type FakeSchemaRegistry struct {
+	mu       sync.Mutex
+	schemas  []Schema
+	subjects map[string][]int
+	requests int
+}
+
+func NewFakeSchemaRegistry() *FakeSchemaRegistry {
+	return &FakeSchemaRegistry{subjects: make(map[string][]int)}
+}
+
+// Subjects returns the schema IDs registered under each subject, in registration order
+func (f *FakeSchemaRegistry) Subjects() map[string][]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subjects := make(map[string][]int, len(f.subjects))
+	for subject, ids := range f.subjects {
+		subjects[subject] = append([]int(nil), ids...)
+	}
+	return subjects
+}
+
+// Requests returns the number of requests served
+func (f *FakeSchemaRegistry) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *FakeSchemaRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+
+	w.Header().Set("Content-Type", schemaRegistryContentType)
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "subjects/") && strings.HasSuffix(path, "/versions"):
+		subject := strings.TrimSuffix(strings.TrimPrefix(path, "subjects/"), "/versions")
+		f.register(w, r, subject)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "schemas/ids/"):
+		f.getSchema(w, strings.TrimPrefix(path, "schemas/ids/"))
+	default:
+		writeRegistryError(w, http.StatusNotFound, 404, "HTTP 404 Not Found")
+	}
+}
+
+func (f *FakeSchemaRegistry) register(w http.ResponseWriter, r *http.Request, subject string) {
+	var request schemaPayload
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Schema == "" {
+		writeRegistryError(w, http.StatusUnprocessableEntity, 42201, "Invalid schema")
+		return
+	}
+	schema := Schema{Type: request.SchemaType, Definition: request.Schema}
+	if schema.Type == "" {
+		schema.Type = SchemaTypeAvro
+	}
+
+	id := 0
+	for i, existing := range f.schemas {
+		if existing == schema {
+			id = i + 1
+			break
+		}
+	}
+	if id == 0 {
+		f.schemas = append(f.schemas, schema)
+		id = len(f.schemas)
+	}
+	registered := false
+	for _, existing := range f.subjects[subject] {
+		registered = registered || existing == id
+	}
+	if !registered {
+		f.subjects[subject] = append(f.subjects[subject], id)
+	}
+	_ = json.NewEncoder(w).Encode(schemaPayload{ID: id})
+}
+
+func (f *FakeSchemaRegistry) getSchema(w http.ResponseWriter, rawID string) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id < 1 || id > len(f.schemas) {
+		writeRegistryError(w, http.StatusNotFound, 40403, "Schema not found")
+		return
+	}
+	schema := f.schemas[id-1]
+	response := schemaPayload{Schema: schema.Definition}
+	if schema.Type != SchemaTypeAvro {
+		response.SchemaType = schema.Type
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func writeRegistryError(w http.ResponseWriter, status int, code int, message string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(registryError{ErrorCode: code, Message: message})
+}
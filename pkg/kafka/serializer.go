@@ -0,0 +1,117 @@
+package kafka_client
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Serializer encodes and decodes the values of the messages of a topic
+type Serializer interface {
+	Serialize(ctx context.Context, topic string, value any) ([]byte, error)
+	Deserialize(ctx context.Context, topic string, data []byte, target any) error
+}
+
+// wireMagicByte starts the values in the Confluent wire format, followed by the big-endian
+// schema ID and the encoded value
+const wireMagicByte = 0
+
+// ValueSubject is the schema registry subject of the values of the topic
+func ValueSubject(topic string) string {
+	return topic + "-value"
+}
+
+// RecordSubject is the schema registry subject of the values of one record type in the topic,
+// for topics carrying several types
+func RecordSubject(topic string, record string) string {
+	return topic + "-" + record
+}
+
+// encodeWire prefixes the payload with the schema ID in the Confluent wire format
+func encodeWire(schemaID int, payload []byte) []byte {
+	data := make([]byte, 5, 5+len(payload))
+	data[0] = wireMagicByte
+	binary.BigEndian.PutUint32(data[1:5], uint32(schemaID))
+	return append(data, payload...)
+}
+
+// decodeWire returns the schema ID and the payload of a value in the Confluent wire format
+func decodeWire(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != wireMagicByte {
+		return 0, nil, errors.New("value is not in the schema registry wire format")
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
+
+// JSONSerializer encodes values as JSON. With a registry, the JSON Schema of the serializer is
+// registered for the topic and its ID embedded in the wire format, without one plain JSON is used.
+type JSONSerializer struct {
+	registry *SchemaRegistryClient
+	schema   string
+	// record names the subject with RecordSubject instead of ValueSubject if set
+	record string
+}
+
+func NewJSONSerializer(registry *SchemaRegistryClient, schema string) *JSONSerializer {
+	return &JSONSerializer{registry: registry, schema: schema}
+}
+
+// NewJSONRecordSerializer registers the schema under the RecordSubject of the record type, so
+// that the values of other types in the same topic do not have to be compatible with it
+func NewJSONRecordSerializer(registry *SchemaRegistryClient, schema string, record string) *JSONSerializer {
+	return &JSONSerializer{registry: registry, schema: schema, record: record}
+}
+
+func (s *JSONSerializer) subject(topic string) string {
+	if s.record != "" {
+		return RecordSubject(topic, s.record)
+	}
+	return ValueSubject(topic)
+}
+
+func (s *JSONSerializer) Serialize(ctx context.Context, topic string, value any) ([]byte, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON value: %w", err)
+	}
+	if s.registry == nil {
+		return payload, nil
+	}
+	id, err := s.registry.Register(ctx, s.subject(topic), Schema{Type: SchemaTypeJSON, Definition: s.schema})
+	if err != nil {
+		return nil, err
+	}
+	return encodeWire(id, payload), nil
+}
+
+func (s *JSONSerializer) Deserialize(ctx context.Context, topic string, data []byte, target any) error {
+	payload := data
+	if s.registry != nil {
+		id, wirePayload, err := decodeWire(data)
+		if err != nil {
+			return err
+		}
+		if err := expectSchemaType(ctx, s.registry, id, SchemaTypeJSON); err != nil {
+			return err
+		}
+		payload = wirePayload
+	}
+	if err := json.Unmarshal(payload, target); err != nil {
+		return fmt.Errorf("failed to decode JSON value: %w", err)
+	}
+	return nil
+}
+
+// expectSchemaType fails unless the schema with the ID has the type
+func expectSchemaType(ctx context.Context, registry *SchemaRegistryClient, id int, schemaType SchemaType) error {
+	schema, err := registry.GetSchema(ctx, id)
+	if err != nil {
+		return err
+	}
+	if schema.Type != schemaType {
+		return fmt.Errorf("value was written with a %s schema, expected %s", schema.Type, schemaType)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package kafka_client
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry(t *testing.T) (*FakeSchemaRegistry, *SchemaRegistryClient) {
+	t.Helper()
+	fake := NewFakeSchemaRegistry()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, NewSchemaRegistryClient(server.URL, server.Client())
+}
+
+func TestJSONSerializer(t *testing.T) {
+	ctx := context.Background()
+	fake, registry := newTestRegistry(t)
+	value := map[string]string{"user_id": "1"}
+
+	plain, err := NewJSONSerializer(nil, "").Serialize(ctx, "users", value)
+	if err != nil || string(plain) != `{"user_id":"1"}` {
+		t.Errorf("Expected plain JSON, got %s, %v", plain, err)
+	}
+
+	serializer := NewJSONSerializer(registry, `{"type":"object"}`)
+	for i := 0; i < 2; i++ {
+		data, err := serializer.Serialize(ctx, "users", value)
+		if err != nil {
+			t.Fatalf("Serialize returned %v", err)
+		}
+		if data[0] != 0 || data[4] != 1 {
+			t.Errorf("Expected the wire format header of schema 1, got %v", data[:5])
+		}
+
+		// A new client has to fetch the schema type from the registry
+		reader := NewJSONSerializer(NewSchemaRegistryClient(registry.url, nil), `{"type":"object"}`)
+		var decoded map[string]string
+		if err := reader.Deserialize(ctx, "users", data, &decoded); err != nil {
+			t.Fatalf("Deserialize returned %v", err)
+		}
+		if decoded["user_id"] != "1" {
+			t.Errorf("Expected user 1, got %v", decoded)
+		}
+	}
+	if subjects := fake.Subjects(); !slices.Equal(subjects["users-value"], []int{1}) {
+		t.Errorf("Expected schema 1 registered for users-value, got %v", subjects)
+	}
+	// One registration and one schema lookup per new client, the IDs are cached
+	if got := fake.Requests(); got != 3 {
+		t.Errorf("Expected 3 registry requests, got %d", got)
+	}
+
+	// A value written with a schema of another type is rejected
+	id, err := registry.Register(ctx, "users-value", Schema{Type: SchemaTypeAvro, Definition: `"string"`})
+	if err != nil {
+		t.Fatalf("Register returned %v", err)
+	}
+	var decoded map[string]string
+	if err := serializer.Deserialize(ctx, "users", encodeWire(id, []byte(`{}`)), &decoded); err == nil || !strings.Contains(err.Error(), "AVRO") {
+		t.Errorf("Expected a schema type error, got %v", err)
+	}
+}
+
+func TestSchemaRegistryError(t *testing.T) {
+	_, registry := newTestRegistry(t)
+	_, err := registry.Register(context.Background(), "users-value", Schema{Type: SchemaTypeJSON})
+	if err == nil || !strings.Contains(err.Error(), "42201") {
+		t.Errorf("Expected the registry error, got %v", err)
+	}
+	if _, err := registry.GetSchema(context.Background(), 99); err == nil || !strings.Contains(err.Error(), "40403") {
+		t.Errorf("Expected schema not found, got %v", err)
+	}
+}
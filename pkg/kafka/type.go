@@ -1,20 +1,30 @@
 // ProducerConfig specific configuration for producers.
 package kafka_client
 
+import "github.com/segmentio/kafka-go"
+
 type Config struct {
 	Brokers []string
-	Topic   string
+	// Topic is the default topic of the producer messages
+	Topic string
 }
 
 type ProducerConfig struct {
 	BatchSize    int
 	BatchTimeout int // In milliseconds
 	Async        bool
-	RequiredAcks int
+	RequiredAcks *int // -1 or nil for all in-sync replicas, 1 for the leader only, 0 for none
+	// Topics maps event types to the topic they are published to
+	Topics map[string]string
+	// OnDelivery is called with every written batch and its error, in async mode it is
+	// the only way to learn about failed deliveries
+	OnDelivery func(messages []kafka.Message, err error)
 }
 
 // ConsumerConfig specific configuration for consumers.
 type ConsumerConfig struct {
+	// Topics lists the topics to consume, several topics require a group ID
+	Topics         []string
 	GroupID        string
 	Partition      int
 	MinBytes       int
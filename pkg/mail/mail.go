@@ -0,0 +1,56 @@
+// Package mail renders the transactional emails of scs-user from localized templates and sends
+// them through a pluggable provider.
+package mail
+
+import (
+	"context"
+	"fmt"
+	config "scs-user/config"
+	"scs-user/pkg/logger"
+)
+
+// Message is an email with an HTML and a plain text version of its body
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers emails through a provider
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender creates the sender of the provider selected in the configuration
+func NewSender(cfg *config.Config, logger logger.Logger) (Sender, error) {
+	switch cfg.Mail.Provider {
+	case "smtp":
+		return NewSMTPSender(SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			TLS:      cfg.Mail.SMTPTLS,
+		})
+	case "", "log":
+		return NewLogSender(logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail provider: %s", cfg.Mail.Provider)
+	}
+}
+
+// LogSender logs the emails instead of sending them, for local development without a mail server
+type LogSender struct {
+	logger logger.Logger
+}
+
+func NewLogSender(logger logger.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	s.logger.Infof("Not sending email %q to %s:\n%s", msg.Subject, msg.To, msg.Text)
+	return nil
+}
@@ -0,0 +1,211 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS modes of the SMTP connection
+const (
+	SMTPTLSStartTLS = "starttls" // Upgrade the connection with STARTTLS, required if the server offers no STARTTLS
+	SMTPTLSImplicit = "tls"      // Connect with TLS, usually on port 465
+	SMTPTLSNone     = "none"     // Send in plain text, only for local mail catchers
+)
+
+// defaultSMTPTimeout bounds a delivery if the context has no deadline
+const defaultSMTPTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+	// TLSConfig overrides the TLS configuration, e.g. to trust a test server
+	TLSConfig *tls.Config
+}
+
+// SMTPSender sends emails through an SMTP server, authenticating with PLAIN if a username is set
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPSender(cfg SMTPConfig) (*SMTPSender, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("SMTP host is required")
+	}
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = SMTPTLSStartTLS
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("unsupported SMTP TLS mode: %s", cfg.TLS)
+	}
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{ServerName: cfg.Host}
+	}
+	return &SMTPSender{cfg: cfg}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	body, err := buildMessage(msg, from, to, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := s.deliver(client, from.Address, to.Address, body); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial connects to the server and secures the connection according to the TLS mode
+func (s *SMTPSender) dial(ctx context.Context) (*smtp.Client, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultSMTPTimeout)
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	var err error
+	if s.cfg.TLS == SMTPTLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.cfg.TLSConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// The deadline also bounds the SMTP conversation
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set SMTP deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	if s.cfg.TLS == SMTPTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.cfg.TLSConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to authenticate to SMTP server: %w", err)
+		}
+	}
+	return client, nil
+}
+
+func (s *SMTPSender) deliver(client *smtp.Client, from string, to string, body []byte) error {
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("SMTP server rejected the sender: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("SMTP server rejected the recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server rejected the message: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected the message: %w", err)
+	}
+	return nil
+}
+
+// buildMessage encodes the message as multipart/alternative with a plain text and an HTML part
+func buildMessage(msg *Message, from *mail.Address, to *mail.Address, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"Message-ID: " + messageID(from.Address),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + parts.Boundary(),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message part: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to encode message part: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode message part: %w", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close message: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID returns a unique Message-ID in the domain of the sender
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 16)
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,217 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSMTPServer is a minimal in-process SMTP server that records the messages it receives
+type testSMTPServer struct {
+	listener net.Listener
+	// rejectRcpt makes the server refuse every recipient
+	rejectRcpt bool
+
+	mu       sync.Mutex
+	auth     string
+	from     string
+	to       []string
+	messages []string
+}
+
+func newTestSMTPServer(t *testing.T) *testSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &testSMTPServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *testSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *testSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	reply := func(line string) { _ = text.PrintfLine("%s", line) }
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			fields := strings.Fields(line)
+			decoded, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+			s.mu.Lock()
+			s.auth = string(decoded)
+			s.mu.Unlock()
+			reply("235 Authenticated")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = line
+			s.mu.Unlock()
+			reply("250 OK")
+		case "RCPT":
+			if s.rejectRcpt {
+				reply("550 No such user")
+				continue
+			}
+			s.mu.Lock()
+			s.to = append(s.to, line)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			body, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(body))
+			s.mu.Unlock()
+			reply("250 Queued")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	server := newTestSMTPServer(t)
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Username: "user", Password: "pass", TLS: SMTPTLSNone})
+	if err != nil {
+		t.Fatalf("NewSMTPSender() error = %v", err)
+	}
+	msg := &Message{
+		From:    "Smart City <no-reply@example.com>",
+		To:      "Alice Tan <alice@example.com>",
+		Subject: "验证您的账户",
+		Text:    "Hello Alice,\nopen https://app.example.com/verify?token=abc\n",
+		HTML:    `<p>Hello <a href="https://app.example.com/verify?token=abc">Alice</a></p>`,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.auth != "\x00user\x00pass" {
+		t.Errorf("Expected PLAIN authentication, got %q", server.auth)
+	}
+	if server.from != "MAIL FROM:<no-reply@example.com> BODY=8BITMIME" && server.from != "MAIL FROM:<no-reply@example.com>" {
+		t.Errorf("Unexpected sender %q", server.from)
+	}
+	if len(server.to) != 1 || server.to[0] != "RCPT TO:<alice@example.com>" {
+		t.Errorf("Unexpected recipients %v", server.to)
+	}
+	if len(server.messages) != 1 {
+		t.Fatalf("Expected one message, got %d", len(server.messages))
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+	if err != nil {
+		t.Fatalf("Failed to parse the message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != msg.Subject {
+		t.Errorf("Subject = %q, expected %q", subject, msg.Subject)
+	}
+	if parsed.Header.Get("Message-Id") == "" || !strings.HasSuffix(parsed.Header.Get("Message-Id"), "@example.com>") {
+		t.Errorf("Expected a Message-ID in the sender domain, got %q", parsed.Header.Get("Message-Id"))
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected a multipart/alternative message, got %q", parsed.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		// The multipart reader decodes quoted-printable parts
+		body, _ := io.ReadAll(part)
+		bodies = append(bodies, string(body))
+	}
+	if len(bodies) != 2 || bodies[0] != msg.Text || bodies[1] != msg.HTML {
+		t.Errorf("Expected the text and HTML parts, got %q", bodies)
+	}
+}
+
+func TestSMTPSenderErrors(t *testing.T) {
+	server := newTestSMTPServer(t)
+	server.rejectRcpt = true
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), TLS: SMTPTLSNone})
+	if err != nil {
+		t.Fatalf("NewSMTPSender() error = %v", err)
+	}
+	msg := &Message{From: "no-reply@example.com", To: "alice@example.com", Subject: "Hi", Text: "Hi", HTML: "<p>Hi</p>"}
+	if err := sender.Send(context.Background(), msg); err == nil || !strings.Contains(err.Error(), "recipient") {
+		t.Errorf("Expected the recipient to be rejected, got %v", err)
+	}
+
+	// The test server offers no STARTTLS
+	sender, _ = NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), TLS: SMTPTLSStartTLS})
+	if err := sender.Send(context.Background(), msg); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Expected STARTTLS to be required, got %v", err)
+	}
+
+	sender, _ = NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), TLS: SMTPTLSNone})
+	if err := sender.Send(context.Background(), &Message{From: "no-reply@example.com", To: "not an address"}); err == nil {
+		t.Error("Expected an invalid recipient to be rejected")
+	}
+
+	if _, err := NewSMTPSender(SMTPConfig{Port: 25}); err == nil {
+		t.Error("Expected a host to be required")
+	}
+	if _, err := NewSMTPSender(SMTPConfig{Host: "localhost", Port: 25, TLS: "ssl"}); err == nil {
+		t.Error("Expected an unknown TLS mode to be rejected")
+	}
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	port := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+	sender, _ = NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: port, TLS: SMTPTLSNone})
+	if err := sender.Send(context.Background(), msg); err == nil {
+		t.Error("Expected an error if the server is unreachable")
+	}
+}
@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates of the transactional emails
+const (
+	TemplateVerification  = "verification"
+	TemplateInvitation    = "invitation"
+	TemplatePasswordReset = "password_reset"
+	TemplateLockout       = "lockout"
+	TemplateEmailChange   = "email_change"
+	TemplateEmailChanged  = "email_changed"
+)
+
+// Every template of a locale consists of NAME.txt, which defines the "subject" and "text"
+// templates, and NAME.html, which defines the "content" rendered into the "layout" of layout.html.
+//
+//go:embed templates
+var templateFiles embed.FS
+
+// Content is a rendered email without its addresses
+type Content struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type localizedTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates renders the emails in the language of the recipient
+type Templates struct {
+	defaultLocale string
+	// templates maps the locale and the template name to the template
+	templates map[string]map[string]localizedTemplate
+}
+
+// NewTemplates parses the embedded templates. Locales without a template fall back to the default locale.
+func NewTemplates(defaultLocale string) (*Templates, error) {
+	t := &Templates{defaultLocale: strings.ToLower(defaultLocale), templates: make(map[string]map[string]localizedTemplate)}
+	locales, err := fs.ReadDir(templateFiles, "templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email templates: %w", err)
+	}
+	for _, locale := range locales {
+		if !locale.IsDir() {
+			continue
+		}
+		dir := path.Join("templates", locale.Name())
+		textFiles, err := fs.Glob(templateFiles, path.Join(dir, "*.txt"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read email templates: %w", err)
+		}
+		byName := make(map[string]localizedTemplate)
+		for _, textFile := range textFiles {
+			name := strings.TrimSuffix(path.Base(textFile), ".txt")
+			text, err := texttemplate.ParseFS(templateFiles, textFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse email template %s: %w", textFile, err)
+			}
+			html, err := htmltemplate.ParseFS(templateFiles, path.Join(dir, "layout.html"), path.Join(dir, name+".html"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse email template %s: %w", name, err)
+			}
+			byName[name] = localizedTemplate{text: text, html: html}
+		}
+		t.templates[strings.ToLower(locale.Name())] = byName
+	}
+	if _, ok := t.templates[t.defaultLocale]; !ok {
+		return nil, fmt.Errorf("no email templates for the default locale %s", defaultLocale)
+	}
+	return t, nil
+}
+
+// Render renders the template in the locale, a language tag like en-SG. It falls back to the
+// language without its region and then to the default locale.
+func (t *Templates) Render(name string, locale string, data any) (*Content, error) {
+	tmpl, ok := t.lookup(name, locale)
+	if !ok {
+		return nil, fmt.Errorf("unknown email template: %s", name)
+	}
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render email subject: %w", err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render email text: %w", err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, fmt.Errorf("failed to render email HTML: %w", err)
+	}
+	return &Content{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+func (t *Templates) lookup(name string, locale string) (localizedTemplate, bool) {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	candidates := []string{locale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, language)
+	}
+	candidates = append(candidates, t.defaultLocale)
+	for _, candidate := range candidates {
+		if tmpl, ok := t.templates[candidate][name]; ok {
+			return tmpl, true
+		}
+	}
+	return localizedTemplate{}, false
+}
@@ -0,0 +1,107 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+type testData struct {
+	Name        string
+	Link        string
+	Role        string
+	Email       string
+	ExpiresAt   string
+	LockedUntil string
+}
+
+func TestTemplatesRenderAll(t *testing.T) {
+	templates, err := NewTemplates("en")
+	if err != nil {
+		t.Fatalf("NewTemplates() error = %v", err)
+	}
+	data := testData{Name: "Alice", Link: "https://app.example.com/x?token=abc&y=1", Role: "guard", Email: "alice@example.com", ExpiresAt: "2026-01-02 15:04", LockedUntil: "2026-01-02 15:19"}
+	for locale := range templates.templates {
+		for _, name := range []string{TemplateVerification, TemplateInvitation, TemplatePasswordReset, TemplateLockout, TemplateEmailChange, TemplateEmailChanged} {
+			// The notice of a changed email has no link, the old address must not be able to act on the account
+			hasLink := name != TemplateEmailChanged
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				if _, ok := templates.templates[locale][name]; !ok {
+					t.Fatalf("Expected locale %s to have template %s", locale, name)
+				}
+				content, err := templates.Render(name, locale, data)
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				if content.Subject == "" || strings.Contains(content.Subject, "\n") {
+					t.Errorf("Expected a single line subject, got %q", content.Subject)
+				}
+				if !strings.Contains(content.Text, "Alice") || strings.Contains(content.Text, data.Link) != hasLink {
+					t.Errorf("Expected the text to contain the name and the link if the template has one, got %q", content.Text)
+				}
+				if !strings.Contains(content.HTML, "Alice") || strings.Contains(content.HTML, `href="https://app.example.com/x?token=abc&amp;y=1"`) != hasLink {
+					t.Errorf("Expected the HTML to contain the name and the escaped link if the template has one, got %q", content.HTML)
+				}
+			})
+		}
+	}
+}
+
+func TestTemplatesLocaleFallback(t *testing.T) {
+	templates, err := NewTemplates("en")
+	if err != nil {
+		t.Fatalf("NewTemplates() error = %v", err)
+	}
+	english, _ := templates.Render(TemplateVerification, "en", testData{})
+	chinese, _ := templates.Render(TemplateVerification, "zh", testData{})
+
+	tests := []struct {
+		locale   string
+		expected string
+	}{
+		{"zh", chinese.Subject},
+		{"zh-SG", chinese.Subject},
+		{"ZH_sg", chinese.Subject},
+		{"en-SG", english.Subject},
+		{"ms-SG", english.Subject},
+		{"", english.Subject},
+	}
+	for _, tt := range tests {
+		content, err := templates.Render(TemplateVerification, tt.locale, testData{})
+		if err != nil {
+			t.Fatalf("Render(%q) error = %v", tt.locale, err)
+		}
+		if content.Subject != tt.expected {
+			t.Errorf("Render(%q) subject = %q, expected %q", tt.locale, content.Subject, tt.expected)
+		}
+	}
+}
+
+func TestTemplatesEscapeHTML(t *testing.T) {
+	templates, err := NewTemplates("en")
+	if err != nil {
+		t.Fatalf("NewTemplates() error = %v", err)
+	}
+	content, err := templates.Render(TemplateVerification, "en", testData{Name: "<script>alert(1)</script>"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if strings.Contains(content.HTML, "<script>") {
+		t.Error("Expected the name to be escaped in the HTML")
+	}
+	if !strings.Contains(content.Text, "<script>") {
+		t.Error("Expected the text to contain the name unescaped")
+	}
+}
+
+func TestTemplatesErrors(t *testing.T) {
+	if _, err := NewTemplates("xx"); err == nil {
+		t.Error("Expected an error for a default locale without templates")
+	}
+	templates, err := NewTemplates("en")
+	if err != nil {
+		t.Fatalf("NewTemplates() error = %v", err)
+	}
+	if _, err := templates.Render("unknown", "en", testData{}); err == nil {
+		t.Error("Expected an error for an unknown template")
+	}
+}
@@ -0,0 +1,317 @@
+// Package migrate applies versioned SQL migrations to a PostgreSQL database. Applied migrations
+// are recorded with their checksums, and an advisory lock makes replicas that start at the same
+// time migrate one after another.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationsLockID is the advisory lock held while migrating
+const migrationsLockID = 7234502
+
+// fileNamePattern matches migration files like 000001_initial_schema.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change with the SQL to apply and to revert it
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the SHA-256 of the up SQL, an applied migration must not change
+	Checksum string
+}
+
+// Applied is a migration recorded in the migrations table
+type Applied struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Status is a known or applied migration. AppliedAt is nil for pending migrations, and Missing
+// is set for applied migrations without a file, e.g. after a downgrade.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Missing   bool
+}
+
+// Load reads the migrations from the root of fsys in version order. Every version needs an up
+// and a down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, expected VERSION_NAME.up.sql or VERSION_NAME.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		if migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and reverts the migrations of a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New loads the migrations from fsys. It does not touch the database yet.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies the pending migrations in version order and returns them. Every migration runs in
+// its own transaction, so a failed migration leaves the ones before it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := getApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(m.migrations, applied); err != nil {
+			return err
+		}
+		for _, migration := range pending(m.migrations, applied) {
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					migration.Version, migration.Name, migration.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := getApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(m.migrations, applied); err != nil {
+			return err
+		}
+		known := make(map[int64]Migration, len(m.migrations))
+		for _, migration := range m.migrations {
+			known[migration.Version] = migration
+		}
+		for i := len(applied) - 1; i >= 0 && len(done) < steps; i-- {
+			migration, ok := known[applied[i].Version]
+			if !ok {
+				return fmt.Errorf("can not revert migration %d_%s, its file is missing", applied[i].Version, applied[i].Name)
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns the known and the applied migrations in version order
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := getApplied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		statuses = status(m.migrations, applied)
+		return nil
+	})
+	return statuses, err
+}
+
+// withLock runs fn on a single connection holding the migrations lock, after creating the
+// migrations table if needed
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationsLockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	defer func() {
+		// Unlock even if ctx was cancelled, closing the connection would release it too late
+		// when it goes back to the pool
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationsLockID)
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		checksum text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	return fn(conn)
+}
+
+func getApplied(ctx context.Context, conn *sql.Conn) ([]Applied, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+	var applied []Applied
+	for rows.Next() {
+		var a Applied
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+		}
+		applied = append(applied, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+// verify returns an error if an applied migration was changed after it was applied
+func verify(migrations []Migration, applied []Applied) error {
+	known := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+	for _, a := range applied {
+		migration, ok := known[a.Version]
+		if ok && migration.Checksum != a.Checksum {
+			return fmt.Errorf("migration %d_%s was changed after it was applied, add a new migration instead", migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// pending returns the migrations that have not been applied, in version order
+func pending(migrations []Migration, applied []Applied) []Migration {
+	done := make(map[int64]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+	var result []Migration
+	for _, migration := range migrations {
+		if !done[migration.Version] {
+			result = append(result, migration)
+		}
+	}
+	return result
+}
+
+func status(migrations []Migration, applied []Applied) []Status {
+	byVersion := make(map[int64]*Status)
+	for _, migration := range migrations {
+		byVersion[migration.Version] = &Status{Version: migration.Version, Name: migration.Name}
+	}
+	for _, a := range applied {
+		s, ok := byVersion[a.Version]
+		if !ok {
+			s = &Status{Version: a.Version, Name: a.Name, Missing: true}
+			byVersion[a.Version] = s
+		}
+		appliedAt := a.AppliedAt
+		s.AppliedAt = &appliedAt
+	}
+	statuses := make([]Status, 0, len(byVersion))
+	for _, s := range byVersion {
+		statuses = append(statuses, *s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"scs-user/migrations"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// baselineSchema is the schema of the first release: users created by AutoMigrate, the premises
+// tables created by hand
+const baselineSchema = `
+CREATE TABLE "users" (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "name" text NOT NULL,
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    "role" text NOT NULL,
+    "is_active" boolean,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+CREATE TABLE "premises" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "name" text,
+    "address" text,
+    "parent_premise_id" uuid REFERENCES "premises"("id")
+);
+CREATE TABLE "user_premises" (
+    "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "user_id" uuid NOT NULL REFERENCES "users"("id"),
+    "premise_id" uuid NOT NULL REFERENCES "premises"("id")
+);
+INSERT INTO "users" ("id", "name", "email", "password", "role", "is_active") VALUES
+    ('00000000-0000-0000-0000-000000000001', 'Active', 'active@example.com', 'hash', 'guard', true),
+    ('00000000-0000-0000-0000-000000000002', 'Inactive', 'inactive@example.com', 'hash', 'guard', false);
+INSERT INTO "premises" ("id", "name") VALUES ('00000000-0000-0000-0000-000000000010', 'Depot');
+INSERT INTO "user_premises" ("user_id", "premise_id") VALUES
+    ('00000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000010');
+`
+
+// openTestDB returns a connection to an empty schema of the database in MIGRATE_TEST_DSN, the
+// test is skipped without it
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("MIGRATE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MIGRATE_TEST_DSN is not set")
+	}
+	config := &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)}
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+func TestUpFromBaselineSchema(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, baselineSchema); err != nil {
+		t.Fatalf("Failed to create the baseline schema: %v", err)
+	}
+
+	migrator, err := New(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	var failedLogins int
+	var pendingEmail sql.NullString
+	var lockedUntil sql.NullTime
+	err = db.QueryRowContext(ctx, `SELECT failed_logins, pending_email, locked_until FROM users WHERE email = 'active@example.com'`).
+		Scan(&failedLogins, &pendingEmail, &lockedUntil)
+	if err != nil || failedLogins != 0 || pendingEmail.Valid || lockedUntil.Valid {
+		t.Errorf("Unexpected new user columns: %d, %v, %v, %v", failedLogins, pendingEmail, lockedUntil, err)
+	}
+	for email, expected := range map[string]string{"active@example.com": "active", "inactive@example.com": "suspended"} {
+		var status string
+		if err := db.QueryRowContext(ctx, `SELECT status FROM users WHERE email = $1`, email).Scan(&status); err != nil || status != expected {
+			t.Errorf("Expected %s to have the status %s, got %q, %v", email, expected, status, err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (name, email, password, role, is_active) VALUES ('New', 'new@example.com', 'hash', 'guard', true)`); err == nil {
+		t.Error("Expected a user without a status to be rejected")
+	}
+	var role string
+	if err := db.QueryRowContext(ctx, `SELECT role FROM user_premises`).Scan(&role); err != nil || role != "guard" {
+		t.Errorf("Expected existing assignments to get the guard role, got %q, %v", role, err)
+	}
+	if _, err := db.ExecContext(ctx, `SELECT latitude, geofence, geofence_max_lng FROM premises`); err != nil {
+		t.Errorf("Expected the premises to get the location columns: %v", err)
+	}
+	var indexes int
+	err = db.QueryRowContext(ctx, `SELECT count(*) FROM pg_indexes WHERE schemaname = current_schema()
+		AND indexname IN ('idx_premises_location', 'idx_premises_parent_premise_id', 'idx_user_premises_user_premise')`).Scan(&indexes)
+	if err != nil || indexes != 3 {
+		t.Errorf("Expected the indexes of the baseline tables, got %d, %v", indexes, err)
+	}
+
+	// The migrations are recorded, a second run applies nothing
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Expected nothing to apply, got %+v, %v", applied, err)
+	}
+}
@@ -0,0 +1,126 @@
+package migrate
+
+import (
+	"scs-user/migrations"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_add_phone.up.sql":         {Data: []byte("ALTER TABLE users ADD COLUMN phone text;")},
+		"000002_add_phone.down.sql":       {Data: []byte("ALTER TABLE users DROP COLUMN phone;")},
+		"000001_initial_schema.up.sql":    {Data: []byte("CREATE TABLE users (id uuid);")},
+		"000001_initial_schema.down.sql":  {Data: []byte("DROP TABLE users;")},
+		"README.md":                       {Data: []byte("not a migration")},
+		"000010_create_sessions.up.sql":   {Data: []byte("CREATE TABLE sessions (id uuid);")},
+		"000010_create_sessions.down.sql": {Data: []byte("DROP TABLE sessions;")},
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("Expected 3 migrations, got %d", len(migrations))
+	}
+	for i, expected := range []struct {
+		version int64
+		name    string
+	}{{1, "initial_schema"}, {2, "add_phone"}, {10, "create_sessions"}} {
+		if migrations[i].Version != expected.version || migrations[i].Name != expected.name {
+			t.Errorf("Migration %d = %d_%s, expected %d_%s", i, migrations[i].Version, migrations[i].Name, expected.version, expected.name)
+		}
+	}
+	if migrations[1].Up != "ALTER TABLE users ADD COLUMN phone text;" || migrations[1].Down != "ALTER TABLE users DROP COLUMN phone;" {
+		t.Errorf("Unexpected SQL of migration 2: %+v", migrations[1])
+	}
+	if len(migrations[0].Checksum) != 64 || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("Expected distinct SHA-256 checksums, got %q and %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		fsys  fstest.MapFS
+		error string
+	}{
+		{"invalid name", fstest.MapFS{"initial.up.sql": {Data: []byte("x")}}, "invalid migration file name"},
+		{"zero version", fstest.MapFS{"0_initial.up.sql": {Data: []byte("x")}, "0_initial.down.sql": {Data: []byte("x")}}, "invalid migration version"},
+		{"missing down", fstest.MapFS{"1_initial.up.sql": {Data: []byte("x")}}, "no down file"},
+		{"missing up", fstest.MapFS{"1_initial.down.sql": {Data: []byte("x")}}, "no up file"},
+		{"different names", fstest.MapFS{"1_initial.up.sql": {Data: []byte("x")}, "1_other.down.sql": {Data: []byte("x")}}, "different names"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Load() error = %v, expected %q", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestPendingAndVerify(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "a", Checksum: "c1"},
+		{Version: 2, Name: "b", Checksum: "c2"},
+		{Version: 3, Name: "c", Checksum: "c3"},
+	}
+	// Migration 2 was merged after 3 had been applied
+	applied := []Applied{{Version: 1, Name: "a", Checksum: "c1"}, {Version: 3, Name: "c", Checksum: "c3"}}
+
+	if err := verify(migrations, applied); err != nil {
+		t.Errorf("verify() error = %v", err)
+	}
+	result := pending(migrations, applied)
+	if len(result) != 1 || result[0].Version != 2 {
+		t.Errorf("Expected migration 2 to be pending, got %+v", result)
+	}
+
+	changed := []Applied{{Version: 1, Name: "a", Checksum: "other"}}
+	if err := verify(migrations, changed); err == nil || !strings.Contains(err.Error(), "1_a was changed") {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+	// Migrations applied by a newer release are not verified
+	if err := verify(migrations, []Applied{{Version: 4, Name: "d", Checksum: "c4"}}); err != nil {
+		t.Errorf("verify() error = %v", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	migrations := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}}
+	applied := []Applied{{Version: 1, Name: "a", AppliedAt: appliedAt}, {Version: 5, Name: "e", AppliedAt: appliedAt}}
+
+	statuses := status(migrations, applied)
+	if len(statuses) != 3 {
+		t.Fatalf("Expected 3 statuses, got %+v", statuses)
+	}
+	if statuses[0].AppliedAt == nil || !statuses[0].AppliedAt.Equal(appliedAt) || statuses[0].Missing {
+		t.Errorf("Expected migration 1 to be applied, got %+v", statuses[0])
+	}
+	if statuses[1].AppliedAt != nil || statuses[1].Missing {
+		t.Errorf("Expected migration 2 to be pending, got %+v", statuses[1])
+	}
+	if statuses[2].Version != 5 || !statuses[2].Missing || statuses[2].AppliedAt == nil {
+		t.Errorf("Expected migration 5 to be applied without a file, got %+v", statuses[2])
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) == 0 || loaded[0].Version != 1 {
+		t.Fatalf("Expected the initial schema migration, got %+v", loaded)
+	}
+	for i, migration := range loaded {
+		if migration.Version != int64(i+1) {
+			t.Errorf("Expected migration versions without gaps, got %d at position %d", migration.Version, i)
+		}
+	}
+}
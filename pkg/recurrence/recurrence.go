@@ -0,0 +1,225 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used for shift
+// schedules: FREQ=DAILY or WEEKLY with INTERVAL, BYDAY, COUNT and UNTIL.
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds the expansion of rules without COUNT or UNTIL
+const maxOccurrences = 10000
+
+type Frequency string
+
+const (
+	Daily  Frequency = "DAILY"
+	Weekly Frequency = "WEEKLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule is a parsed recurrence rule. The first occurrence is the start passed to its methods.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// ByDay restricts weekly rules to these days, the weekday of the start is used if it is empty
+	ByDay []time.Weekday
+	// Count limits the number of occurrences including the first one, zero means no limit
+	Count int
+	// Until is the last time an occurrence may start, zero means no limit
+	Until time.Time
+}
+
+// Parse parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR;UNTIL=20261231T235959Z".
+// An optional "RRULE:" prefix is accepted.
+func Parse(s string) (*Rule, error) {
+	rule := &Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+			if rule.Freq != Daily && rule.Freq != Weekly {
+				return nil, fmt.Errorf("unsupported frequency %q, must be DAILY or WEEKLY", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid interval %q", value)
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid count %q", value)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("invalid weekday %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part %q", name)
+		}
+	}
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("recurrence rule requires FREQ")
+	}
+	if rule.Freq == Daily && len(rule.ByDay) > 0 {
+		return nil, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("COUNT and UNTIL can not be combined")
+	}
+	sort.Slice(rule.ByDay, func(i, j int) bool {
+		return weekdayIndex(rule.ByDay[i]) < weekdayIndex(rule.ByDay[j])
+	})
+	return rule, nil
+}
+
+// String formats the rule in RFC 5545 syntax
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, weekday := range r.ByDay {
+			days[i] = strings.ToUpper(weekday.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Bounded reports whether the rule has a finite number of occurrences
+func (r *Rule) Bounded() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// Between returns the occurrences of the rule starting at start that begin in [from, to)
+func (r *Rule) Between(start time.Time, from time.Time, to time.Time) []time.Time {
+	var occurrences []time.Time
+	r.iterate(start, func(occurrence time.Time) bool {
+		if !occurrence.Before(to) {
+			return false
+		}
+		if !occurrence.Before(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// Last returns the start of the last occurrence, or false if the rule is unbounded
+func (r *Rule) Last(start time.Time) (time.Time, bool) {
+	if !r.Bounded() {
+		return time.Time{}, false
+	}
+	last := start
+	r.iterate(start, func(occurrence time.Time) bool {
+		last = occurrence
+		return true
+	})
+	return last, true
+}
+
+// iterate calls fn with each occurrence in order until fn returns false or the rule ends
+func (r *Rule) iterate(start time.Time, fn func(time.Time) bool) {
+	emitted := 0
+	emit := func(occurrence time.Time) bool {
+		if !r.Until.IsZero() && occurrence.After(r.Until) {
+			return false
+		}
+		if (r.Count > 0 && emitted >= r.Count) || emitted >= maxOccurrences {
+			return false
+		}
+		emitted++
+		return fn(occurrence)
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	if r.Freq == Daily {
+		for i := 0; ; i++ {
+			if !emit(start.AddDate(0, 0, i*interval)) {
+				return
+			}
+		}
+	}
+
+	byDay := r.ByDay
+	if len(byDay) == 0 {
+		byDay = []time.Weekday{start.Weekday()}
+	}
+	// Weeks start on Monday as in RFC 5545
+	weekStart := start.AddDate(0, 0, -weekdayIndex(start.Weekday()))
+	for week := 0; ; week += interval {
+		for _, weekday := range byDay {
+			occurrence := weekStart.AddDate(0, 0, week*7+weekdayIndex(weekday))
+			if occurrence.Before(start) {
+				continue
+			}
+			if !emit(occurrence) {
+				return
+			}
+		}
+	}
+}
+
+// weekdayIndex returns the position of the weekday in a week starting on Monday
+func weekdayIndex(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if until, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date includes the whole day
+				until = until.Add(24*time.Hour - time.Second)
+			}
+			return until, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid until %q", value)
+}
@@ -0,0 +1,93 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	rule, err := Parse("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=FR,MO;UNTIL=20261231T235959Z")
+	if err != nil {
+		t.Fatalf("Failed to parse rule: %v", err)
+	}
+	if rule.Freq != Weekly || rule.Interval != 2 || len(rule.ByDay) != 2 || rule.ByDay[0] != time.Monday {
+		t.Errorf("Unexpected rule %+v", rule)
+	}
+	if got := rule.String(); got != "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;UNTIL=20261231T235959Z" {
+		t.Errorf("Unexpected string %s", got)
+	}
+
+	invalid := []string{
+		"",
+		"FREQ=MONTHLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=DAILY;COUNT=2;UNTIL=20261231",
+		"FREQ=DAILY;BYMONTH=1",
+	}
+	for _, s := range invalid {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+func TestDailyBetween(t *testing.T) {
+	rule, _ := Parse("FREQ=DAILY;INTERVAL=2;COUNT=4")
+	start := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	occurrences := rule.Between(start, start, start.AddDate(1, 0, 0))
+	if len(occurrences) != 4 {
+		t.Fatalf("Expected 4 occurrences, got %d", len(occurrences))
+	}
+	if !occurrences[3].Equal(time.Date(2026, 3, 7, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected last occurrence %v", occurrences[3])
+	}
+
+	occurrences = rule.Between(start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 4))
+	if len(occurrences) != 1 || !occurrences[0].Equal(start.AddDate(0, 0, 2)) {
+		t.Errorf("Unexpected occurrences in window %v", occurrences)
+	}
+}
+
+func TestWeeklyBetween(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20260320")
+	// Wednesday
+	start := time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC)
+
+	occurrences := rule.Between(start, start, start.AddDate(1, 0, 0))
+	expected := []time.Time{
+		time.Date(2026, 3, 4, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 16, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 18, 8, 0, 0, 0, time.UTC),
+	}
+	if len(occurrences) != len(expected) {
+		t.Fatalf("Expected %d occurrences, got %v", len(expected), occurrences)
+	}
+	for i := range expected {
+		if !occurrences[i].Equal(expected[i]) {
+			t.Errorf("Occurrence %d: expected %v, got %v", i, expected[i], occurrences[i])
+		}
+	}
+
+	last, ok := rule.Last(start)
+	if !ok || !last.Equal(expected[len(expected)-1]) {
+		t.Errorf("Unexpected last occurrence %v", last)
+	}
+}
+
+func TestUnboundedRule(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY")
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	if _, ok := rule.Last(start); ok {
+		t.Error("Expected an unbounded rule to have no last occurrence")
+	}
+	occurrences := rule.Between(start, start.AddDate(0, 0, 20), start.AddDate(0, 0, 27))
+	if len(occurrences) != 1 || occurrences[0].Weekday() != time.Monday {
+		t.Errorf("Unexpected occurrences %v", occurrences)
+	}
+}
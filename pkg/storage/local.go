@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStore stores objects on the local disk. Signed URLs point to a download
+// endpoint which must verify them with VerifySignature.
+type LocalStore struct {
+	root    string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// MinSigningSecretLength is the minimum length of the secret that signs the download URLs
+const MinSigningSecretLength = 32
+
+// NewLocalStore creates a store rooted at dir. baseURL is the URL of the download endpoint.
+func NewLocalStore(dir string, baseURL string, secret []byte) (*LocalStore, error) {
+	if len(secret) < MinSigningSecretLength {
+		return nil, fmt.Errorf("signing secret of at least %d bytes is required for local storage", MinSigningSecretLength)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStore{root: dir, baseURL: strings.TrimRight(baseURL, "/"), secret: secret, now: time.Now}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return f, &ObjectInfo{Key: key, Size: stat.Size(), ContentType: contentType}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := s.now().Add(expiry).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign(key, expires))
+	return s.baseURL + "/" + key + "?" + query.Encode(), nil
+}
+
+// VerifySignature checks a signature produced by SignedURL
+func (s *LocalStore) VerifySignature(key string, expires string, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(s.sign(key, expiresAt)), []byte(signature))
+}
+
+func (s *LocalStore) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps a key to a file path, rejecting keys that escape the root directory
+func (s *LocalStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if key == "" || cleaned == "/" || cleaned != "/"+key {
+		return "", fmt.Errorf("invalid object key: %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestLocalStoreRequiresSecret(t *testing.T) {
+	for _, secret := range [][]byte{nil, []byte("s3cr3t")} {
+		if _, err := NewLocalStore(t.TempDir(), "", secret); err == nil {
+			t.Errorf("Expected a signing secret of %d bytes to be rejected", len(secret))
+		}
+	}
+}
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost/files", testSecret)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+	content := []byte("image bytes")
+
+	if err := store.Put(ctx, "avatars/1/original.png", bytes.NewReader(content), int64(len(content)), "image/png"); err != nil {
+		t.Fatalf("Failed to put object: %v", err)
+	}
+	rc, info, err := store.Get(ctx, "avatars/1/original.png")
+	if err != nil {
+		t.Fatalf("Failed to get object: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(data, content) || info.Size != int64(len(content)) {
+		t.Errorf("Unexpected object content %q (size %d)", data, info.Size)
+	}
+	if info.ContentType != "image/png" {
+		t.Errorf("Expected content type image/png, got %s", info.ContentType)
+	}
+
+	if err := store.Delete(ctx, "avatars/1/original.png"); err != nil {
+		t.Fatalf("Failed to delete object: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "avatars/1/original.png"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestLocalStoreRejectsPathTraversal(t *testing.T) {
+	store, _ := NewLocalStore(t.TempDir(), "", testSecret)
+	for _, key := range []string{"", "../etc/passwd", "a/../../b", "/abs"} {
+		if err := store.Put(context.Background(), key, bytes.NewReader(nil), 0, ""); err == nil {
+			t.Errorf("Expected error for key %q", key)
+		}
+	}
+}
+
+func TestLocalStoreSignedURL(t *testing.T) {
+	store, _ := NewLocalStore(t.TempDir(), "http://localhost/files/", testSecret)
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	signedURL, err := store.SignedURL(context.Background(), "avatars/1/original.png", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to sign URL: %v", err)
+	}
+	u, _ := url.Parse(signedURL)
+	if u.Path != "/files/avatars/1/original.png" {
+		t.Errorf("Unexpected path %s", u.Path)
+	}
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+
+	if !store.VerifySignature("avatars/1/original.png", expires, signature) {
+		t.Error("Expected signature to be valid")
+	}
+	if store.VerifySignature("avatars/2/original.png", expires, signature) {
+		t.Error("Expected signature to be invalid for another key")
+	}
+	now = now.Add(2 * time.Minute)
+	if store.VerifySignature("avatars/1/original.png", expires, signature) {
+		t.Error("Expected signature to be expired")
+	}
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat    = "20060102T150405Z"
+	amzShortDate     = "20060102"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+)
+
+// S3Config configures an S3-compatible store such as AWS S3 or MinIO
+type S3Config struct {
+	Endpoint     string // e.g. https://s3.ap-southeast-1.amazonaws.com or http://minio:9000
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool // Required by most self-hosted S3 implementations
+}
+
+// S3Store stores objects in an S3-compatible bucket using Signature Version 4
+type S3Store struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %q", cfg.Endpoint)
+	}
+	return &S3Store{cfg: cfg, endpoint: endpoint, client: &http.Client{Timeout: time.Minute}, now: time.Now}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, &ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a presigned GET URL
+func (s *S3Store) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	now := s.now().UTC()
+	u := s.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", signingAlgorithm)
+	query.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+s.credentialScope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = canonicalQuery(query)
+
+	headers := http.Header{}
+	headers.Set("Host", u.Host)
+	signature := s.signature(now, http.MethodGet, u, headers, []string{"host"}, unsignedPayload)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// do signs and sends the request, mapping error responses to errors
+func (s *S3Store) do(req *http.Request) (*http.Response, error) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	headers := req.Header.Clone()
+	headers.Set("Host", req.URL.Host)
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Content-Type") != "" {
+		signedHeaders = append([]string{"content-type"}, signedHeaders...)
+	}
+	signature := s.signature(now, req.Method, req.URL, headers, signedHeaders, unsignedPayload)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, s.cfg.AccessKey, s.credentialScope(now), strings.Join(signedHeaders, ";"), signature))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// objectURL returns the URL of the object in path or virtual-hosted style
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	basePath := strings.TrimRight(s.endpoint.Path, "/")
+	if s.cfg.UsePathStyle {
+		u.Path = basePath + "/" + s.cfg.Bucket + "/" + key
+		u.RawPath = basePath + "/" + uriEncode(s.cfg.Bucket, true) + "/" + uriEncode(key, false)
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+		u.RawPath = basePath + "/" + uriEncode(key, false)
+	}
+	return &u
+}
+
+func (s *S3Store) credentialScope(t time.Time) string {
+	return t.Format(amzShortDate) + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+// signature computes the Signature Version 4 signature of a request
+func (s *S3Store) signature(t time.Time, method string, u *url.URL, headers http.Header, signedHeaders []string, payloadHash string) string {
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers.Get(name)) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		u.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		t.Format(amzDateFormat),
+		s.credentialScope(t),
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), t.Format(amzShortDate))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalQuery encodes the query sorted by key as required by Signature Version 4
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range values[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters, and '/' unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
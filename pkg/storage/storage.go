@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	config "scs-user/config"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+}
+
+// BlobStore stores binary objects such as avatars and attachments
+type BlobStore interface {
+	// Put stores the content of r under key. size is the exact length of the content.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns the content of the object. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that allows downloading the object until it expires
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// NewBlobStore creates the blob store selected in the configuration
+func NewBlobStore(cfg *config.Config) (BlobStore, error) {
+	switch cfg.Storage.Driver {
+	case "", "local":
+		return NewLocalStore(cfg.Upload.Dir, cfg.Storage.PublicBaseURL, []byte(cfg.Storage.SigningSecret))
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:     cfg.Storage.S3Endpoint,
+			Region:       cfg.Storage.S3Region,
+			Bucket:       cfg.Storage.S3Bucket,
+			AccessKey:    cfg.Storage.S3AccessKey,
+			SecretKey:    cfg.Storage.S3SecretKey,
+			UsePathStyle: cfg.Storage.S3UsePathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", cfg.Storage.Driver)
+	}
+}
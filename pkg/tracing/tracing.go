@@ -0,0 +1,70 @@
+// Package tracing propagates W3C trace context from the HTTP requests to the events they cause.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// Header is the W3C trace context header
+const Header = "traceparent"
+
+type contextKey struct{}
+
+// WithTraceParent returns a context carrying the traceparent
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, contextKey{}, traceParent)
+}
+
+// FromContext returns the traceparent of the context, or "" if it has none
+func FromContext(ctx context.Context) string {
+	traceParent, _ := ctx.Value(contextKey{}).(string)
+	return traceParent
+}
+
+// ChildOf returns the traceparent of a new span in the trace of parent. A new trace is started
+// if parent is not a valid traceparent.
+func ChildOf(parent string) string {
+	traceID, flags, ok := parse(parent)
+	if !ok {
+		traceID, flags = randomHex(16), "01"
+	}
+	return "00-" + traceID + "-" + randomHex(8) + "-" + flags
+}
+
+// parse returns the trace ID and the flags of a version 00 traceparent
+func parse(traceParent string) (traceID string, flags string, ok bool) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return "", "", false
+	}
+	if !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return "", "", false
+	}
+	// All-zero IDs are invalid
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", false
+	}
+	return parts[1], parts[3], true
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(bytes int) string {
+	b := make([]byte, bytes)
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestChildOf(t *testing.T) {
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	child := ChildOf(parent)
+	traceID, flags, ok := parse(child)
+	if !ok {
+		t.Fatalf("ChildOf returned an invalid traceparent %s", child)
+	}
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || flags != "01" {
+		t.Errorf("Expected the trace ID and flags of the parent, got %s", child)
+	}
+	if strings.Contains(child, "00f067aa0ba902b7") {
+		t.Errorf("Expected a new span ID, got %s", child)
+	}
+}
+
+func TestChildOfInvalidParent(t *testing.T) {
+	tests := []string{
+		"",
+		"garbage",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	}
+	for _, parent := range tests {
+		child := ChildOf(parent)
+		if _, _, ok := parse(child); !ok {
+			t.Errorf("ChildOf(%q) returned an invalid traceparent %s", parent, child)
+		}
+		if len(parent) >= 35 && strings.Contains(child, parent[3:35]) {
+			t.Errorf("ChildOf(%q) kept the invalid trace ID", parent)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != "" {
+		t.Errorf("Expected no traceparent, got %s", got)
+	}
+	traceParent := ChildOf("")
+	if got := FromContext(WithTraceParent(ctx, traceParent)); got != traceParent {
+		t.Errorf("Expected %s, got %s", traceParent, got)
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+// Backoff returns the delay before the given retry attempt, starting at base and doubling on every
+// further attempt up to max
+func Backoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{10, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt, 10*time.Second, 5*time.Minute); got != tt.expected {
+			t.Errorf("Backoff(%d) = %v, expected %v", tt.attempt, got, tt.expected)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -76,9 +78,12 @@ func SaveUploadedFile(file *multipart.FileHeader, uploadDir string) (*FileInfo,
 		return nil, fmt.Errorf("failed to copy file content: %w", err)
 	}
 
-	// Get content type
-	contentType := file.Header.Get("Content-Type")
-	if contentType == "" {
+	// Get content type from the file content, the client supplied header can't be trusted
+	contentType, err := DetectContentType(file)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "application/octet-stream" {
 		contentType = getContentTypeFromExtension(extension)
 	}
 
@@ -99,10 +104,10 @@ func ValidateImageFile(file *multipart.FileHeader, maxSize int64) error {
 		return fmt.Errorf("file size %d bytes exceeds maximum allowed size %d bytes", file.Size, maxSize)
 	}
 
-	// Check content type
-	contentType := file.Header.Get("Content-Type")
-	if contentType == "" {
-		contentType = getContentTypeFromExtension(filepath.Ext(file.Filename))
+	// Check content type by sniffing the content instead of trusting the header
+	contentType, err := DetectContentType(file)
+	if err != nil {
+		return err
 	}
 
 	if !AllowedImageTypes[contentType] {
@@ -132,6 +137,26 @@ func ValidateVideoFile(file *multipart.FileHeader, maxSize int64) error {
 	return nil
 }
 
+// DetectContentType detects the content type of an uploaded file from its first bytes
+func DetectContentType(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return "application/octet-stream", nil
+	}
+	return contentType, nil
+}
+
 // generateUniqueFilename generates a unique filename with timestamp and UUID
 func generateUniqueFilename(extension string) string {
 	timestamp := time.Now().Format("20060102_150405")
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+)
+
+// newFileHeader builds a multipart file header with the given declared content type
+func newFileHeader(t *testing.T, filename string, contentType string, content []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatalf("Failed to create part: %v", err)
+	}
+	part.Write(content)
+	mw.Close()
+
+	form, err := multipart.NewReader(&body, mw.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("Failed to read form: %v", err)
+	}
+	return form.File["file"][0]
+}
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidateImageFileSniffsContent(t *testing.T) {
+	// A real PNG declared with a wrong content type is accepted
+	file := newFileHeader(t, "avatar.bin", "application/octet-stream", pngBytes(t, 4, 4))
+	if err := ValidateImageFile(file, 1<<20); err != nil {
+		t.Errorf("Expected PNG content to be accepted, got %v", err)
+	}
+
+	// A script disguised as an image is rejected
+	file = newFileHeader(t, "avatar.png", "image/png", []byte("<html><script>alert(1)</script></html>"))
+	if err := ValidateImageFile(file, 1<<20); err == nil {
+		t.Error("Expected HTML content declared as image/png to be rejected")
+	}
+}
+
+func TestValidateImageFileSize(t *testing.T) {
+	file := newFileHeader(t, "avatar.png", "image/png", pngBytes(t, 4, 4))
+	if err := ValidateImageFile(file, 10); err == nil {
+		t.Error("Expected file exceeding max size to be rejected")
+	}
+}
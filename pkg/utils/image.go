@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrImageTooLarge is returned by DecodeImage for images with more pixels than allowed
+var ErrImageTooLarge = errors.New("image has too many pixels")
+
+// DecodeImage decodes an image and returns it with its format name (jpeg, png, gif, bmp or webp).
+// The dimensions are read from the header first, so that a small file declaring a huge image is
+// rejected before its pixels are allocated.
+func DecodeImage(data []byte, maxPixels int) (image.Image, string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > int64(maxPixels) {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// Thumbnail crops the center square of the image and scales it to size x size pixels
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Over, nil)
+	return dst
+}
+
+// EncodeImage encodes the image in the given format. Formats without an encoder are written as PNG.
+// It returns the content type of the written data.
+func EncodeImage(w io.Writer, img image.Image, format string) (string, error) {
+	switch format {
+	case "jpeg":
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case "gif":
+		return "image/gif", gif.Encode(w, img, nil)
+	default:
+		return "image/png", png.Encode(w, img)
+	}
+}
+
+// ImageExtension returns the file extension for an image content type
+func ImageExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/bmp":
+		return ".bmp"
+	default:
+		return ".png"
+	}
+}
+
+// MimeTypeFromFormat returns the content type of an image format name returned by DecodeImage
+func MimeTypeFromFormat(format string) string {
+	return "image/" + format
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	img, format, err := DecodeImage(pngBytes(t, 300, 200), 1000000)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+	if format != "png" {
+		t.Errorf("Expected png format, got %s", format)
+	}
+
+	thumb := Thumbnail(img, 64)
+	if thumb.Bounds().Dx() != 64 || thumb.Bounds().Dy() != 64 {
+		t.Errorf("Expected 64x64 thumbnail, got %v", thumb.Bounds())
+	}
+
+	var buf bytes.Buffer
+	contentType, err := EncodeImage(&buf, thumb, format)
+	if err != nil {
+		t.Fatalf("Failed to encode thumbnail: %v", err)
+	}
+	if contentType != "image/png" || ImageExtension(contentType) != ".png" {
+		t.Errorf("Unexpected content type %s", contentType)
+	}
+}
+
+func TestDecodeImageRejectsTooManyPixels(t *testing.T) {
+	if _, _, err := DecodeImage(pngBytes(t, 300, 200), 300*200); err != nil {
+		t.Fatalf("Expected an image at the limit to decode, got %v", err)
+	}
+	if _, _, err := DecodeImage(pngBytes(t, 300, 200), 300*200-1); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("Expected ErrImageTooLarge, got %v", err)
+	}
+	// A tiny file declaring a huge image is rejected from its header
+	bomb := pngBytes(t, 1, 1)
+	binary.BigEndian.PutUint32(bomb[16:], 100000)
+	binary.BigEndian.PutUint32(bomb[20:], 100000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+	if _, _, err := DecodeImage(bomb, 25000000); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("Expected ErrImageTooLarge for a decompression bomb, got %v", err)
+	}
+}
+
+func TestDecodeImageRejectsInvalidData(t *testing.T) {
+	if _, _, err := DecodeImage([]byte("not an image"), 1000000); err == nil {
+		t.Error("Expected error for invalid image data")
+	}
+}
@@ -1,39 +1,86 @@
 package utils
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// jwtSecret signs tokens until a signing key is set with SetSigningKeys
 var jwtSecret = []byte("s3cr3t") // should be from env
 
+// SigningKey is a secret that signs tokens, identified by the kid header of the tokens
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+var (
+	signingKeysMu sync.RWMutex
+	// signingKey signs new tokens, nil while the built-in secret is used
+	signingKey *SigningKey
+	// verificationKeys are the keys accepted by ParseToken by ID
+	verificationKeys map[string][]byte
+)
+
+// SetSigningKeys replaces the keys tokens are signed and verified with. current signs new tokens,
+// the other keys are still accepted for the tokens they signed. Once a current key is set, tokens
+// signed with the built-in secret are no longer accepted.
+func SetSigningKeys(current *SigningKey, others []SigningKey) {
+	keys := make(map[string][]byte, len(others)+1)
+	for _, key := range others {
+		keys[key.ID] = key.Secret
+	}
+	if current != nil {
+		keys[current.ID] = current.Secret
+	}
+
+	signingKeysMu.Lock()
+	defer signingKeysMu.Unlock()
+	signingKey = current
+	verificationKeys = keys
+}
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a JWT for a given user ID
-func GenerateToken(userID string, role string) (string, error) {
+// TokenTTL is the lifetime of tokens created by GenerateToken
+const TokenTTL = time.Hour * 24 // 1 day
+
+// GenerateToken creates a JWT for a given user ID. Access tokens carry the ID of the
+// login session, tokens that are not bound to a session pass an empty sessionID.
+func GenerateToken(userID string, role string, sessionID string) (string, error) {
 	claims := &Claims{
 		UserID: userID,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)), // 1 day
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	signingKeysMu.RLock()
+	key := signingKey
+	signingKeysMu.RUnlock()
+	if key == nil {
+		return token.SignedString(jwtSecret)
+	}
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Secret)
 }
 
 // ParseToken validates a JWT and returns claims
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+		return lookupKey(token)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return nil, err
 	}
@@ -49,3 +96,21 @@ func ValidateToken(tokenString string) error {
 	_, err := ParseToken(tokenString)
 	return err
 }
+
+// lookupKey returns the secret that signed the token
+func lookupKey(token *jwt.Token) ([]byte, error) {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if signingKey != nil {
+			return nil, fmt.Errorf("token without key id")
+		}
+		return jwtSecret, nil
+	}
+	secret, ok := verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %s", kid)
+	}
+	return secret, nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokenSigningKeys(t *testing.T) {
+	t.Cleanup(func() { SetSigningKeys(nil, nil) })
+
+	builtIn, err := GenerateToken("user-1", "admin", "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if _, err := ParseToken(builtIn); err != nil {
+		t.Fatalf("Expected a token signed with the built-in secret to be valid, got %v", err)
+	}
+
+	first := SigningKey{ID: "key-1", Secret: []byte("first secret")}
+	SetSigningKeys(&first, nil)
+	signedFirst, err := GenerateToken("user-1", "admin", "session-1")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	claims, err := ParseToken(signedFirst)
+	if err != nil || claims.UserID != "user-1" || claims.ID != "session-1" {
+		t.Fatalf("ParseToken() = %+v, %v", claims, err)
+	}
+	if _, err := ParseToken(builtIn); err == nil {
+		t.Error("Expected a token signed with the built-in secret to be rejected once a key is set")
+	}
+
+	// After a rotation the retired key still verifies the tokens it signed
+	second := SigningKey{ID: "key-2", Secret: []byte("second secret")}
+	SetSigningKeys(&second, []SigningKey{first})
+	signedSecond, _ := GenerateToken("user-2", "guard", "session-2")
+	token, _, _ := jwt.NewParser().ParseUnverified(signedSecond, &Claims{})
+	if token.Header["kid"] != "key-2" {
+		t.Errorf("Expected the token to be signed with key-2, got kid %v", token.Header["kid"])
+	}
+	if _, err := ParseToken(signedFirst); err != nil {
+		t.Errorf("Expected a token signed with the retired key to be valid, got %v", err)
+	}
+	if _, err := ParseToken(signedSecond); err != nil {
+		t.Errorf("Expected a token signed with the current key to be valid, got %v", err)
+	}
+
+	// Once the retired key is dropped, its tokens are rejected
+	SetSigningKeys(&second, nil)
+	if _, err := ParseToken(signedFirst); err == nil {
+		t.Error("Expected a token signed with a dropped key to be rejected")
+	}
+}
@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+func TestGenerateRandomToken(t *testing.T) {
+	token1, err := GenerateRandomToken()
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	token2, _ := GenerateRandomToken()
+
+	if len(token1) != 43 {
+		t.Errorf("Expected 43 character token, got %d", len(token1))
+	}
+	if token1 == token2 {
+		t.Fatal("Two generated tokens should be different")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	if HashToken("token") != HashToken("token") {
+		t.Fatal("Hash of the same token should be stable")
+	}
+	if HashToken("token") == HashToken("other") {
+		t.Fatal("Hashes of different tokens should differ")
+	}
+	if HashToken("token") == "token" {
+		t.Fatal("Hash should not equal the token")
+	}
+}
@@ -89,6 +89,18 @@ func getErrorMessage(fe validator.FieldError) string {
 		return fmt.Sprintf("%s must be greater than %s", fe.Field(), fe.Param())
 	case "lt":
 		return fmt.Sprintf("%s must be less than %s", fe.Field(), fe.Param())
+	case "e164":
+		return fmt.Sprintf("%s must be a phone number in E.164 format, e.g. +6591234567", fe.Field())
+	case "bcp47_language_tag":
+		return fmt.Sprintf("%s must be a valid locale, e.g. en-SG", fe.Field())
+	case "timezone":
+		return fmt.Sprintf("%s must be a valid IANA time zone, e.g. Asia/Singapore", fe.Field())
+	case "latitude":
+		return fmt.Sprintf("%s must be a latitude between -90 and 90", fe.Field())
+	case "longitude":
+		return fmt.Sprintf("%s must be a longitude between -180 and 180", fe.Field())
+	case "required_with":
+		return fmt.Sprintf("%s is required when %s is set", fe.Field(), fe.Param())
 	default:
 		return fmt.Sprintf("%s is invalid", fe.Field())
 	}
@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxResponseSize is how much of the response body is kept for the delivery log
+const maxResponseSize = 1024
+
+// Delivery is a signed request to a webhook endpoint
+type Delivery struct {
+	ID        string
+	EventType string
+	URL       string
+	Secret    string
+	Body      []byte
+}
+
+// Result is the outcome of sending a delivery. StatusCode is 0 if no response was received.
+type Result struct {
+	StatusCode int
+	Response   string
+	Duration   time.Duration
+	Err        error
+}
+
+// OK reports whether the endpoint acknowledged the delivery with a 2xx response
+func (r Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// ErrForbiddenAddress is returned for endpoints on loopback, link-local, private or otherwise
+// internal addresses that are not allowed
+var ErrForbiddenAddress = errors.New("webhook endpoint address is not allowed")
+
+// Client sends webhook deliveries
+type Client struct {
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewClient returns a client that gives endpoints the timeout to respond. Redirects are not
+// followed, the endpoint has to respond itself. Endpoints on internal addresses are refused unless
+// one of the allowed networks contains them; the address is checked when connecting, after DNS
+// resolution, so that a host name can not point the client at an internal service.
+func NewClient(timeout time.Duration, allowed []netip.Prefix) *Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			return checkAddress(address, allowed)
+		},
+	}
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// No proxy, the dialer has to see the address of the endpoint
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// checkAddress returns ErrForbiddenAddress if the host:port address is internal and not allowed
+func checkAddress(address string, allowed []netip.Prefix) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid webhook endpoint address %s: %w", address, err)
+	}
+	return checkAddr(addrPort.Addr(), allowed)
+}
+
+func checkAddr(addr netip.Addr, allowed []netip.Prefix) error {
+	addr = addr.Unmap()
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if IsInternalAddress(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// CheckURL returns ErrForbiddenAddress if the host of the endpoint URL is an internal address or
+// localhost that is not allowed. Host names are only checked when connecting.
+func CheckURL(rawURL string, allowed []netip.Prefix) error {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook endpoint URL: %w", err)
+	}
+	host := strings.ToLower(strings.TrimSuffix(endpoint.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return checkAddr(netip.AddrFrom4([4]byte{127, 0, 0, 1}), allowed)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr, allowed)
+	}
+	return nil
+}
+
+// IsInternalAddress reports whether the address is a loopback, link-local (like the cloud
+// metadata service at 169.254.169.254), private, shared, multicast or unspecified address
+func IsInternalAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsPrivate() || addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Send posts the body of the delivery with its signature headers
+func (c *Client) Send(ctx context.Context, delivery Delivery) Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return Result{Err: fmt.Errorf("failed to create webhook request: %w", err)}
+	}
+	timestamp := c.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "scs-user-webhooks/1.0")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Body))
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Result{Duration: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	// Drain the rest so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*maxResponseSize))
+
+	result := Result{StatusCode: resp.StatusCode, Response: string(response), Duration: time.Since(start)}
+	if !result.OK() {
+		result.Err = fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loopback allows the test servers, which listen on the loopback interface
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func TestClientSend(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	body := []byte(`{"type":"user.created"}`)
+	result := NewClient(time.Second, loopback).Send(context.Background(), Delivery{
+		ID:        "delivery-1",
+		EventType: "user.created",
+		URL:       server.URL,
+		Secret:    "secret",
+		Body:      body,
+	})
+	if !result.OK() || result.StatusCode != http.StatusAccepted || result.Response != "ok" {
+		t.Fatalf("Expected an accepted delivery, got %+v", result)
+	}
+	if received.Header.Get(HeaderID) != "delivery-1" || received.Header.Get(HeaderEventType) != "user.created" {
+		t.Errorf("Expected the delivery headers, got %v", received.Header)
+	}
+	err := Verify("secret", received.Header.Get(HeaderTimestamp), received.Header.Get(HeaderSignature), receivedBody, time.Minute, time.Now())
+	if err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+}
+
+func TestClientSendFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(strings.Repeat("x", 2*maxResponseSize)))
+		case "/redirect":
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(50*time.Millisecond, loopback)
+
+	result := client.Send(context.Background(), Delivery{URL: server.URL + "/error"})
+	if result.OK() || result.StatusCode != http.StatusInternalServerError || len(result.Response) != maxResponseSize {
+		t.Errorf("Expected a failed delivery with a truncated response, got status %d and %d bytes", result.StatusCode, len(result.Response))
+	}
+	result = client.Send(context.Background(), Delivery{URL: server.URL + "/redirect"})
+	if result.OK() || result.StatusCode != http.StatusFound {
+		t.Errorf("Expected the redirect not to be followed, got %+v", result)
+	}
+	result = client.Send(context.Background(), Delivery{URL: server.URL + "/slow"})
+	if result.OK() || result.StatusCode != 0 || result.Err == nil {
+		t.Errorf("Expected a timeout, got %+v", result)
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	received := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer server.Close()
+
+	result := NewClient(time.Second, nil).Send(context.Background(), Delivery{URL: server.URL})
+	if result.OK() || !errors.Is(result.Err, ErrForbiddenAddress) || received {
+		t.Errorf("Expected the loopback endpoint to be refused, got %+v", result)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed []netip.Prefix
+		ok      bool
+	}{
+		{"https://hooks.example.com/scs", nil, true},
+		{"https://93.184.216.34/scs", nil, true},
+		{"http://127.0.0.1:8080/", nil, false},
+		{"http://localhost/", nil, false},
+		{"http://api.localhost./", nil, false},
+		{"http://169.254.169.254/latest/meta-data/", nil, false},
+		{"http://10.1.2.3/", nil, false},
+		{"http://192.168.0.10/", nil, false},
+		{"http://[::1]/", nil, false},
+		{"http://[::ffff:127.0.0.1]/", nil, false},
+		{"http://[fd00::1]/", nil, false},
+		{"http://100.64.0.1/", nil, false},
+		{"http://0.0.0.0/", nil, false},
+		{"http://10.1.2.3/", []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}, true},
+		{"http://localhost:9000/", loopback, true},
+	}
+	for _, tt := range tests {
+		err := CheckURL(tt.url, tt.allowed)
+		if (err == nil) != tt.ok || (err != nil && !errors.Is(err, ErrForbiddenAddress)) {
+			t.Errorf("CheckURL(%s, %v) = %v, expected ok %t", tt.url, tt.allowed, err, tt.ok)
+		}
+	}
+}
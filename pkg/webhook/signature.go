@@ -0,0 +1,54 @@
+// Package webhook signs webhook deliveries and verifies their signatures. Receivers can use
+// Verify to check that a delivery comes from scs-user and is recent.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a webhook delivery
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEventType = "Webhook-Event-Type"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm of the signature header value
+const signaturePrefix = "sha256="
+
+// Sign returns the signature header value of the body sent at the timestamp. The timestamp is
+// signed together with the body so that a captured delivery can not be replayed later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp header values of a delivery. Deliveries with a
+// timestamp more than tolerance away from now are rejected.
+func Verify(secret string, timestampHeader string, signatureHeader string, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+	timestamp := time.Unix(unix, 0)
+	if timestamp.Before(now.Add(-tolerance)) || timestamp.After(now.Add(tolerance)) {
+		return errors.New("webhook timestamp is outside the tolerance")
+	}
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) {
+		return errors.New("unsupported webhook signature")
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signatureHeader)) {
+		return errors.New("invalid webhook signature")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	body := []byte(`{"type":"user.created"}`)
+	signature := Sign("secret", now, body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	if signature != Sign("secret", now, body) {
+		t.Error("Expected the signature to be deterministic")
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      string
+		now       time.Time
+		valid     bool
+	}{
+		{"valid", "secret", timestamp, signature, string(body), now, true},
+		{"within tolerance", "secret", timestamp, signature, string(body), now.Add(4 * time.Minute), true},
+		{"wrong secret", "other", timestamp, signature, string(body), now, false},
+		{"modified body", "secret", timestamp, signature, `{"type":"user.deleted"}`, now, false},
+		{"modified timestamp", "secret", strconv.FormatInt(now.Unix()+1, 10), signature, string(body), now, false},
+		{"too old", "secret", timestamp, signature, string(body), now.Add(6 * time.Minute), false},
+		{"invalid timestamp", "secret", "yesterday", signature, string(body), now, false},
+		{"other algorithm", "secret", timestamp, "sha1=abc", string(body), now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, []byte(tt.body), 5*time.Minute, tt.now)
+			if (err == nil) != tt.valid {
+				t.Errorf("Verify() error = %v, expected valid %v", err, tt.valid)
+			}
+		})
+	}
+}